@@ -20,13 +20,17 @@ import (
 	"fmt"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/valpere/peretran/internal/store"
 )
 
-var cacheDBPath string
+var (
+	cacheDBPath       string
+	cacheQualitySince time.Duration
+)
 
 var cacheCmd = &cobra.Command{
 	Use:   "cache",
@@ -131,6 +135,58 @@ var cacheClearCmd = &cobra.Command{
 	},
 }
 
+var cacheQualityCmd = &cobra.Command{
+	Use:   "quality",
+	Short: "Show average arbiter rubric scores per service",
+	Long: `Show the average accuracy, fluency, terminology, style and formatting
+scores (1–5) the arbiter gave each service, to track engine quality over time.
+Results are split by prompt template, so prompt changes can be A/B compared.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := store.New(cacheDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		var since time.Time
+		if cacheQualitySince > 0 {
+			since = time.Now().Add(-cacheQualitySince)
+		}
+		quality, err := db.ServiceQuality(context.Background(), since)
+		if err != nil {
+			return fmt.Errorf("failed to get quality: %w", err)
+		}
+
+		if len(quality) == 0 {
+			fmt.Println("No scored results.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tPROMPT\tSAMPLES\tACCURACY\tFLUENCY\tTERMINOLOGY\tSTYLE\tFORMATTING\tMEAN")
+		for _, q := range quality {
+			tmpl := q.PromptTemplate
+			if tmpl == "" {
+				tmpl = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				q.ServiceName, tmpl, q.Samples, rubricScore(q.Accuracy), rubricScore(q.Fluency),
+				rubricScore(q.Terminology), rubricScore(q.Style), rubricScore(q.Formatting),
+				rubricScore(q.Mean()))
+		}
+		return w.Flush()
+	},
+}
+
+// rubricScore formats an average rubric score, or "-" for a dimension that
+// was never scored.
+func rubricScore(v float64) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
 func init() {
 	rootCmd.AddCommand(cacheCmd)
 
@@ -140,4 +196,7 @@ func init() {
 	cacheCmd.AddCommand(cacheStatsCmd)
 	cacheCmd.AddCommand(cacheDeleteCmd)
 	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheQualityCmd)
+
+	cacheQualityCmd.Flags().DurationVar(&cacheQualitySince, "since", 0, "Only include results from this period (e.g. 720h); 0 means all")
 }
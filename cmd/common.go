@@ -16,10 +16,28 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/valpere/peretran/internal"
+	"github.com/valpere/peretran/internal/arbiter"
+	"github.com/valpere/peretran/internal/chunker"
+	"github.com/valpere/peretran/internal/consistency"
+	"github.com/valpere/peretran/internal/prompts"
+	"github.com/valpere/peretran/internal/refiner"
+	"github.com/valpere/peretran/internal/store"
+	"github.com/valpere/peretran/internal/style"
 	"github.com/valpere/peretran/internal/translator"
+	"github.com/valpere/peretran/internal/validator"
 )
 
 var (
@@ -35,14 +53,53 @@ var (
 	}
 )
 
+// serviceOptions carries the CLI parameters needed to construct translation services.
+type serviceOptions struct {
+	ollamaURL        string
+	ollamaModels     []string
+	openrouterKey    string
+	openrouterModels []string
+	systranKey       string
+	mymemoryEmail    string
+
+	// rotation is the model selection policy for Ollama and OpenRouter
+	// (see translator.RotationPolicies); seed makes it reproducible.
+	rotation string
+	seed     int64
+
+	// ollamaConcurrency and openrouterConcurrency cap in-flight requests
+	// across all models of each backend; modelConcurrency holds per-model
+	// "model=N" caps (a bare "N" applies to every model). Zero is unlimited.
+	ollamaConcurrency     int
+	openrouterConcurrency int
+	modelConcurrency      []string
+
+	// prompts renders the LLM translation prompts; nil means the defaults.
+	prompts *prompts.Set
+}
+
 // buildServices constructs the list of translation services from CLI parameters.
-// ollamaModels and openrouterModels may be nil to use the defaults.
-func buildServices(serviceNames []string, ollamaBaseURL, openrouterAPIKey, systranAPIKey, mymemoryEmailAddr string, ollamaModels, openrouterModels []string) ([]translator.TranslationService, error) {
-	if len(ollamaModels) == 0 {
-		ollamaModels = defaultOllamaModels
+// Empty model lists fall back to the defaults. Model specs may carry a weight
+// ("gemma2:27b=3") used by the weighted rotation policy. With the fan-out
+// policy every model becomes its own service, named "<provider>/<model>".
+func buildServices(serviceNames []string, opts serviceOptions) ([]translator.TranslationService, error) {
+	ollamaSpecs := opts.ollamaModels
+	if len(ollamaSpecs) == 0 {
+		ollamaSpecs = defaultOllamaModels
 	}
-	if len(openrouterModels) == 0 {
-		openrouterModels = defaultOpenRouterModels
+	openrouterSpecs := opts.openrouterModels
+	if len(openrouterSpecs) == 0 {
+		openrouterSpecs = defaultOpenRouterModels
+	}
+
+	policy, err := translator.ParseRotationPolicy(opts.rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	modelLimits, defaultModelLimit, err := translator.ParseModelLimits(opts.modelConcurrency)
+	if err != nil {
+		return nil, err
 	}
 
 	var list []translator.TranslationService
@@ -52,13 +109,41 @@ func buildServices(serviceNames []string, ollamaBaseURL, openrouterAPIKey, systr
 		case "google":
 			list = append(list, translator.NewGoogleService())
 		case "systran":
-			list = append(list, translator.NewSystranService(systranAPIKey))
+			list = append(list, translator.NewSystranService(opts.systranKey))
 		case "mymemory":
-			list = append(list, translator.NewMyMemoryService(mymemoryEmailAddr))
+			list = append(list, translator.NewMyMemoryService(opts.mymemoryEmail))
 		case "ollama":
-			list = append(list, translator.NewOllamaTranslator(ollamaBaseURL, ollamaModels))
+			rot, err := buildRotation(policy, ollamaSpecs, opts.seed)
+			if err != nil {
+				return nil, fmt.Errorf("ollama: %w", err)
+			}
+			svc := translator.NewOllamaTranslator(opts.ollamaURL, rot.Models())
+			svc.SetPrompts(opts.prompts)
+			svc.SetLimiter(translator.NewConcurrencyLimiter(opts.ollamaConcurrency, defaultModelLimit, modelLimits))
+			if policy == translator.RotationFanOut {
+				for _, m := range rot.Models() {
+					list = append(list, svc.ForModel(m))
+				}
+				continue
+			}
+			svc.SetRotation(rot)
+			list = append(list, svc)
 		case "openrouter":
-			list = append(list, translator.NewOpenRouterService(openrouterAPIKey, "", openrouterModels))
+			rot, err := buildRotation(policy, openrouterSpecs, opts.seed)
+			if err != nil {
+				return nil, fmt.Errorf("openrouter: %w", err)
+			}
+			svc := translator.NewOpenRouterService(opts.openrouterKey, "", rot.Models())
+			svc.SetPrompts(opts.prompts)
+			svc.SetLimiter(translator.NewConcurrencyLimiter(opts.openrouterConcurrency, defaultModelLimit, modelLimits))
+			if policy == translator.RotationFanOut {
+				for _, m := range rot.Models() {
+					list = append(list, svc.ForModel(m))
+				}
+				continue
+			}
+			svc.SetRotation(rot)
+			list = append(list, svc)
 		default:
 			fmt.Fprintf(os.Stderr, "Unknown service: %s, skipping\n", name)
 		}
@@ -69,3 +154,568 @@ func buildServices(serviceNames []string, ollamaBaseURL, openrouterAPIKey, systr
 	}
 	return list, nil
 }
+
+// buildRotation parses weighted model specs and creates a model rotation.
+func buildRotation(policy translator.RotationPolicy, specs []string, seed int64) (*translator.ModelRotation, error) {
+	models, weights, err := translator.ParseWeightedModels(specs)
+	if err != nil {
+		return nil, err
+	}
+	return translator.NewModelRotation(policy, models, weights, seed)
+}
+
+// loadStyleProfile reads the named style profile from the database at
+// dbPath. An empty name returns nil. The database is opened independently of
+// the cache, so --style also works with --no-cache.
+func loadStyleProfile(ctx context.Context, dbPath, name string) (*style.Profile, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if dbPath == "" {
+		return nil, fmt.Errorf("--style requires --db")
+	}
+
+	db, err := store.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	p, found, err := db.GetStyleProfile(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load style profile: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown style profile %q (see: peretran style list)", name)
+	}
+	fmt.Fprintf(os.Stderr, "Using style profile: %s\n", p.Name)
+	return p, nil
+}
+
+// refinerOptions carries the CLI parameters needed to construct a Stage 2
+// refiner. Empty model and baseURL fall back to per-provider defaults.
+type refinerOptions struct {
+	provider string
+	model    string
+	baseURL  string
+	apiKey   string
+
+	// openrouterKey is the translation service key, reused by the
+	// openrouter provider when no dedicated refiner key is given.
+	openrouterKey string
+
+	prompts  *prompts.Set
+	style    *style.Profile
+	glossary map[string]string
+	// instructions is the placeholder hint when placeholder mode is active.
+	instructions string
+	maxRounds    int
+}
+
+// buildRefiner constructs the refiner for the selected provider: ollama
+// (local), openrouter, or openai (any OpenAI-compatible endpoint).
+func buildRefiner(opts refinerOptions) (refiner.LLMRefiner, error) {
+	var r refiner.LLMRefiner
+	switch opts.provider {
+	case "", "ollama":
+		model, baseURL := opts.model, opts.baseURL
+		if model == "" {
+			model = "llama3.2"
+		}
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		r = refiner.NewOllamaRefiner(model, baseURL)
+	case "openrouter":
+		key := opts.apiKey
+		if key == "" {
+			key = opts.openrouterKey
+		}
+		if key == "" {
+			return nil, fmt.Errorf("--refiner-provider openrouter requires --refiner-key or --openrouter-key")
+		}
+		model := opts.model
+		if model == "" {
+			model = defaultOpenRouterModels[0]
+		}
+		r = refiner.NewOpenRouterRefiner(model, opts.baseURL, key)
+	case "openai":
+		key := opts.apiKey
+		if key == "" {
+			key = os.Getenv("OPENAI_API_KEY")
+		}
+		if key == "" {
+			return nil, fmt.Errorf("--refiner-provider openai requires --refiner-key or OPENAI_API_KEY")
+		}
+		model := opts.model
+		if model == "" {
+			model = "gpt-4o-mini"
+		}
+		r = refiner.NewOpenAIRefiner(model, opts.baseURL, key)
+	default:
+		return nil, fmt.Errorf("unknown refiner provider %q (valid: ollama, openrouter, openai)", opts.provider)
+	}
+
+	r.SetPrompts(opts.prompts)
+	r.SetStyle(opts.style)
+	r.SetGlossary(opts.glossary)
+	r.SetInstructions(opts.instructions)
+	r.SetMaxRounds(opts.maxRounds)
+	return r, nil
+}
+
+// buildRefineGuard returns the guardrails applied to refinements, or nil when
+// disabled. With backTranslate, the first service translates the draft and
+// the refinement back to the source language to detect semantic drift.
+func buildRefineGuard(enabled, backTranslate bool, services []translator.TranslationService, cfg translator.ServiceConfig) *refiner.Guard {
+	if !enabled {
+		return nil
+	}
+
+	g := refiner.NewGuard(validator.New())
+	if backTranslate && len(services) > 0 {
+		svc := services[0]
+		g.SetBackTranslator(func(ctx context.Context, text, sourceLang, targetLang string) (string, error) {
+			res, err := svc.Translate(ctx, cfg, translator.TranslateRequest{
+				Text:       text,
+				SourceLang: sourceLang,
+				TargetLang: targetLang,
+			})
+			if err != nil {
+				return "", err
+			}
+			return res.TranslatedText, nil
+		}, refiner.DefaultMaxSimilarityDrop)
+	}
+	return g
+}
+
+// stage1Key fingerprints the settings that determine a Stage 1 draft: the
+// services and their models, everything rendered into the translation
+// prompts and, when arb is non-nil, the arbiter. Drafts are reused
+// automatically only by runs with the same key.
+func stage1Key(serviceNames []string, svc serviceOptions, arb *arbiterOptions, glossary map[string]string, styleGuide, instructions string) string {
+	parts := []string{
+		strings.Join(serviceNames, ","),
+		strings.Join(svc.ollamaModels, ","),
+		strings.Join(svc.openrouterModels, ","),
+		svc.rotation,
+		strconv.FormatInt(svc.seed, 10),
+		svc.prompts.ID(prompts.Translate),
+		svc.prompts.ID(prompts.TranslateSystem),
+		glossaryFingerprint(glossary),
+		styleGuide,
+		instructions,
+	}
+	if arb != nil {
+		parts = append(parts, arb.provider, arb.model, arb.baseURL, arb.mode,
+			arb.prompts.ID(prompts.Arbiter), arb.prompts.ID(prompts.ArbiterPairwise))
+	}
+	return settingsKey("s1", parts)
+}
+
+// refineKey fingerprints the Stage 2 settings. A translation memory entry
+// refined under a different key is not reused; its draft is re-refined
+// instead.
+func refineKey(opts refinerOptions, mode string, guard, backTranslate bool) string {
+	parts := []string{
+		opts.provider, opts.model, opts.baseURL, mode,
+		opts.prompts.ID(prompts.Refine),
+		glossaryFingerprint(opts.glossary),
+		opts.style.Guide(),
+		opts.instructions,
+		strconv.FormatBool(guard),
+		strconv.FormatBool(backTranslate),
+	}
+	if mode == "critique" {
+		parts = append(parts, strconv.Itoa(opts.maxRounds),
+			opts.prompts.ID(prompts.Critique), opts.prompts.ID(prompts.Revise))
+	}
+	return settingsKey("rf", parts)
+}
+
+// glossaryFingerprint renders glossary terms in a stable order.
+func glossaryFingerprint(glossary map[string]string) string {
+	terms := make([]string, 0, len(glossary))
+	for src, dst := range glossary {
+		terms = append(terms, src+"="+dst)
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, "\n")
+}
+
+// settingsKey hashes parts into a short key such as "s1-1a2b3c4d5e6f".
+func settingsKey(prefix string, parts []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return prefix + "-" + hex.EncodeToString(sum[:6])
+}
+
+// loadStage1Draft returns a saved Stage 1 draft of text and the service that
+// produced it. It prefers a draft saved under key; with anySettings it falls
+// back to the latest draft whatever settings produced it.
+func loadStage1Draft(ctx context.Context, db *store.Store, text, sourceLang, targetLang, key string, anySettings bool) (string, string, bool) {
+	draft, service, found, err := db.LatestStage1Draft(ctx, text, sourceLang, targetLang, key)
+	if err == nil && !found && anySettings {
+		draft, service, found, err = db.LatestStage1Draft(ctx, text, sourceLang, targetLang, "")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read Stage 1 drafts: %v\n", err)
+		return "", "", false
+	}
+	return draft, service, found
+}
+
+// contextKey extends a Stage 1 settings key with the hash of a chunk's
+// surrounding source text (see store.ContextHash), since the context is
+// part of the prompt.
+func contextKey(key, contextHash string) string {
+	if contextHash == "" {
+		return key
+	}
+	return key + "@" + contextHash
+}
+
+// loadChunkMemory returns the remembered translation of a chunk translated
+// with the surrounding text identified by contextHash. A refined entry is
+// used only if it was refined with refKey.
+func loadChunkMemory(ctx context.Context, db *store.Store, chunk, sourceLang, targetLang, contextHash, refKey string) (string, bool) {
+	if key, found, err := db.GetMemoryRefineKey(ctx, chunk, sourceLang, targetLang, contextHash); err != nil || !found || key != refKey {
+		return "", false
+	}
+	cached, found, err := db.GetCachedTranslationInContext(ctx, chunk, sourceLang, targetLang, contextHash)
+	return cached, err == nil && found
+}
+
+// runRecord is what translating one chunk, CSV cell or document piece
+// produced, as kept in the history behind `cache stats`, `cache quality`
+// and --hedge.
+type runRecord struct {
+	Source     string
+	SourceLang string
+	TargetLang string
+	Results    []translator.ServiceResult
+	// Scores are the arbiter's rubric scores, keyed by service name.
+	Scores map[string]internal.RubricScores
+	// Rounds are the critique rounds of --refine-mode critique.
+	Rounds []refiner.Round
+}
+
+// saveRunRecord saves r as a translation request with its service results,
+// latencies, scores and critique rounds, and returns the request ID.
+func saveRunRecord(ctx context.Context, db *store.Store, r runRecord) string {
+	reqID := uuid.New().String()
+	_ = db.SaveRequest(ctx, internal.TranslationRequest{
+		ID:         reqID,
+		SourceText: r.Source,
+		SourceLang: r.SourceLang,
+		TargetLang: r.TargetLang,
+		Timestamp:  time.Now(),
+	})
+	for _, res := range r.Results {
+		_ = db.SaveResult(ctx, reqID, res.ServiceName, res.TranslatedText, res.Confidence, int(res.Latency.Milliseconds()), res.Error)
+		if tmpl := res.Metadata["prompt_template"]; tmpl != "" {
+			_ = db.SaveResultPromptTemplate(ctx, reqID, res.ServiceName, tmpl)
+		}
+	}
+	for name, scores := range r.Scores {
+		_ = db.SaveResultScores(ctx, reqID, name, scores)
+	}
+	for _, round := range r.Rounds {
+		_ = db.SaveRefinementRound(ctx, reqID, round.Number, refiner.FormatIssues(round.Issues), round.Revised, round.Err)
+	}
+	return reqID
+}
+
+// tokenBudget returns the context window and tokenizer estimate of the most
+// constrained LLM model among services. Ollama models are capped at
+// chunker.DefaultContextTokens, Ollama's small default num_ctx, and
+// OpenRouter replies at its max_tokens. contextTokens > 0 overrides the
+// window. Services without models (Google, Systran, ...) do not constrain
+// the budget; with no LLM model at all the default profile is used.
+func tokenBudget(services []translator.TranslationService, contextTokens int) (chunker.TokenBudget, chunker.Tokenizer) {
+	var budget chunker.TokenBudget
+	var est chunker.Estimator
+	found := false
+
+	for _, svc := range services {
+		rot, ok := svc.(translator.ModelRotator)
+		if !ok {
+			continue
+		}
+		_, isOllama := svc.(*translator.OllamaTranslator)
+		if _, ok := svc.(*translator.OpenRouterService); ok {
+			budget.MaxOutputTokens = translator.OpenRouterMaxTokens
+		}
+		for _, m := range rot.GetModels() {
+			p := chunker.ProfileFor(m)
+			window := p.ContextTokens
+			if isOllama && window > chunker.DefaultContextTokens {
+				window = chunker.DefaultContextTokens
+			}
+			if !found || window < budget.ContextTokens {
+				budget.ContextTokens = window
+			}
+			// Keep the most pessimistic estimate per script.
+			if !found {
+				est = p.Tokenizer
+			} else {
+				est.Latin = math.Min(est.Latin, p.Tokenizer.Latin)
+				est.Cyrillic = math.Min(est.Cyrillic, p.Tokenizer.Cyrillic)
+				est.CJK = math.Min(est.CJK, p.Tokenizer.CJK)
+				est.Other = math.Min(est.Other, p.Tokenizer.Other)
+			}
+			found = true
+		}
+	}
+
+	if !found {
+		p := chunker.ProfileFor("")
+		budget.ContextTokens, est = p.ContextTokens, p.Tokenizer
+	}
+	if contextTokens > 0 {
+		budget.ContextTokens = contextTokens
+	}
+	return budget, est
+}
+
+// promptOverhead estimates the tokens every translation request spends
+// besides the text: the larger of the Ollama and chat prompt templates,
+// rendered with req's glossary, instructions and style guide and with
+// contextWords words of previous translation and of source text on each
+// side of the chunk.
+func promptOverhead(set *prompts.Set, tok chunker.Tokenizer, contextWords int, req translator.TranslateRequest) int {
+	filler := strings.TrimSpace(strings.Repeat("context ", contextWords))
+	data := prompts.Data{
+		SourceLang:   req.SourceLang,
+		TargetLang:   req.TargetLang,
+		Context:      filler,
+		SourceBefore: filler,
+		SourceAfter:  filler,
+		Glossary:     req.GlossaryTerms,
+		Instructions: req.Instructions,
+		StyleGuide:   req.StyleGuide,
+	}
+	overhead := 0
+	for _, name := range []string{prompts.Translate, prompts.TranslateSystem} {
+		if prompt, err := set.Render(name, data); err == nil {
+			if n := tok.CountTokens(prompt); n > overhead {
+				overhead = n
+			}
+		}
+	}
+	return overhead
+}
+
+// splitTruncated separates the results that look cut off — the backend
+// reported a length limit, or the text is far shorter than source — from
+// the complete ones.
+func splitTruncated(source string, results []translator.ServiceResult) (complete, truncated []translator.ServiceResult) {
+	for _, r := range results {
+		if r.Metadata["finish_reason"] == "length" || chunker.LooksTruncated(source, r.TranslatedText) {
+			truncated = append(truncated, r)
+		} else {
+			complete = append(complete, r)
+		}
+	}
+	return complete, truncated
+}
+
+// validateRefineMode checks the --refine-mode value.
+func validateRefineMode(mode string) error {
+	switch mode {
+	case "literary", "critique":
+		return nil
+	default:
+		return fmt.Errorf("unknown refine mode %q (valid: literary, critique)", mode)
+	}
+}
+
+// printCritiqueReport writes the critique of every refinement round to
+// stderr; label names the chunk or cell.
+func printCritiqueReport(label string, res *refiner.Refinement) {
+	for _, r := range res.Rounds {
+		switch {
+		case r.Err != "":
+			fmt.Fprintf(os.Stderr, "Critique round %d (%s): stopped: %s\n", r.Number, label, r.Err)
+		case len(r.Issues) == 0:
+			fmt.Fprintf(os.Stderr, "Critique round %d (%s): no issues\n", r.Number, label)
+		default:
+			fmt.Fprintf(os.Stderr, "Critique round %d (%s): %d issue(s)\n%s\n", r.Number, label, len(r.Issues), refiner.FormatIssues(r.Issues))
+		}
+	}
+	if !res.Converged {
+		fmt.Fprintf(os.Stderr, "Critique (%s): stopped after %d round(s) without converging\n", label, len(res.Rounds))
+	}
+}
+
+// printConsistencyReport prints the terms whose renderings differed across
+// chunks and every change the consistency pass made.
+func printConsistencyReport(report *consistency.Report) {
+	inconsistent := 0
+	for _, t := range report.Terms {
+		if t.Consistent() {
+			continue
+		}
+		inconsistent++
+		source := "majority"
+		if t.FromGlossary {
+			source = "glossary"
+		}
+		variants := make([]string, 0, len(t.Variants))
+		for _, v := range t.Variants {
+			variants = append(variants, fmt.Sprintf("%q ×%d", v.Rendering, len(v.Chunks)))
+		}
+		fmt.Fprintf(os.Stderr, "Consistency: %q → %q (%s); found %s\n", t.Term, t.Chosen, source, strings.Join(variants, ", "))
+	}
+	for _, c := range report.Changes {
+		fmt.Fprintf(os.Stderr, "Consistency: chunk %d: %q %q → %q\n", c.Chunk+1, c.Term, c.From, c.To)
+	}
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Consistency warning: %s\n", w)
+	}
+	fmt.Fprintf(os.Stderr, "Consistency pass: %d term(s) checked, %d inconsistent, %d change(s)\n", len(report.Terms), inconsistent, len(report.Changes))
+}
+
+// arbiterOptions carries the CLI parameters needed to construct an arbiter.
+// Empty model and baseURL fall back to per-provider defaults.
+type arbiterOptions struct {
+	provider string
+	model    string
+	baseURL  string
+	apiKey   string
+
+	// mode is "single" (one prompt with every candidate) or "tournament"
+	// (order-swapped pairwise comparisons aggregated with Bradley-Terry).
+	mode string
+
+	// openrouterKey is the translation service key, reused by the
+	// openrouter provider when no dedicated arbiter key is given.
+	openrouterKey string
+
+	// glossary is used by the consensus provider to score term compliance.
+	glossary map[string]string
+
+	// prompts renders the LLM arbiter prompts; nil means the defaults.
+	prompts *prompts.Set
+
+	// style is the style profile candidates are judged against; nil means none.
+	style *style.Profile
+}
+
+// buildArbiter constructs the arbiter for the selected provider and mode.
+// In tournament mode the provider's model judges candidates pairwise.
+func buildArbiter(opts arbiterOptions) (arbiter.Arbiter, error) {
+	base, err := buildProviderArbiter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.mode {
+	case "", "single":
+		return base, nil
+	case "tournament":
+		judge, ok := base.(arbiter.PairwiseJudge)
+		if !ok {
+			return nil, fmt.Errorf("--arbiter-mode tournament is not supported by arbiter provider %q", opts.provider)
+		}
+		return arbiter.NewTournamentArbiter(judge), nil
+	default:
+		return nil, fmt.Errorf("unknown arbiter mode %q (valid: single, tournament)", opts.mode)
+	}
+}
+
+// buildProviderArbiter constructs the arbiter for the selected provider:
+// ollama (local), openrouter, openai (any OpenAI-compatible endpoint), or
+// consensus (metric-based, no LLM).
+func buildProviderArbiter(opts arbiterOptions) (arbiter.Arbiter, error) {
+	switch opts.provider {
+	case "consensus":
+		return arbiter.NewConsensusArbiter(opts.glossary, validator.New()), nil
+	case "", "ollama":
+		model, baseURL := opts.model, opts.baseURL
+		if model == "" {
+			model = "llama3.2"
+		}
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		a := arbiter.NewOllamaArbiter(model, baseURL)
+		a.SetValidator(validator.New())
+		a.SetPrompts(opts.prompts)
+		a.SetStyle(opts.style)
+		return a, nil
+	case "openrouter":
+		key := opts.apiKey
+		if key == "" {
+			key = opts.openrouterKey
+		}
+		if key == "" {
+			return nil, fmt.Errorf("--arbiter-provider openrouter requires --arbiter-key or --openrouter-key")
+		}
+		model := opts.model
+		if model == "" {
+			model = defaultOpenRouterModels[0]
+		}
+		a := arbiter.NewOpenRouterArbiter(model, opts.baseURL, key)
+		a.SetValidator(validator.New())
+		a.SetPrompts(opts.prompts)
+		a.SetStyle(opts.style)
+		return a, nil
+	case "openai":
+		key := opts.apiKey
+		if key == "" {
+			key = os.Getenv("OPENAI_API_KEY")
+		}
+		if key == "" {
+			return nil, fmt.Errorf("--arbiter-provider openai requires --arbiter-key or OPENAI_API_KEY")
+		}
+		model := opts.model
+		if model == "" {
+			model = "gpt-4o-mini"
+		}
+		a := arbiter.NewOpenAIArbiter(model, opts.baseURL, key)
+		a.SetValidator(validator.New())
+		a.SetPrompts(opts.prompts)
+		a.SetStyle(opts.style)
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown arbiter provider %q (valid: ollama, openrouter, openai, consensus)", opts.provider)
+	}
+}
+
+const (
+	// hedgePercentile is the latency percentile after which a hedged request fires.
+	hedgePercentile = 0.95
+	// hedgeMinSamples is the number of recorded calls required before a
+	// service's latency percentile is trusted.
+	hedgeMinSamples = 20
+	// hedgeMaxSamples bounds the history window so the threshold follows
+	// recent behaviour (model swaps, hardware changes).
+	hedgeMaxSamples = 500
+)
+
+// buildHedgeDelays learns the p95 latency of every model-rotating service
+// from translation_results. Services without enough history are not hedged.
+func buildHedgeDelays(ctx context.Context, db *store.Store, serviceList []translator.TranslationService) map[string]time.Duration {
+	delays := make(map[string]time.Duration)
+	if db == nil {
+		return delays
+	}
+	for _, svc := range serviceList {
+		if _, ok := svc.(translator.ModelRotator); !ok {
+			continue
+		}
+		d, ok, err := db.LatencyPercentile(ctx, svc.Name(), hedgePercentile, hedgeMinSamples, hedgeMaxSamples)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load latency history for %s: %v\n", svc.Name(), err)
+			continue
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Hedging disabled for %s: fewer than %d recorded calls\n", svc.Name(), hedgeMinSamples)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Hedging %s after %v (p95)\n", svc.Name(), d)
+		delays[svc.Name()] = d
+	}
+	return delays
+}
@@ -24,10 +24,12 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/valpere/peretran/internal"
 	"github.com/valpere/peretran/internal/arbiter"
 	"github.com/valpere/peretran/internal/detector"
 	"github.com/valpere/peretran/internal/orchestrator"
 	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/prompts"
 	"github.com/valpere/peretran/internal/refiner"
 	"github.com/valpere/peretran/internal/store"
 	"github.com/valpere/peretran/internal/translator"
@@ -40,21 +42,42 @@ var (
 	csvTargetLang string
 	csvColumns    []int
 
-	csvServices     []string
-	csvUseArbiter   bool
-	csvArbiterModel string
-	csvArbiterURL   string
+	csvServices        []string
+	csvUseArbiter      bool
+	csvArbiterProvider string
+	csvArbiterModel    string
+	csvArbiterURL      string
+	csvArbiterKey      string
+	csvArbiterMode     string
+	csvReviewBelow     float64
+	csvPromptsDir      string
+	csvStyleName       string
 
 	csvOllamaURL        string
 	csvOllamaModels     []string
 	csvOpenrouterKey    string
 	csvOpenrouterModels []string
-	csvSystranKey       string
-	csvMymemoryEmail    string
+	csvModelRotation    string
+	csvSeed             int64
 
-	csvUseRefine    bool
-	csvRefinerModel string
-	csvRefinerURL   string
+	csvSystranKey    string
+	csvMymemoryEmail string
+
+	csvOllamaConcurrency     int
+	csvOpenrouterConcurrency int
+	csvModelConcurrency      []string
+
+	csvUseRefine       bool
+	csvRefineOnly      bool
+	csvRefineMode      string
+	csvRefineRounds    int
+	csvRefinerProvider string
+	csvRefinerModel    string
+	csvRefinerURL      string
+	csvRefinerKey      string
+
+	csvNoRefineGuard       bool
+	csvRefineBacktranslate bool
 
 	csvMaxRetries int
 	csvDBPath     string
@@ -65,6 +88,8 @@ var (
 	csvFuzzyThreshold float64
 	csvUsePlaceholder bool
 	csvUseGlossary    bool
+
+	csvUseHedge bool
 )
 
 var csvCmd = &cobra.Command{
@@ -85,6 +110,15 @@ Example:
 		if csvInputFile == csvOutputFile {
 			return fmt.Errorf("input file and output file cannot be the same")
 		}
+		if err := validateRefineMode(csvRefineMode); err != nil {
+			return err
+		}
+		if csvRefineOnly {
+			if csvNoCache || csvDBPath == "" {
+				return fmt.Errorf("--refine-only reads the saved Stage 1 drafts and cannot be used with --no-cache")
+			}
+			csvUseRefine = true
+		}
 
 		f, err := os.Open(csvInputFile)
 		if err != nil {
@@ -114,6 +148,11 @@ Example:
 			}
 		}
 
+		styleProfile, err := loadStyleProfile(ctx, csvDBPath, csvStyleName)
+		if err != nil {
+			return err
+		}
+
 		// Open store for cache and checkpoint support.
 		var db *store.Store
 		if !csvNoCache && csvDBPath != "" {
@@ -168,19 +207,97 @@ Example:
 			phHint = placeholder.InstructionHint()
 		}
 
-		serviceList, err := buildServices(csvServices, csvOllamaURL, csvOpenrouterKey, csvSystranKey, csvMymemoryEmail, csvOllamaModels, csvOpenrouterModels)
+		promptSet, err := prompts.Load(csvPromptsDir)
+		if err != nil {
+			return err
+		}
+
+		svcOpts := serviceOptions{
+			ollamaURL:        csvOllamaURL,
+			ollamaModels:     csvOllamaModels,
+			openrouterKey:    csvOpenrouterKey,
+			openrouterModels: csvOpenrouterModels,
+			systranKey:       csvSystranKey,
+			mymemoryEmail:    csvMymemoryEmail,
+			rotation:         csvModelRotation,
+			seed:             csvSeed,
+
+			ollamaConcurrency:     csvOllamaConcurrency,
+			openrouterConcurrency: csvOpenrouterConcurrency,
+			modelConcurrency:      csvModelConcurrency,
+
+			prompts: promptSet,
+		}
+		arbOpts := arbiterOptions{
+			provider:      csvArbiterProvider,
+			model:         csvArbiterModel,
+			baseURL:       csvArbiterURL,
+			apiKey:        csvArbiterKey,
+			mode:          csvArbiterMode,
+			openrouterKey: csvOpenrouterKey,
+			glossary:      glossaryTerms,
+			prompts:       promptSet,
+			style:         styleProfile,
+		}
+		refOpts := refinerOptions{
+			provider:      csvRefinerProvider,
+			model:         csvRefinerModel,
+			baseURL:       csvRefinerURL,
+			apiKey:        csvRefinerKey,
+			openrouterKey: csvOpenrouterKey,
+			prompts:       promptSet,
+			style:         styleProfile,
+			glossary:      glossaryTerms,
+			instructions:  phHint,
+			maxRounds:     csvRefineRounds,
+		}
+
+		var keyArbiter *arbiterOptions
+		if csvUseArbiter {
+			keyArbiter = &arbOpts
+		}
+		draftKey := stage1Key(csvServices, svcOpts, keyArbiter, glossaryTerms, styleProfile.Guide(), phHint)
+		refKey := ""
+		if csvUseRefine {
+			refKey = refineKey(refOpts, csvRefineMode, !csvNoRefineGuard, csvRefineBacktranslate)
+		}
+
+		serviceList, err := buildServices(csvServices, svcOpts)
 		if err != nil {
 			return err
 		}
 
 		cfg := translator.ServiceConfig{}
 
+		var hedgeAfter map[string]time.Duration
+		if csvUseHedge {
+			hedgeAfter = buildHedgeDelays(ctx, db, serviceList)
+		}
+
 		orch := orchestrator.New(serviceList, orchestrator.OrchestratorConfig{
 			Timeout:     30 * time.Second,
 			MinServices: 1,
 			MaxAttempts: csvMaxRetries,
+			HedgeAfter:  hedgeAfter,
 		})
 
+		var arb arbiter.Arbiter
+		if csvUseArbiter && !csvRefineOnly {
+			arb, err = buildArbiter(arbOpts)
+			if err != nil {
+				return err
+			}
+		}
+
+		var ref refiner.LLMRefiner
+		if csvUseRefine {
+			ref, err = buildRefiner(refOpts)
+			if err != nil {
+				return err
+			}
+		}
+		guard := buildRefineGuard(csvUseRefine && !csvNoRefineGuard, csvRefineBacktranslate, serviceList, cfg)
+
 		// Determine which columns to translate.
 		colSet := make(map[int]bool, len(csvColumns))
 		for _, c := range csvColumns {
@@ -210,8 +327,17 @@ Example:
 					continue
 				}
 
-				// Check translation memory cache (exact match).
-				if db != nil {
+				// Check translation memory cache (exact match). The memory
+				// is not style-specific, so it is bypassed with --style; a
+				// refined entry is reused only under the current refiner
+				// settings.
+				useMemory := db != nil && styleProfile == nil && !csvRefineOnly
+				if useMemory && csvUseRefine {
+					if key, found, keyErr := db.GetMemoryRefineKey(ctx, cell, srcLang, csvTargetLang, ""); keyErr == nil && found && key != refKey {
+						useMemory = false
+					}
+				}
+				if useMemory {
 					if cached, found, cacheErr := db.GetCachedTranslation(ctx, cell, srcLang, csvTargetLang); cacheErr == nil && found {
 						out[rowIdx][colIdx] = cached
 						if checkpointID != "" {
@@ -222,7 +348,7 @@ Example:
 				}
 
 				// Fuzzy cache check.
-				if csvFuzzyThreshold > 0 && db != nil {
+				if csvFuzzyThreshold > 0 && useMemory {
 					if cached, found, cacheErr := db.FuzzyGetCachedTranslation(ctx, cell, srcLang, csvTargetLang, csvFuzzyThreshold); cacheErr == nil && found {
 						out[rowIdx][colIdx] = cached
 						if checkpointID != "" {
@@ -245,33 +371,72 @@ Example:
 					TargetLang:    csvTargetLang,
 					GlossaryTerms: glossaryTerms,
 					Instructions:  phHint,
+					StyleGuide:    styleProfile.Guide(),
 				}
 
-				result := orch.Execute(ctx, cfg, req)
-				if result.Succeeded == 0 {
-					fmt.Fprintf(os.Stderr, "Row %d col %d: all services failed, keeping original\n", rowIdx, colIdx)
+				// Stage 1: reuse a saved draft when only Stage 2 is re-run.
+				var translated, serviceUsed string
+				var draftReused bool
+				if db != nil && csvUseRefine {
+					translated, serviceUsed, draftReused = loadStage1Draft(ctx, db, cellToTranslate, srcLang, csvTargetLang, draftKey, csvRefineOnly)
+				}
+				if !draftReused && csvRefineOnly {
+					fmt.Fprintf(os.Stderr, "Row %d col %d: no saved Stage 1 draft, keeping original\n", rowIdx, colIdx)
 					continue
 				}
 
-				translated := result.Results[0].TranslatedText
+				var result *orchestrator.OrchestratorResult
+				if !draftReused {
+					result = orch.Execute(ctx, cfg, req)
+					if result.Succeeded == 0 {
+						fmt.Fprintf(os.Stderr, "Row %d col %d: all services failed, keeping original\n", rowIdx, colIdx)
+						continue
+					}
+					translated = result.Results[0].TranslatedText
+					serviceUsed = result.Results[0].ServiceName
+				}
 
-				if csvUseArbiter && len(result.Results) > 1 {
-					arb := arbiter.NewOllamaArbiter(csvArbiterModel, csvArbiterURL)
+				var scores map[string]internal.RubricScores
+				if arb != nil && !draftReused && len(result.Results) > 1 {
 					eval, arbErr := arb.Evaluate(ctx, cellToTranslate, srcLang, csvTargetLang, result.Results)
 					if arbErr != nil {
 						fmt.Fprintf(os.Stderr, "Arbiter failed row %d col %d: %v\n", rowIdx, colIdx, arbErr)
 					} else {
 						translated = eval.CompositeText
+						serviceUsed = eval.SelectedService
+						scores = eval.Scores
+						if best, ok := eval.BestAccuracy(); ok && best < csvReviewBelow {
+							fmt.Fprintf(os.Stderr, "Needs human review: row %d col %d best accuracy %.1f/5 is below %.1f\n", rowIdx, colIdx, best, csvReviewBelow)
+						}
 					}
 				}
 
-				if csvUseRefine {
-					ref := refiner.NewOllamaRefiner(csvRefinerModel, csvRefinerURL)
-					refined, refErr := ref.Refine(ctx, srcLang, csvTargetLang, cellToTranslate, translated)
-					if refErr != nil {
-						fmt.Fprintf(os.Stderr, "Refiner failed row %d col %d: %v\n", rowIdx, colIdx, refErr)
+				stage1Draft := translated
+				var rounds []refiner.Round
+				if ref != nil {
+					draftText := translated
+					if csvRefineMode == "critique" {
+						res, refErr := ref.RefineWithCritique(ctx, srcLang, csvTargetLang, cellToTranslate, translated)
+						if refErr != nil {
+							fmt.Fprintf(os.Stderr, "Refiner failed row %d col %d: %v\n", rowIdx, colIdx, refErr)
+						} else {
+							printCritiqueReport(fmt.Sprintf("row %d col %d", rowIdx, colIdx), res)
+							translated = res.Text
+							rounds = res.Rounds
+						}
 					} else {
-						translated = refined
+						refined, refErr := ref.Refine(ctx, srcLang, csvTargetLang, cellToTranslate, translated)
+						if refErr != nil {
+							fmt.Fprintf(os.Stderr, "Refiner failed row %d col %d: %v\n", rowIdx, colIdx, refErr)
+						} else {
+							translated = refined
+						}
+					}
+					if guard != nil && translated != draftText {
+						if gErr := guard.Check(ctx, srcLang, csvTargetLang, cellToTranslate, draftText, translated); gErr != nil {
+							fmt.Fprintf(os.Stderr, "Refinement rejected row %d col %d: %v; using draft\n", rowIdx, colIdx, gErr)
+							translated = draftText
+						}
 					}
 				}
 
@@ -282,11 +447,28 @@ Example:
 
 				out[rowIdx][colIdx] = translated
 
-				// Persist to cache and checkpoint.
+				// Persist to cache, history and checkpoint.
 				if db != nil {
-					draftText := result.Results[0].TranslatedText
-					serviceUsed := result.Results[0].ServiceName
-					_ = db.SaveToMemory(ctx, cell, srcLang, csvTargetLang, translated, draftText, serviceUsed)
+					if result != nil || len(rounds) > 0 {
+						rec := runRecord{
+							Source:     cellToTranslate,
+							SourceLang: srcLang,
+							TargetLang: csvTargetLang,
+							Scores:     scores,
+							Rounds:     rounds,
+						}
+						if result != nil {
+							rec.Results = result.Results
+						}
+						saveRunRecord(ctx, db, rec)
+					}
+					if styleProfile == nil {
+						_ = db.SaveToMemory(ctx, cell, srcLang, csvTargetLang, translated, stage1Draft, serviceUsed)
+						_ = db.SetMemoryRefineKey(ctx, cell, srcLang, csvTargetLang, "", refKey)
+					}
+					if !draftReused {
+						_ = db.SaveStage1Draft(ctx, cellToTranslate, srcLang, csvTargetLang, stage1Draft, serviceUsed, draftKey)
+					}
 					if checkpointID != "" {
 						_ = db.SaveCSVCell(ctx, checkpointID, rowIdx, colIdx, translated)
 					}
@@ -330,17 +512,36 @@ func init() {
 
 	csvCmd.Flags().StringSliceVar(&csvServices, "services", []string{"google"}, "Translation services to use (comma-separated)")
 	csvCmd.Flags().BoolVar(&csvUseArbiter, "arbiter", false, "Use LLM arbiter to select best translation")
-	csvCmd.Flags().StringVar(&csvArbiterModel, "arbiter-model", "llama3.2", "Arbiter model name")
-	csvCmd.Flags().StringVar(&csvArbiterURL, "arbiter-url", "http://localhost:11434", "Arbiter Ollama URL")
+	csvCmd.Flags().StringVar(&csvArbiterProvider, "arbiter-provider", "ollama", "Arbiter backend: ollama, openrouter, openai (any OpenAI-compatible endpoint), consensus (metric-based, no LLM)")
+	csvCmd.Flags().StringVar(&csvArbiterModel, "arbiter-model", "", "Arbiter model name (default depends on provider: llama3.2, first OpenRouter model, gpt-4o-mini)")
+	csvCmd.Flags().StringVar(&csvArbiterURL, "arbiter-url", "", "Arbiter endpoint URL (default depends on provider)")
+	csvCmd.Flags().StringVar(&csvArbiterKey, "arbiter-key", "", "Arbiter API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
+	csvCmd.Flags().StringVar(&csvArbiterMode, "arbiter-mode", "single", "Arbiter mode: single (one prompt with all candidates), tournament (pairwise comparisons with order swapping, Bradley-Terry ranking)")
+	csvCmd.Flags().Float64Var(&csvReviewBelow, "review-below", 0, "Flag cells for human review when the best arbiter accuracy score (1-5) is below this value; 0 disables")
+
+	csvCmd.Flags().StringVar(&csvStyleName, "style", "", "Style profile from the database to translate with (see: peretran style list); bypasses the translation memory")
+	csvCmd.Flags().StringVar(&csvPromptsDir, "prompts-dir", "", "Directory of *.tmpl prompt templates overriding the built-in ones (see: peretran prompts list)")
 
 	csvCmd.Flags().BoolVar(&csvUseRefine, "refine", false, "Enable Stage 2 literary refinement")
-	csvCmd.Flags().StringVar(&csvRefinerModel, "refiner-model", "llama3.2", "Refiner model name")
-	csvCmd.Flags().StringVar(&csvRefinerURL, "refiner-url", "http://localhost:11434", "Refiner Ollama URL")
+	csvCmd.Flags().BoolVar(&csvRefineOnly, "refine-only", false, "Skip Stage 1 and refine the Stage 1 drafts saved by an earlier run (implies --refine)")
+	csvCmd.Flags().StringVar(&csvRefineMode, "refine-mode", "literary", "Refinement mode: literary (one polishing pass), critique (critique-then-revise rounds)")
+	csvCmd.Flags().IntVar(&csvRefineRounds, "refine-rounds", refiner.DefaultCritiqueRounds, "Maximum critique-then-revise rounds in critique mode")
+	csvCmd.Flags().BoolVar(&csvNoRefineGuard, "no-refine-guard", false, "Accept refinements without checking length, numbers, URLs, placeholders and language against the draft")
+	csvCmd.Flags().BoolVar(&csvRefineBacktranslate, "refine-backtranslate", false, "Also reject refinements whose back-translation (by the first service) drifts from the source")
+	csvCmd.Flags().StringVar(&csvRefinerProvider, "refiner-provider", "ollama", "Refiner backend: ollama, openrouter, openai (any OpenAI-compatible endpoint)")
+	csvCmd.Flags().StringVar(&csvRefinerModel, "refiner-model", "", "Refiner model name (default depends on provider: llama3.2, first OpenRouter model, gpt-4o-mini)")
+	csvCmd.Flags().StringVar(&csvRefinerURL, "refiner-url", "", "Refiner endpoint URL (default depends on provider)")
+	csvCmd.Flags().StringVar(&csvRefinerKey, "refiner-key", "", "Refiner API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
 
 	csvCmd.Flags().StringVar(&csvOllamaURL, "ollama-url", "http://localhost:11434", "Ollama base URL")
-	csvCmd.Flags().StringSliceVar(&csvOllamaModels, "ollama-models", nil, "Ollama models to rotate (default list used if empty)")
+	csvCmd.Flags().StringSliceVar(&csvOllamaModels, "ollama-models", nil, "Ollama models to rotate, optionally weighted as model=N (default list used if empty)")
 	csvCmd.Flags().StringVar(&csvOpenrouterKey, "openrouter-key", "", "OpenRouter API key")
-	csvCmd.Flags().StringSliceVar(&csvOpenrouterModels, "openrouter-models", nil, "OpenRouter models to rotate (default list used if empty)")
+	csvCmd.Flags().StringSliceVar(&csvOpenrouterModels, "openrouter-models", nil, "OpenRouter models to rotate, optionally weighted as model=N (default list used if empty)")
+	csvCmd.Flags().StringVar(&csvModelRotation, "model-rotation", "random", "Ollama/OpenRouter model selection: random, round-robin, weighted, sticky, fixed, fan-out")
+	csvCmd.Flags().Int64Var(&csvSeed, "seed", 0, "Seed for model rotation (0 = non-reproducible)")
+	csvCmd.Flags().IntVar(&csvOllamaConcurrency, "ollama-concurrency", 0, "Max in-flight Ollama requests across all models (0 = unlimited)")
+	csvCmd.Flags().IntVar(&csvOpenrouterConcurrency, "openrouter-concurrency", 0, "Max in-flight OpenRouter requests across all models (0 = unlimited)")
+	csvCmd.Flags().StringSliceVar(&csvModelConcurrency, "model-concurrency", nil, "Per-model max in-flight requests as model=N, or N for every model")
 	csvCmd.Flags().StringVar(&csvSystranKey, "systran-key", "", "Systran API key")
 	csvCmd.Flags().StringVar(&csvMymemoryEmail, "mymemory-email", "", "MyMemory email (for higher limits)")
 	csvCmd.Flags().IntVar(&csvMaxRetries, "max-retries", 3, "Total attempts per service including the first (1 = no retries)")
@@ -354,6 +555,8 @@ func init() {
 	csvCmd.Flags().BoolVar(&csvUsePlaceholder, "placeholder", false, "Protect HTML/Markdown markup with placeholders during translation")
 	csvCmd.Flags().BoolVar(&csvUseGlossary, "glossary", false, "Load terminology glossary from database for LLM services")
 
+	csvCmd.Flags().BoolVar(&csvUseHedge, "hedge", false, "Fire a duplicate request with another model when an LLM service exceeds its p95 latency")
+
 	csvCmd.MarkFlagRequired("input")
 	csvCmd.MarkFlagRequired("output")
 	csvCmd.MarkFlagRequired("target")
@@ -0,0 +1,476 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal"
+	"github.com/valpere/peretran/internal/arbiter"
+	"github.com/valpere/peretran/internal/chunker"
+	"github.com/valpere/peretran/internal/detector"
+	"github.com/valpere/peretran/internal/orchestrator"
+	"github.com/valpere/peretran/internal/prompts"
+	"github.com/valpere/peretran/internal/refiner"
+	"github.com/valpere/peretran/internal/store"
+	"github.com/valpere/peretran/internal/style"
+	"github.com/valpere/peretran/internal/translator"
+)
+
+// docFlags holds the flags of the translate subcommands for structured
+// documents (markdown, html, ...), which translate a document piece by piece
+// and put the pieces back into its markup. Each subcommand owns one,
+// registered with addDocFlags.
+type docFlags struct {
+	inputFile   string
+	outputFile  string
+	sourceLang  string
+	targetLang  string
+	credentials string
+	projectID   string
+
+	services        []string
+	useArbiter      bool
+	arbiterProvider string
+	arbiterModel    string
+	arbiterURL      string
+	arbiterKey      string
+	arbiterMode     string
+	reviewBelow     float64
+	promptsDir      string
+	styleName       string
+
+	ollamaURL        string
+	ollamaModels     []string
+	openrouterKey    string
+	openrouterModels []string
+	modelRotation    string
+	seed             int64
+
+	ollamaConcurrency     int
+	openrouterConcurrency int
+	modelConcurrency      []string
+
+	systranKey    string
+	mymemoryEmail string
+
+	useRefine       bool
+	refineOnly      bool
+	refineMode      string
+	refineRounds    int
+	refinerProvider string
+	refinerModel    string
+	refinerURL      string
+	refinerKey      string
+
+	noRefineGuard       bool
+	refineBacktranslate bool
+
+	maxRetries   int
+	dbPath       string
+	noCache      bool
+	useGlossary  bool
+	useHedge     bool
+	contextWords int
+}
+
+// addDocFlags registers the document translation flags of f on cmd.
+func addDocFlags(cmd *cobra.Command, f *docFlags) {
+	fl := cmd.Flags()
+	fl.StringVarP(&f.inputFile, "input", "i", "", "Input file (required)")
+	fl.StringVarP(&f.outputFile, "output", "o", "", "Output file (required)")
+	fl.StringVarP(&f.sourceLang, "source", "s", "auto", "Source language code")
+	fl.StringVarP(&f.targetLang, "target", "t", "", "Target language code (required)")
+	fl.StringVarP(&f.credentials, "credentials", "c", "", "Path to Google Cloud credentials")
+	fl.StringVarP(&f.projectID, "project", "p", "", "Google Cloud Project ID")
+
+	fl.StringSliceVar(&f.services, "services", []string{"google"}, "Translation services to use (comma-separated)")
+	fl.BoolVar(&f.useArbiter, "arbiter", false, "Use LLM arbiter to select best translation")
+	fl.StringVar(&f.arbiterProvider, "arbiter-provider", "ollama", "Arbiter backend: ollama, openrouter, openai (any OpenAI-compatible endpoint), consensus (metric-based, no LLM)")
+	fl.StringVar(&f.arbiterModel, "arbiter-model", "", "Arbiter model name (default depends on provider: llama3.2, first OpenRouter model, gpt-4o-mini)")
+	fl.StringVar(&f.arbiterURL, "arbiter-url", "", "Arbiter endpoint URL (default depends on provider)")
+	fl.StringVar(&f.arbiterKey, "arbiter-key", "", "Arbiter API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
+	fl.StringVar(&f.arbiterMode, "arbiter-mode", "single", "Arbiter mode: single (one prompt with all candidates), tournament (pairwise comparisons with order swapping, Bradley-Terry ranking)")
+	fl.Float64Var(&f.reviewBelow, "review-below", 0, "Flag segments for human review when the best arbiter accuracy score (1-5) is below this value; 0 disables")
+
+	fl.StringVar(&f.styleName, "style", "", "Style profile from the database to translate with (see: peretran style list); bypasses the translation memory")
+	fl.StringVar(&f.promptsDir, "prompts-dir", "", "Directory of *.tmpl prompt templates overriding the built-in ones (see: peretran prompts list)")
+
+	fl.BoolVar(&f.useRefine, "refine", false, "Enable Stage 2 literary refinement")
+	fl.BoolVar(&f.refineOnly, "refine-only", false, "Skip Stage 1 and refine the Stage 1 drafts saved by an earlier run (implies --refine)")
+	fl.StringVar(&f.refineMode, "refine-mode", "literary", "Refinement mode: literary (one polishing pass), critique (critique-then-revise rounds)")
+	fl.IntVar(&f.refineRounds, "refine-rounds", refiner.DefaultCritiqueRounds, "Maximum critique-then-revise rounds in critique mode")
+	fl.BoolVar(&f.noRefineGuard, "no-refine-guard", false, "Accept refinements without checking length, numbers, URLs, placeholders and language against the draft")
+	fl.BoolVar(&f.refineBacktranslate, "refine-backtranslate", false, "Also reject refinements whose back-translation (by the first service) drifts from the source")
+	fl.StringVar(&f.refinerProvider, "refiner-provider", "ollama", "Refiner backend: ollama, openrouter, openai (any OpenAI-compatible endpoint)")
+	fl.StringVar(&f.refinerModel, "refiner-model", "", "Refiner model name (default depends on provider: llama3.2, first OpenRouter model, gpt-4o-mini)")
+	fl.StringVar(&f.refinerURL, "refiner-url", "", "Refiner endpoint URL (default depends on provider)")
+	fl.StringVar(&f.refinerKey, "refiner-key", "", "Refiner API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
+
+	fl.StringVar(&f.ollamaURL, "ollama-url", "http://localhost:11434", "Ollama base URL")
+	fl.StringSliceVar(&f.ollamaModels, "ollama-models", nil, "Ollama models to rotate, optionally weighted as model=N (default list used if empty)")
+	fl.StringVar(&f.openrouterKey, "openrouter-key", "", "OpenRouter API key")
+	fl.StringSliceVar(&f.openrouterModels, "openrouter-models", nil, "OpenRouter models to rotate, optionally weighted as model=N (default list used if empty)")
+	fl.StringVar(&f.modelRotation, "model-rotation", "random", "Ollama/OpenRouter model selection: random, round-robin, weighted, sticky, fixed, fan-out")
+	fl.Int64Var(&f.seed, "seed", 0, "Seed for model rotation (0 = non-reproducible)")
+	fl.IntVar(&f.ollamaConcurrency, "ollama-concurrency", 0, "Max in-flight Ollama requests across all models (0 = unlimited)")
+	fl.IntVar(&f.openrouterConcurrency, "openrouter-concurrency", 0, "Max in-flight OpenRouter requests across all models (0 = unlimited)")
+	fl.StringSliceVar(&f.modelConcurrency, "model-concurrency", nil, "Per-model max in-flight requests as model=N, or N for every model")
+	fl.StringVar(&f.systranKey, "systran-key", "", "Systran API key")
+	fl.StringVar(&f.mymemoryEmail, "mymemory-email", "", "MyMemory email (for higher limits)")
+	fl.IntVar(&f.maxRetries, "max-retries", 3, "Total attempts per service including the first (1 = no retries)")
+
+	fl.StringVar(&f.dbPath, "db", "./data/peretran.db", "Database path for translation memory")
+	fl.BoolVar(&f.noCache, "no-cache", false, "Disable translation memory cache")
+	fl.BoolVar(&f.useGlossary, "glossary", false, "Load terminology glossary from database for LLM services")
+	fl.BoolVar(&f.useHedge, "hedge", false, "Fire a duplicate request with another model when an LLM service exceeds its p95 latency")
+	fl.IntVar(&f.contextWords, "context-words", chunker.DefaultContextWords, "Words of the surrounding text shown to LLMs on each side of a segment, and of the previous segment's translation (0 = none of either)")
+
+	cmd.MarkFlagRequired("input")
+	cmd.MarkFlagRequired("output")
+	cmd.MarkFlagRequired("target")
+}
+
+// docUnit is one piece of a document to translate.
+type docUnit struct {
+	Text string
+	// Before and After are the source text around the piece, shown to
+	// LLMs for reference.
+	Before string
+	After  string
+	// Note is added to the LLM instructions for this piece only, e.g. the
+	// msgctxt of a gettext message. Pieces with different notes are
+	// remembered separately.
+	Note string
+	// Label names the piece in messages, e.g. "segment 3".
+	Label string
+}
+
+// docTranslator runs the pieces of a document through the translation
+// pipeline one at a time: translation memory, Stage 1 services, arbiter
+// and Stage 2 refiner. Pieces are remembered with their surrounding text,
+// like the chunks of a long text.
+type docTranslator struct {
+	flags        *docFlags
+	sourceLang   string
+	instructions string
+
+	db       *store.Store
+	style    *style.Profile
+	orch     *orchestrator.Orchestrator
+	arb      arbiter.Arbiter
+	ref      refiner.LLMRefiner
+	guard    *refiner.Guard
+	cfg      translator.ServiceConfig
+	glossary map[string]string
+	draftKey string
+	refKey   string
+
+	// previous is the end of the last translation, the sliding context
+	// of the next piece.
+	previous string
+}
+
+// newDocTranslator sets up the pipeline from f. A source language of
+// "auto" is detected from sample. instructions are added to every LLM
+// prompt, e.g. the placeholder hint.
+func newDocTranslator(ctx context.Context, f *docFlags, sample, instructions string) (*docTranslator, error) {
+	if f.inputFile == f.outputFile {
+		return nil, fmt.Errorf("input file and output file cannot be the same")
+	}
+	if err := validateRefineMode(f.refineMode); err != nil {
+		return nil, err
+	}
+	if f.contextWords < 0 {
+		return nil, fmt.Errorf("--context-words must not be negative")
+	}
+	if f.refineOnly {
+		if f.noCache || f.dbPath == "" {
+			return nil, fmt.Errorf("--refine-only reads the saved Stage 1 drafts and cannot be used with --no-cache")
+		}
+		f.useRefine = true
+	}
+
+	t := &docTranslator{flags: f, sourceLang: f.sourceLang, instructions: instructions}
+	if t.sourceLang == "auto" {
+		if detected, ok := detector.New().DetectISO(sample); ok {
+			t.sourceLang = detected
+			fmt.Fprintf(os.Stderr, "Detected source language: %s\n", t.sourceLang)
+		}
+	}
+
+	var err error
+	t.style, err = loadStyleProfile(ctx, f.dbPath, f.styleName)
+	if err != nil {
+		return nil, err
+	}
+	if !f.noCache && f.dbPath != "" {
+		t.db, err = store.New(f.dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			t.Close()
+		}
+	}()
+
+	if f.useGlossary && t.db != nil {
+		t.glossary, err = t.db.GetGlossaryTerms(ctx, t.sourceLang, f.targetLang)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load glossary: %v\n", err)
+		} else if len(t.glossary) > 0 {
+			fmt.Fprintf(os.Stderr, "Loaded %d glossary terms\n", len(t.glossary))
+		}
+	}
+
+	promptSet, err := prompts.Load(f.promptsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	svcOpts := serviceOptions{
+		ollamaURL:        f.ollamaURL,
+		ollamaModels:     f.ollamaModels,
+		openrouterKey:    f.openrouterKey,
+		openrouterModels: f.openrouterModels,
+		systranKey:       f.systranKey,
+		mymemoryEmail:    f.mymemoryEmail,
+		rotation:         f.modelRotation,
+		seed:             f.seed,
+
+		ollamaConcurrency:     f.ollamaConcurrency,
+		openrouterConcurrency: f.openrouterConcurrency,
+		modelConcurrency:      f.modelConcurrency,
+
+		prompts: promptSet,
+	}
+	arbOpts := arbiterOptions{
+		provider:      f.arbiterProvider,
+		model:         f.arbiterModel,
+		baseURL:       f.arbiterURL,
+		apiKey:        f.arbiterKey,
+		mode:          f.arbiterMode,
+		openrouterKey: f.openrouterKey,
+		glossary:      t.glossary,
+		prompts:       promptSet,
+		style:         t.style,
+	}
+	refOpts := refinerOptions{
+		provider:      f.refinerProvider,
+		model:         f.refinerModel,
+		baseURL:       f.refinerURL,
+		apiKey:        f.refinerKey,
+		openrouterKey: f.openrouterKey,
+		prompts:       promptSet,
+		style:         t.style,
+		glossary:      t.glossary,
+		instructions:  instructions,
+		maxRounds:     f.refineRounds,
+	}
+
+	var keyArbiter *arbiterOptions
+	if f.useArbiter {
+		keyArbiter = &arbOpts
+	}
+	t.draftKey = stage1Key(f.services, svcOpts, keyArbiter, t.glossary, t.style.Guide(), instructions)
+	if f.useRefine {
+		t.refKey = refineKey(refOpts, f.refineMode, !f.noRefineGuard, f.refineBacktranslate)
+	}
+
+	serviceList, err := buildServices(f.services, svcOpts)
+	if err != nil {
+		return nil, err
+	}
+	t.cfg = translator.ServiceConfig{
+		Credentials: f.credentials,
+		ProjectID:   f.projectID,
+	}
+
+	var hedgeAfter map[string]time.Duration
+	if f.useHedge {
+		hedgeAfter = buildHedgeDelays(ctx, t.db, serviceList)
+	}
+	t.orch = orchestrator.New(serviceList, orchestrator.OrchestratorConfig{
+		Timeout:     30 * time.Second,
+		MinServices: 1,
+		MaxAttempts: f.maxRetries,
+		HedgeAfter:  hedgeAfter,
+	})
+
+	if f.useArbiter && !f.refineOnly {
+		if t.arb, err = buildArbiter(arbOpts); err != nil {
+			return nil, err
+		}
+	}
+	if f.useRefine {
+		if t.ref, err = buildRefiner(refOpts); err != nil {
+			return nil, err
+		}
+	}
+	t.guard = buildRefineGuard(f.useRefine && !f.noRefineGuard, f.refineBacktranslate, serviceList, t.cfg)
+
+	ok = true
+	return t, nil
+}
+
+// Close closes the database.
+func (t *docTranslator) Close() {
+	if t.db != nil {
+		t.db.Close()
+	}
+}
+
+// surrounding returns the source text shown around a piece: the end of
+// before and the start of after, within the --context-words limit.
+func (t *docTranslator) surrounding(before, after string) (string, string) {
+	if t.flags.contextWords == 0 {
+		return "", ""
+	}
+	return chunker.ExtractContext(before, t.flags.contextWords), chunker.ExtractLeadingContext(after, t.flags.contextWords)
+}
+
+// Translate returns the translation of u. An error means the piece could
+// not be translated and should keep its source text.
+func (t *docTranslator) Translate(ctx context.Context, u docUnit) (string, error) {
+	f := t.flags
+	before, instructions := u.Before, t.instructions
+	if u.Note != "" {
+		before = u.Note + "\x00" + before
+		instructions = strings.TrimSpace(instructions + " " + u.Note)
+	}
+	ctxHash := store.ContextHash(before, u.After)
+
+	if t.db != nil && t.style == nil && !f.refineOnly {
+		if cached, found := loadChunkMemory(ctx, t.db, u.Text, t.sourceLang, f.targetLang, ctxHash, t.refKey); found {
+			t.slide(cached)
+			return cached, nil
+		}
+	}
+
+	req := translator.TranslateRequest{
+		Text:            u.Text,
+		SourceLang:      t.sourceLang,
+		TargetLang:      f.targetLang,
+		PreviousContext: t.previous,
+		SourceBefore:    u.Before,
+		SourceAfter:     u.After,
+		GlossaryTerms:   t.glossary,
+		Instructions:    instructions,
+		StyleGuide:      t.style.Guide(),
+	}
+
+	// Stage 1: reuse a saved draft when only Stage 2 is re-run.
+	var draftText, selectedService string
+	var draftReused bool
+	result := &orchestrator.OrchestratorResult{}
+	if t.db != nil && f.useRefine {
+		draftText, selectedService, draftReused = loadStage1Draft(ctx, t.db, u.Text, t.sourceLang, f.targetLang, contextKey(t.draftKey, ctxHash), f.refineOnly)
+	}
+	switch {
+	case draftReused:
+	case f.refineOnly:
+		return "", fmt.Errorf("no saved Stage 1 draft")
+	default:
+		result = t.orch.Execute(ctx, t.cfg, req)
+		if result.Succeeded == 0 {
+			return "", fmt.Errorf("all translation services failed")
+		}
+		draftText = result.Results[0].TranslatedText
+		selectedService = result.Results[0].ServiceName
+	}
+
+	var scores map[string]internal.RubricScores
+	if t.arb != nil && len(result.Results) > 1 {
+		eval, err := t.arb.Evaluate(ctx, u.Text, t.sourceLang, f.targetLang, result.Results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Arbiter failed (%s): %v, using first result\n", u.Label, err)
+		} else {
+			draftText = eval.CompositeText
+			selectedService = eval.SelectedService
+			scores = eval.Scores
+			if best, ok := eval.BestAccuracy(); ok && best < f.reviewBelow {
+				fmt.Fprintf(os.Stderr, "Needs human review: %s best accuracy %.1f/5 is below %.1f\n", u.Label, best, f.reviewBelow)
+			}
+		}
+	}
+
+	// Stage 2: optional refinement.
+	translation := draftText
+	var rounds []refiner.Round
+	if t.ref != nil {
+		if f.refineMode == "critique" {
+			res, err := t.ref.RefineWithCritique(ctx, t.sourceLang, f.targetLang, u.Text, draftText)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Refiner failed (%s): %v, using draft\n", u.Label, err)
+			} else {
+				printCritiqueReport(u.Label, res)
+				translation = res.Text
+				rounds = res.Rounds
+			}
+		} else {
+			refined, err := t.ref.Refine(ctx, t.sourceLang, f.targetLang, u.Text, draftText)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Refiner failed (%s): %v, using draft\n", u.Label, err)
+			} else {
+				translation = refined
+			}
+		}
+		if t.guard != nil && translation != draftText {
+			if err := t.guard.Check(ctx, t.sourceLang, f.targetLang, u.Text, draftText, translation); err != nil {
+				fmt.Fprintf(os.Stderr, "Refinement rejected (%s): %v; using draft\n", u.Label, err)
+				translation = draftText
+			}
+		}
+	}
+	t.slide(translation)
+
+	if t.db != nil {
+		if t.style == nil {
+			_ = t.db.SaveToMemoryInContext(ctx, u.Text, t.sourceLang, f.targetLang, translation, draftText, selectedService, ctxHash)
+			_ = t.db.SetMemoryRefineKey(ctx, u.Text, t.sourceLang, f.targetLang, ctxHash, t.refKey)
+		}
+		if len(result.Results) > 0 || len(rounds) > 0 {
+			saveRunRecord(ctx, t.db, runRecord{
+				Source:     u.Text,
+				SourceLang: t.sourceLang,
+				TargetLang: f.targetLang,
+				Results:    result.Results,
+				Scores:     scores,
+				Rounds:     rounds,
+			})
+		}
+		if !draftReused {
+			_ = t.db.SaveStage1Draft(ctx, u.Text, t.sourceLang, f.targetLang, draftText, selectedService, contextKey(t.draftKey, ctxHash))
+		}
+	}
+	return translation, nil
+}
+
+// slide keeps the end of translation as the context of the next piece.
+func (t *docTranslator) slide(translation string) {
+	if t.flags.contextWords > 0 {
+		t.previous = chunker.ExtractContext(translation, t.flags.contextWords)
+	}
+}
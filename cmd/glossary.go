@@ -19,11 +19,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/valpere/peretran/internal/arbiter"
+	"github.com/valpere/peretran/internal/orchestrator"
 	"github.com/valpere/peretran/internal/store"
+	"github.com/valpere/peretran/internal/terms"
+	"github.com/valpere/peretran/internal/translator"
 )
 
 var glossaryDBPath string
@@ -35,7 +41,11 @@ var glossaryCmd = &cobra.Command{
 
 Glossary entries ensure that specific source terms are always translated
 to the same target term — useful for proper nouns, brand names, and
-domain-specific vocabulary.`,
+domain-specific vocabulary.
+
+To bootstrap a glossary, "glossary extract" finds candidate terms in a
+corpus and proposes translations; review them with "glossary pending",
+"glossary accept" and "glossary reject".`,
 }
 
 var (
@@ -132,6 +142,322 @@ Example:
 	},
 }
 
+var (
+	glossaryExtractInput    string
+	glossaryExtractSource   string
+	glossaryExtractTarget   string
+	glossaryExtractMinCount int
+	glossaryExtractMaxWords int
+	glossaryExtractLimit    int
+
+	glossaryExtractServices         []string
+	glossaryExtractCredentials      string
+	glossaryExtractProjectID        string
+	glossaryExtractOllamaURL        string
+	glossaryExtractOllamaModels     []string
+	glossaryExtractOpenrouterKey    string
+	glossaryExtractOpenrouterModels []string
+	glossaryExtractSystranKey       string
+	glossaryExtractMymemoryEmail    string
+
+	glossaryExtractUseArbiter      bool
+	glossaryExtractArbiterProvider string
+	glossaryExtractArbiterModel    string
+	glossaryExtractArbiterURL      string
+	glossaryExtractArbiterKey      string
+)
+
+// glossaryTermHint asks LLM services for a glossary entry rather than a
+// sentence.
+const glossaryTermHint = "The text is a single term for a glossary: reply with its translation in dictionary form only."
+
+var glossaryExtractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Find candidate terms in a corpus and propose translations",
+	Long: `Find terminology candidates in a source corpus and propose a translation
+for each with the configured services (and the arbiter, if enabled).
+
+Candidates are names and other capitalized phrases, frequent multi-word
+phrases, and domain-looking words (acronyms, identifiers, compounds, long
+words). Terms already in the glossary or already proposed are skipped.
+The proposals go to a pending review list:
+
+  peretran glossary pending -s en -t uk
+  peretran glossary accept gc_1234567890123456789 --as "вузол"
+  peretran glossary accept --all -s en -t uk
+  peretran glossary reject gc_1234567890123456789
+
+Example:
+  peretran glossary extract -i corpus.txt -s en -t uk --services ollama,google --arbiter`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if glossaryExtractInput == "" {
+			return fmt.Errorf("--input is required")
+		}
+		if glossaryExtractSource == "" || glossaryExtractTarget == "" {
+			return fmt.Errorf("--source and --target language flags are required")
+		}
+		corpus, err := os.ReadFile(glossaryExtractInput)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+
+		ctx := context.Background()
+		db, err := store.New(glossaryDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		candidates := terms.Extract(glossaryExtractSource, string(corpus), terms.Options{
+			MinCount: glossaryExtractMinCount,
+			MaxWords: glossaryExtractMaxWords,
+			Limit:    glossaryExtractLimit,
+		})
+
+		// Skip terms already in the glossary or on the review list.
+		known := map[string]bool{}
+		glossary, err := db.GetGlossaryTerms(ctx, glossaryExtractSource, glossaryExtractTarget)
+		if err != nil {
+			return fmt.Errorf("failed to load glossary: %w", err)
+		}
+		for term := range glossary {
+			known[strings.ToLower(term)] = true
+		}
+		proposed, err := db.ListGlossaryCandidates(ctx, glossaryExtractSource, glossaryExtractTarget, "")
+		if err != nil {
+			return fmt.Errorf("failed to load glossary candidates: %w", err)
+		}
+		for _, c := range proposed {
+			known[strings.ToLower(c.SourceTerm)] = true
+		}
+		var fresh []terms.Candidate
+		for _, c := range candidates {
+			if !known[strings.ToLower(c.Term)] {
+				fresh = append(fresh, c)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Found %d candidate term(s), %d new\n", len(candidates), len(fresh))
+		if len(fresh) == 0 {
+			return nil
+		}
+
+		serviceList, err := buildServices(glossaryExtractServices, serviceOptions{
+			ollamaURL:        glossaryExtractOllamaURL,
+			ollamaModels:     glossaryExtractOllamaModels,
+			openrouterKey:    glossaryExtractOpenrouterKey,
+			openrouterModels: glossaryExtractOpenrouterModels,
+			systranKey:       glossaryExtractSystranKey,
+			mymemoryEmail:    glossaryExtractMymemoryEmail,
+		})
+		if err != nil {
+			return err
+		}
+		orch := orchestrator.New(serviceList, orchestrator.OrchestratorConfig{
+			Timeout:     30 * time.Second,
+			MinServices: 1,
+		})
+		var arb arbiter.Arbiter
+		if glossaryExtractUseArbiter {
+			arb, err = buildArbiter(arbiterOptions{
+				provider:      glossaryExtractArbiterProvider,
+				model:         glossaryExtractArbiterModel,
+				baseURL:       glossaryExtractArbiterURL,
+				apiKey:        glossaryExtractArbiterKey,
+				mode:          "single",
+				openrouterKey: glossaryExtractOpenrouterKey,
+				glossary:      glossary,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cfg := translator.ServiceConfig{
+			Credentials: glossaryExtractCredentials,
+			ProjectID:   glossaryExtractProjectID,
+		}
+
+		added := 0
+		for i, c := range fresh {
+			fmt.Fprintf(os.Stderr, "Translating term %d/%d: %s\n", i+1, len(fresh), c.Term)
+			proposal, service, ok := proposeTermTranslation(ctx, orch, arb, cfg, c)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Warning: no translation for %q; skipped\n", c.Term)
+				continue
+			}
+			isNew, err := db.AddGlossaryCandidate(ctx, store.GlossaryCandidate{
+				SourceLang:   glossaryExtractSource,
+				TargetLang:   glossaryExtractTarget,
+				SourceTerm:   c.Term,
+				ProposedTerm: proposal,
+				Kind:         string(c.Kind),
+				Frequency:    c.Count,
+				Example:      c.Example,
+				ServiceUsed:  service,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save glossary candidate: %w", err)
+			}
+			if isNew {
+				added++
+			}
+		}
+		fmt.Printf("Added %d term(s) to the review list; see: peretran glossary pending -s %s -t %s\n",
+			added, glossaryExtractSource, glossaryExtractTarget)
+		return nil
+	},
+}
+
+// proposeTermTranslation translates a candidate term with every service,
+// showing LLMs the sentence it was found in, and picks one result with the
+// arbiter if there is one, otherwise the first.
+func proposeTermTranslation(ctx context.Context, orch *orchestrator.Orchestrator, arb arbiter.Arbiter, cfg translator.ServiceConfig, c terms.Candidate) (proposal, service string, ok bool) {
+	req := translator.TranslateRequest{
+		Text:         c.Term,
+		SourceLang:   glossaryExtractSource,
+		TargetLang:   glossaryExtractTarget,
+		Instructions: glossaryTermHint,
+	}
+	// Lower-casing keeps byte offsets for nearly every script; the context
+	// is left out when it does not.
+	lowerExample := strings.ToLower(c.Example)
+	if i := strings.Index(lowerExample, strings.ToLower(c.Term)); i >= 0 && len(lowerExample) == len(c.Example) {
+		req.SourceBefore = strings.TrimSpace(c.Example[:i])
+		req.SourceAfter = strings.TrimSpace(c.Example[i+len(c.Term):])
+	}
+
+	result := orch.Execute(ctx, cfg, req)
+	if result.Succeeded == 0 || len(result.Results) == 0 {
+		return "", "", false
+	}
+	proposal, service = result.Results[0].TranslatedText, result.Results[0].ServiceName
+	if arb != nil && len(result.Results) > 1 {
+		if eval, err := arb.Evaluate(ctx, c.Term, glossaryExtractSource, glossaryExtractTarget, result.Results); err != nil {
+			fmt.Fprintf(os.Stderr, "Arbiter failed: %v, using first result\n", err)
+		} else {
+			proposal, service = eval.CompositeText, eval.SelectedService
+		}
+	}
+	proposal = strings.Trim(strings.TrimSpace(proposal), `."'«»“”`)
+	return proposal, service, proposal != ""
+}
+
+var (
+	glossaryPendingSource string
+	glossaryPendingTarget string
+	glossaryPendingAll    bool
+)
+
+var glossaryPendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List extracted terms awaiting review",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := store.New(glossaryDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		status := store.CandidatePending
+		if glossaryPendingAll {
+			status = ""
+		}
+		cands, err := db.ListGlossaryCandidates(context.Background(), glossaryPendingSource, glossaryPendingTarget, status)
+		if err != nil {
+			return fmt.Errorf("failed to list glossary candidates: %w", err)
+		}
+		if len(cands) == 0 {
+			fmt.Println("No glossary candidates to review.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tLANGS\tSOURCE TERM\tPROPOSED\tKIND\tCOUNT\tSTATUS\tSERVICE")
+		for _, c := range cands {
+			fmt.Fprintf(w, "%s\t%s→%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				c.ID, c.SourceLang, c.TargetLang, c.SourceTerm, c.ProposedTerm, c.Kind, c.Frequency, c.Status, c.ServiceUsed)
+		}
+		return w.Flush()
+	},
+}
+
+var (
+	glossaryAcceptAs     string
+	glossaryAcceptAll    bool
+	glossaryAcceptSource string
+	glossaryAcceptTarget string
+)
+
+var glossaryAcceptCmd = &cobra.Command{
+	Use:   "accept [id...]",
+	Short: "Accept reviewed terms into the glossary",
+	Long: `Move pending glossary candidates into the glossary with their proposed
+translation. --as replaces the proposal of a single candidate; --all accepts
+every pending candidate, optionally filtered by --source and --target.
+
+Example:
+  peretran glossary accept gc_1234567890123456789 --as "робочий вузол"
+  peretran glossary accept --all -s en -t uk`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case glossaryAcceptAll && len(args) > 0:
+			return fmt.Errorf("pass candidate IDs or --all, not both")
+		case !glossaryAcceptAll && len(args) == 0:
+			return fmt.Errorf("pass candidate IDs or --all")
+		case glossaryAcceptAs != "" && len(args) != 1:
+			return fmt.Errorf("--as needs exactly one candidate ID")
+		}
+
+		ctx := context.Background()
+		db, err := store.New(glossaryDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		ids := args
+		if glossaryAcceptAll {
+			cands, err := db.ListGlossaryCandidates(ctx, glossaryAcceptSource, glossaryAcceptTarget, store.CandidatePending)
+			if err != nil {
+				return fmt.Errorf("failed to list glossary candidates: %w", err)
+			}
+			for _, c := range cands {
+				ids = append(ids, c.ID)
+			}
+		}
+		for _, id := range ids {
+			c, err := db.AcceptGlossaryCandidate(ctx, id, glossaryAcceptAs)
+			if err != nil {
+				return fmt.Errorf("failed to accept %s: %w", id, err)
+			}
+			fmt.Printf("Added: [%s→%s] %q → %q\n", c.SourceLang, c.TargetLang, c.SourceTerm, c.ProposedTerm)
+		}
+		return nil
+	},
+}
+
+var glossaryRejectCmd = &cobra.Command{
+	Use:   "reject <id>...",
+	Short: "Reject extracted terms",
+	Long: `Mark glossary candidates as rejected. Rejected terms stay out of the
+glossary and are not proposed again by "glossary extract".`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := store.New(glossaryDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		for _, id := range args {
+			if err := db.RejectGlossaryCandidate(context.Background(), id); err != nil {
+				return fmt.Errorf("failed to reject %s: %w", id, err)
+			}
+			fmt.Printf("Rejected glossary candidate: %s\n", id)
+		}
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(glossaryCmd)
 
@@ -145,7 +471,41 @@ func init() {
 	glossaryAddCmd.Flags().StringVarP(&glossaryAddSource, "source", "s", "", "Source language code (e.g. en)")
 	glossaryAddCmd.Flags().StringVarP(&glossaryAddTarget, "target", "t", "", "Target language code (e.g. uk)")
 
+	glossaryExtractCmd.Flags().StringVarP(&glossaryExtractInput, "input", "i", "", "Source corpus file (required)")
+	glossaryExtractCmd.Flags().StringVarP(&glossaryExtractSource, "source", "s", "", "Source language code (required)")
+	glossaryExtractCmd.Flags().StringVarP(&glossaryExtractTarget, "target", "t", "", "Target language code (required)")
+	glossaryExtractCmd.Flags().IntVar(&glossaryExtractMinCount, "min-count", terms.DefaultMinCount, "Minimum occurrences of a term in the corpus")
+	glossaryExtractCmd.Flags().IntVar(&glossaryExtractMaxWords, "max-words", terms.DefaultMaxWords, "Longest phrase considered, in words")
+	glossaryExtractCmd.Flags().IntVar(&glossaryExtractLimit, "limit", terms.DefaultLimit, "Maximum number of candidates, most frequent first")
+	glossaryExtractCmd.Flags().StringSliceVar(&glossaryExtractServices, "services", []string{"google"}, "Translation services proposing translations (comma-separated)")
+	glossaryExtractCmd.Flags().StringVarP(&glossaryExtractCredentials, "credentials", "c", "", "Path to Google Cloud credentials")
+	glossaryExtractCmd.Flags().StringVarP(&glossaryExtractProjectID, "project", "p", "", "Google Cloud Project ID")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractOllamaURL, "ollama-url", "http://localhost:11434", "Ollama base URL")
+	glossaryExtractCmd.Flags().StringSliceVar(&glossaryExtractOllamaModels, "ollama-models", nil, "Ollama models to rotate (default list used if empty)")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractOpenrouterKey, "openrouter-key", "", "OpenRouter API key")
+	glossaryExtractCmd.Flags().StringSliceVar(&glossaryExtractOpenrouterModels, "openrouter-models", nil, "OpenRouter models to rotate (default list used if empty)")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractSystranKey, "systran-key", "", "Systran API key")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractMymemoryEmail, "mymemory-email", "", "MyMemory email (for higher limits)")
+	glossaryExtractCmd.Flags().BoolVar(&glossaryExtractUseArbiter, "arbiter", false, "Use the arbiter to pick among the proposed translations")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractArbiterProvider, "arbiter-provider", "ollama", "Arbiter backend: ollama, openrouter, openai (any OpenAI-compatible endpoint), consensus (metric-based, no LLM)")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractArbiterModel, "arbiter-model", "", "Arbiter model name (default depends on provider)")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractArbiterURL, "arbiter-url", "", "Arbiter endpoint URL (default depends on provider)")
+	glossaryExtractCmd.Flags().StringVar(&glossaryExtractArbiterKey, "arbiter-key", "", "Arbiter API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
+
+	glossaryPendingCmd.Flags().StringVarP(&glossaryPendingSource, "source", "s", "", "Filter by source language code (e.g. en)")
+	glossaryPendingCmd.Flags().StringVarP(&glossaryPendingTarget, "target", "t", "", "Filter by target language code (e.g. uk)")
+	glossaryPendingCmd.Flags().BoolVar(&glossaryPendingAll, "all", false, "Also list rejected candidates")
+
+	glossaryAcceptCmd.Flags().StringVar(&glossaryAcceptAs, "as", "", "Target term to use instead of the proposed one (single ID only)")
+	glossaryAcceptCmd.Flags().BoolVar(&glossaryAcceptAll, "all", false, "Accept every pending candidate")
+	glossaryAcceptCmd.Flags().StringVarP(&glossaryAcceptSource, "source", "s", "", "With --all, only this source language")
+	glossaryAcceptCmd.Flags().StringVarP(&glossaryAcceptTarget, "target", "t", "", "With --all, only this target language")
+
 	glossaryCmd.AddCommand(glossaryListCmd)
 	glossaryCmd.AddCommand(glossaryAddCmd)
 	glossaryCmd.AddCommand(glossaryDeleteCmd)
+	glossaryCmd.AddCommand(glossaryExtractCmd)
+	glossaryCmd.AddCommand(glossaryPendingCmd)
+	glossaryCmd.AddCommand(glossaryAcceptCmd)
+	glossaryCmd.AddCommand(glossaryRejectCmd)
 }
@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/htmldoc"
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+var htmlFlags docFlags
+
+var htmlCmd = &cobra.Command{
+	Use:   "html",
+	Short: "Translate an HTML document, keeping its markup",
+	Long: `Translate the text of an HTML page or fragment and write back valid HTML.
+
+Text is translated one block at a time: inline tags such as <a>, <em> or
+<span> inside a paragraph reach the services as [PHn] placeholders and are
+put back around the translated words, so a paragraph costs a handful of
+markers instead of one per tag. The alt, title, placeholder and aria-label
+attributes and the content of description, keywords and Open Graph <meta>
+tags are translated too.
+
+Scripts, styles, <pre> and <code> are kept, as is any element marked
+translate="no" or class="notranslate" and any element whose lang attribute
+names a language other than the source. With --source auto the lang of the
+<html> element, when present, is taken as the source language. The lang
+attribute is set to the target language in the output.
+
+A block whose translation lost a placeholder keeps its source text and is
+reported, so the markup is never broken.
+
+Example:
+  peretran translate html -i index.html -o index.uk.html -t uk --services ollama`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.ReadFile(htmlFlags.inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		doc, err := htmldoc.Parse(string(src))
+		if err != nil {
+			return err
+		}
+		if htmlFlags.sourceLang == "auto" && doc.Lang() != "" {
+			htmlFlags.sourceLang = doc.Lang()
+		}
+
+		var sample strings.Builder
+		for _, seg := range doc.Segments {
+			sample.WriteString(seg.PlainText() + "\n")
+		}
+
+		ctx := context.Background()
+		tr, err := newDocTranslator(ctx, &htmlFlags, sample.String(), placeholder.InstructionHint())
+		if err != nil {
+			return err
+		}
+		defer tr.Close()
+
+		kept := 0
+		for i, seg := range doc.Segments {
+			if seg.Lang != "" && !htmldoc.SameLanguage(seg.Lang, tr.sourceLang) {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Translating segment %d/%d...\n", i+1, len(doc.Segments))
+			var before, after string
+			if i > 0 {
+				before = doc.Segments[i-1].PlainText()
+			}
+			if i+1 < len(doc.Segments) {
+				after = doc.Segments[i+1].PlainText()
+			}
+			unit := docUnit{Text: seg.Text, Label: fmt.Sprintf("segment %d", i+1)}
+			unit.Before, unit.After = tr.surrounding(before, after)
+
+			translation, err := tr.Translate(ctx, unit)
+			if err == nil {
+				err = doc.Apply(i, translation)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Segment %d: %v; keeping the source text\n", i+1, err)
+				kept++
+			}
+		}
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d of %d segment(s) left untranslated\n", kept, len(doc.Segments))
+		}
+
+		doc.SetLang(tr.sourceLang, htmlFlags.targetLang)
+		out, err := doc.Render()
+		if err != nil {
+			return err
+		}
+		return writeOutput(htmlFlags.outputFile, out, tr.sourceLang, htmlFlags.targetLang, false)
+	},
+}
+
+func init() {
+	translateCmd.AddCommand(htmlCmd)
+	addDocFlags(htmlCmd, &htmlFlags)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/i18n"
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+var (
+	i18nFlags       docFlags
+	i18nIncremental bool
+)
+
+var i18nCmd = &cobra.Command{
+	Use:     "i18n",
+	Aliases: []string{"json", "yaml"},
+	Short:   "Translate a JSON or YAML locale file",
+	Long: `Translate the strings of a nested JSON or YAML locale file, as used by
+i18next, vue-i18n and Rails.
+
+Only leaf string values are translated; keys, numbers, booleans, key order
+and nesting are kept. The format follows the file extension (.json, .yml,
+.yaml). A JSON file is written back unchanged apart from its translated
+values; a YAML file keeps its keys, order and comments.
+
+Interpolation variables ({{name}}, {count}, %{name}, $t(key), @:key), HTML
+tags and the syntax of ICU plural and select messages reach the services as
+[PHn] placeholders; the branch texts of an ICU message are translated. The
+key of each string is given to LLMs as context. A top-level language key,
+as in Rails ("en:"), is renamed to the target language.
+
+With --incremental the existing output file is read first: strings it
+already has are kept, and only the keys missing from it are translated.
+
+Example:
+  peretran translate i18n -i locales/en.json -o locales/uk.json -t uk --services ollama --incremental`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.ReadFile(i18nFlags.inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		file, err := i18n.Parse(string(src), i18n.DetectFormat(i18nFlags.inputFile, string(src)))
+		if err != nil {
+			return err
+		}
+		if root := file.LocaleRoot(); root != "" {
+			if i18nFlags.sourceLang == "auto" {
+				i18nFlags.sourceLang = root
+			}
+			file.SetLocaleRoot(i18nFlags.targetLang)
+		}
+
+		var existing *i18n.File
+		if i18nIncremental {
+			data, err := os.ReadFile(i18nFlags.outputFile)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				fmt.Fprintf(os.Stderr, "No existing %s; translating every key\n", i18nFlags.outputFile)
+			case err != nil:
+				return fmt.Errorf("failed to read existing output file: %w", err)
+			default:
+				existing, err = i18n.Parse(string(data), i18n.DetectFormat(i18nFlags.outputFile, string(data)))
+				if err != nil {
+					return fmt.Errorf("failed to read existing output file: %w", err)
+				}
+			}
+		}
+
+		var sample strings.Builder
+		for _, e := range file.Entries {
+			sample.WriteString(e.Text + "\n")
+		}
+
+		ctx := context.Background()
+		tr, err := newDocTranslator(ctx, &i18nFlags, sample.String(), placeholder.InstructionHint())
+		if err != nil {
+			return err
+		}
+		defer tr.Close()
+
+		translated, reused, kept, plurals := 0, 0, 0, 0
+		for i, e := range file.Entries {
+			if existing != nil && file.Reuse(i, existing) {
+				reused++
+				continue
+			}
+			if !e.Translatable() {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Translating %s (%d/%d)...\n", e.Key(), i+1, len(file.Entries))
+			var before, after string
+			if i > 0 {
+				before = file.Entries[i-1].Text
+			}
+			if i+1 < len(file.Entries) {
+				after = file.Entries[i+1].Text
+			}
+			p := i18n.Protect(e.Text)
+			unit := docUnit{
+				Text:  p.Text,
+				Note:  fmt.Sprintf("The string's key is %q.", e.Key()),
+				Label: e.Key(),
+			}
+			unit.Before, unit.After = tr.surrounding(before, after)
+
+			translation, err := tr.Translate(ctx, unit)
+			if err == nil {
+				translation, err = p.Restore(strings.TrimSpace(translation))
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v; keeping the source text\n", e.Key(), err)
+				kept++
+				continue
+			}
+			file.Set(i, translation)
+			translated++
+			if missing := p.MissingPluralForms(i18nFlags.targetLang); len(missing) > 0 {
+				fmt.Fprintf(os.Stderr, "%s: the ICU plural has no %s branch that %s needs; add it by hand\n", e.Key(), strings.Join(missing, "/"), i18nFlags.targetLang)
+				plurals++
+			}
+		}
+		if reused > 0 {
+			fmt.Fprintf(os.Stderr, "Kept %d string(s) from %s\n", reused, i18nFlags.outputFile)
+		}
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d string(s) left untranslated\n", kept)
+		}
+		if plurals > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d ICU plural message(s) lack plural forms of %s\n", plurals, i18nFlags.targetLang)
+		}
+		fmt.Fprintf(os.Stderr, "Translated %d string(s)\n", translated)
+
+		out, err := file.Render()
+		if err != nil {
+			return err
+		}
+		return writeOutput(i18nFlags.outputFile, out, tr.sourceLang, i18nFlags.targetLang, false)
+	},
+}
+
+func init() {
+	translateCmd.AddCommand(i18nCmd)
+	addDocFlags(i18nCmd, &i18nFlags)
+	i18nCmd.Flags().BoolVar(&i18nIncremental, "incremental", false, "Keep the strings of the existing output file and translate only the missing keys")
+}
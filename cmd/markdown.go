@@ -0,0 +1,104 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/markdown"
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+var mdFlags docFlags
+
+var markdownCmd = &cobra.Command{
+	Use:     "markdown",
+	Aliases: []string{"md"},
+	Short:   "Translate a Markdown document, keeping its markup",
+	Long: `Translate the text of a Markdown document and leave everything else
+exactly as it was.
+
+Headings, paragraphs, list items, table cells, link text and image alt text
+are translated one block at a time. Front matter, code blocks and spans,
+HTML blocks, URLs, reference definitions and emphasis markers are kept:
+inline markup reaches the services as [PHn] placeholders and is put back
+after translation. Outside the translated text the output is byte for byte
+identical to the input.
+
+A block whose translation lost a placeholder keeps its source text and is
+reported, so the markup is never broken.
+
+Example:
+  peretran translate markdown -i README.md -o README.uk.md -t uk --services ollama`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.ReadFile(mdFlags.inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		doc := markdown.Parse(string(src))
+
+		var sample strings.Builder
+		for _, seg := range doc.Segments {
+			sample.WriteString(seg.PlainText() + "\n")
+		}
+
+		ctx := context.Background()
+		tr, err := newDocTranslator(ctx, &mdFlags, sample.String(), placeholder.InstructionHint())
+		if err != nil {
+			return err
+		}
+		defer tr.Close()
+
+		translated := make([]string, len(doc.Segments))
+		kept := 0
+		for i, seg := range doc.Segments {
+			fmt.Fprintf(os.Stderr, "Translating segment %d/%d...\n", i+1, len(doc.Segments))
+			var before, after string
+			if i > 0 {
+				before = doc.Segments[i-1].PlainText()
+			}
+			if i+1 < len(doc.Segments) {
+				after = doc.Segments[i+1].PlainText()
+			}
+			unit := docUnit{Text: seg.Text, Label: fmt.Sprintf("segment %d", i+1)}
+			unit.Before, unit.After = tr.surrounding(before, after)
+
+			translation, err := tr.Translate(ctx, unit)
+			if err == nil {
+				translated[i], err = seg.Restore(translation)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Segment %d: %v; keeping the source text\n", i+1, err)
+				kept++
+			}
+		}
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d of %d segment(s) left untranslated\n", kept, len(doc.Segments))
+		}
+
+		return writeOutput(mdFlags.outputFile, doc.Render(translated), tr.sourceLang, mdFlags.targetLang, false)
+	},
+}
+
+func init() {
+	translateCmd.AddCommand(markdownCmd)
+	addDocFlags(markdownCmd, &mdFlags)
+}
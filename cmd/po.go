@@ -0,0 +1,208 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/po"
+)
+
+var (
+	poFlags docFlags
+	poForce bool
+)
+
+// errCountLost reports a plural form whose translation lost the count it
+// was translated with.
+var errCountLost = errors.New("the count does not appear once in the translation")
+
+var poCmd = &cobra.Command{
+	Use:   "po",
+	Short: "Translate a gettext PO or POT file",
+	Long: `Translate the messages of a gettext PO or POT file into msgstr.
+
+Each msgid is translated on its own, with its msgctxt given to LLMs as
+context. Format directives (%s, %1$d, %(name)s, {name}) and HTML tags reach
+the services as [PHn] placeholders. A plural message gets as many msgstr[n]
+forms as the target language has: each form is translated with a count that
+uses it ("1 file", "2 files", "5 files" for Ukrainian) so the services pick
+the right grammar. The header gets the target Language and its Plural-Forms.
+
+Translated messages are marked fuzzy for review. Comments, references and
+flags are kept, and entries that are not translated are written back exactly
+as they were. Messages that already have a translation are skipped unless
+--force is given; a fuzzy message with the previous msgid (#|) left by
+msgmerge counts as untranslated.
+
+Example:
+  peretran translate po -i messages.pot -o uk.po -t uk --services ollama`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.ReadFile(poFlags.inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		file, err := po.Parse(string(src))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", poFlags.inputFile, err)
+		}
+
+		var messages []*po.Entry
+		var sample strings.Builder
+		for _, e := range file.Entries {
+			if e.IsHeader() || e.Obsolete {
+				continue
+			}
+			messages = append(messages, e)
+			sample.WriteString(e.ID + "\n")
+		}
+
+		plural, known, err := file.SetLanguage(poFlags.targetLang)
+		if err != nil {
+			return err
+		}
+		if !known {
+			fmt.Fprintf(os.Stderr, "Warning: plural forms of %q are unknown; using the English rule, check the Plural-Forms header\n", poFlags.targetLang)
+		}
+
+		ctx := context.Background()
+		tr, err := newDocTranslator(ctx, &poFlags, sample.String(), placeholder.InstructionHint())
+		if err != nil {
+			return err
+		}
+		defer tr.Close()
+
+		translated, skipped, kept := 0, 0, 0
+		for i, e := range messages {
+			if e.Translated() && !poForce {
+				skipped++
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Translating message %d/%d...\n", i+1, len(messages))
+			var before, after string
+			if i > 0 {
+				before = messages[i-1].ID
+			}
+			if i+1 < len(messages) {
+				after = messages[i+1].ID
+			}
+			unit := docUnit{Label: fmt.Sprintf("message %d", i+1)}
+			unit.Before, unit.After = tr.surrounding(before, after)
+			if e.HasContext {
+				unit.Note = fmt.Sprintf("The message is used in this context: %s.", e.Context)
+			}
+
+			strs, err := translatePOEntry(ctx, tr, unit, e, plural)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Message %d: %v; left untranslated\n", i+1, err)
+				kept++
+				continue
+			}
+			e.SetTranslation(strs)
+			translated++
+		}
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Skipped %d already translated message(s); use --force to translate them again\n", skipped)
+		}
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d of %d message(s) left untranslated\n", kept, len(messages))
+		}
+		fmt.Fprintf(os.Stderr, "Translated %d message(s), marked fuzzy for review\n", translated)
+
+		return writeOutput(poFlags.outputFile, file.String(), tr.sourceLang, poFlags.targetLang, false)
+	},
+}
+
+// translatePOEntry returns the msgstr values of e: one for a singular
+// message, one per plural form for a plural one.
+func translatePOEntry(ctx context.Context, tr *docTranslator, unit docUnit, e *po.Entry, plural *po.Plural) ([]string, error) {
+	if e.Plural == "" {
+		s, err := translatePOText(ctx, tr, unit, e.ID, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+
+	strs := make([]string, plural.N)
+	for form := range strs {
+		n, onlyOne, ok := plural.Sample(form)
+		src := e.Plural
+		if _, _, counted := po.WithCount(e.ID, n); ok && n == 1 && (onlyOne || counted) {
+			src = e.ID
+		}
+		u := unit
+		u.Label = fmt.Sprintf("%s, plural form %d", unit.Label, form)
+		s, err := translatePOText(ctx, tr, u, src, n, ok)
+		if errors.Is(err, errCountLost) {
+			// The count got lost or duplicated: translate the form
+			// without it.
+			s, err = translatePOText(ctx, tr, u, src, 0, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		strs[form] = s
+	}
+	return strs, nil
+}
+
+// translatePOText translates one message string with its directives and
+// markup protected and its surrounding whitespace kept. With withCount,
+// the integer directive is translated as the count n.
+func translatePOText(ctx context.Context, tr *docTranslator, unit docUnit, text string, n int, withCount bool) (string, error) {
+	core := strings.TrimFunc(text, unicode.IsSpace)
+	if core == "" {
+		return text, nil
+	}
+	start := strings.Index(text, core)
+	lead, trail := text[:start], text[start+len(core):]
+
+	var undo func(string) (string, bool)
+	if withCount {
+		core, undo, withCount = po.WithCount(core, n)
+	}
+	protected, markers := po.Protect(core)
+	unit.Text = protected
+	translation, err := tr.Translate(ctx, unit)
+	if err != nil {
+		return "", err
+	}
+	if missing := placeholder.Validate(translation, markers); len(missing) > 0 {
+		return "", fmt.Errorf("translation lost %d of %d placeholder(s)", len(missing), len(markers))
+	}
+	if withCount {
+		var ok bool
+		if translation, ok = undo(translation); !ok {
+			return "", errCountLost
+		}
+	}
+	return lead + placeholder.Restore(strings.TrimSpace(translation), markers) + trail, nil
+}
+
+func init() {
+	translateCmd.AddCommand(poCmd)
+	addDocFlags(poCmd, &poFlags)
+	poCmd.Flags().BoolVar(&poForce, "force", false, "Translate messages that already have a translation again")
+}
@@ -0,0 +1,187 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/prompts"
+)
+
+var promptsCmdDir string
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Inspect the LLM prompt templates",
+	Long: `List, show, and render the text/template prompts used by the LLM
+translators, the arbiter, and the refiner.
+
+Built-in templates are embedded in the binary. Point --prompts-dir at a
+directory of *.tmpl files to override them (translate.tmpl replaces the
+"translate" prompt) or to try variants; the same flag is accepted by
+"translate" and "translate csv". Each result records the template ID
+(name@hash), so prompt changes can be compared with "cache quality".`,
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List prompt templates with their IDs and origins",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := prompts.Load(promptsCmdDir)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tID\tORIGIN")
+		for _, name := range set.Names() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, set.ID(name), set.Origin(name))
+		}
+		return w.Flush()
+	},
+}
+
+var promptsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Print the source of a prompt template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := prompts.Load(promptsCmdDir)
+		if err != nil {
+			return err
+		}
+
+		src, ok := set.Source(args[0])
+		if !ok {
+			return fmt.Errorf("unknown prompt template %q (see: peretran prompts list)", args[0])
+		}
+		fmt.Println(src)
+		return nil
+	},
+}
+
+var (
+	renderSourceLang   string
+	renderTargetLang   string
+	renderText         string
+	renderDraft        string
+	renderContext      string
+	renderCritique     string
+	renderInstructions string
+	renderStyleGuide   string
+	renderTerms        []string
+	renderCandidates   []string
+)
+
+var promptsRenderCmd = &cobra.Command{
+	Use:   "render <name>",
+	Short: "Render a prompt template with sample or given values",
+	Long: `Render a prompt template exactly as it would be sent to the model.
+
+Variables not given as flags take sample values, so "prompts render arbiter"
+shows a complete prompt without any input.
+
+Example:
+  peretran prompts render translate -s en -t de --text "Good morning" --term "morning=Morgen"
+  peretran prompts render arbiter --candidate google="Guten Morgen" --candidate ollama="Morgen"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set, err := prompts.Load(promptsCmdDir)
+		if err != nil {
+			return err
+		}
+
+		data := prompts.SampleData()
+		flags := cmd.Flags()
+		if flags.Changed("source-lang") {
+			data.SourceLang = renderSourceLang
+		}
+		if flags.Changed("target-lang") {
+			data.TargetLang = renderTargetLang
+		}
+		if flags.Changed("text") {
+			data.Source = renderText
+		}
+		if flags.Changed("draft") {
+			data.Draft = renderDraft
+		}
+		if flags.Changed("context") {
+			data.Context = renderContext
+		}
+		if flags.Changed("critique") {
+			data.Critique = renderCritique
+		}
+		if flags.Changed("instructions") {
+			data.Instructions = renderInstructions
+		}
+		if flags.Changed("style-guide") {
+			data.StyleGuide = renderStyleGuide
+		}
+		if flags.Changed("term") {
+			data.Glossary = make(map[string]string, len(renderTerms))
+			for _, spec := range renderTerms {
+				src, tgt, ok := strings.Cut(spec, "=")
+				if !ok {
+					return fmt.Errorf("invalid --term %q: expected source=target", spec)
+				}
+				data.Glossary[src] = tgt
+			}
+		}
+		if flags.Changed("candidate") {
+			data.Candidates = data.Candidates[:0]
+			for _, spec := range renderCandidates {
+				name, text, ok := strings.Cut(spec, "=")
+				if !ok {
+					return fmt.Errorf("invalid --candidate %q: expected name=text", spec)
+				}
+				data.Candidates = append(data.Candidates, prompts.Candidate{Name: name, Text: text})
+			}
+		}
+
+		out, err := set.Render(args[0], data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+
+	promptsCmd.PersistentFlags().StringVar(&promptsCmdDir, "prompts-dir", "", "Directory of *.tmpl prompt templates overriding the built-in ones")
+
+	promptsCmd.AddCommand(promptsListCmd)
+	promptsCmd.AddCommand(promptsShowCmd)
+	promptsCmd.AddCommand(promptsRenderCmd)
+
+	promptsRenderCmd.Flags().StringVarP(&renderSourceLang, "source-lang", "s", "", "Source language")
+	promptsRenderCmd.Flags().StringVarP(&renderTargetLang, "target-lang", "t", "", "Target language")
+	promptsRenderCmd.Flags().StringVar(&renderText, "text", "", "Source text")
+	promptsRenderCmd.Flags().StringVar(&renderDraft, "draft", "", "Draft translation (refine)")
+	promptsRenderCmd.Flags().StringVar(&renderContext, "context", "", "Previous passage for continuity")
+	promptsRenderCmd.Flags().StringVar(&renderCritique, "critique", "", "Reviewer critique, one issue per line (revise)")
+	promptsRenderCmd.Flags().StringVar(&renderInstructions, "instructions", "", "Extra instructions")
+	promptsRenderCmd.Flags().StringVar(&renderStyleGuide, "style-guide", "", "Style guide text")
+	promptsRenderCmd.Flags().StringArrayVar(&renderTerms, "term", nil, "Glossary entry source=target (repeatable)")
+	promptsRenderCmd.Flags().StringArrayVar(&renderCandidates, "candidate", nil, "Arbiter candidate name=text (repeatable)")
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/store"
+	"github.com/valpere/peretran/internal/style"
+)
+
+var styleDBPath string
+
+var styleCmd = &cobra.Command{
+	Use:   "style",
+	Short: "Manage style and tone profiles",
+	Long: `Add, list, show, and delete named style profiles.
+
+A style profile fixes formality, audience, register, forbidden words,
+quotation marks, punctuation and number formatting for a translation. Pass
+--style <name> to "translate" or "translate csv" to apply it: the profile is
+rendered into the prompts of the LLM translators, the arbiter and the
+refiner, and arbiter composites containing forbidden words are rejected.`,
+}
+
+var (
+	styleAddFormality   string
+	styleAddAudience    string
+	styleAddRegister    string
+	styleAddForbidden   []string
+	styleAddQuotes      string
+	styleAddPunctuation string
+	styleAddNumbers     string
+	styleAddNotes       string
+)
+
+var styleAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or replace a style profile",
+	Long: `Add a style profile, replacing any existing profile with the same name.
+
+Example:
+  peretran style add ui-uk --formality formal --audience "end users" \
+    --register "UI copy" --forbid юзер --forbid девайс --quotes "«»" \
+    --numbers "1 234,56; dates as DD.MM.YYYY"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formality, err := style.ParseFormality(styleAddFormality)
+		if err != nil {
+			return err
+		}
+
+		p := style.Profile{
+			Name:         args[0],
+			Formality:    formality,
+			Audience:     styleAddAudience,
+			Register:     styleAddRegister,
+			Quotes:       styleAddQuotes,
+			Punctuation:  styleAddPunctuation,
+			NumberFormat: styleAddNumbers,
+			Notes:        styleAddNotes,
+		}
+		for _, w := range styleAddForbidden {
+			if w = strings.TrimSpace(w); w != "" {
+				p.ForbiddenWords = append(p.ForbiddenWords, w)
+			}
+		}
+
+		db, err := store.New(styleDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		if err := db.SaveStyleProfile(context.Background(), p); err != nil {
+			return fmt.Errorf("failed to save style profile: %w", err)
+		}
+		fmt.Printf("Saved style profile: %s\n", p.Name)
+		return nil
+	},
+}
+
+var styleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all style profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := store.New(styleDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		profiles, err := db.ListStyleProfiles(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to list style profiles: %w", err)
+		}
+
+		if len(profiles) == 0 {
+			fmt.Println("No style profiles.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tFORMALITY\tAUDIENCE\tREGISTER\tFORBIDDEN")
+		for _, p := range profiles {
+			formality := p.Formality
+			if formality == "" {
+				formality = "default"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+				p.Name, formality, p.Audience, p.Register, len(p.ForbiddenWords))
+		}
+		return w.Flush()
+	},
+}
+
+var styleShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Print a style profile as it is sent to the models",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := store.New(styleDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		p, found, err := db.GetStyleProfile(context.Background(), args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load style profile: %w", err)
+		}
+		if !found {
+			return fmt.Errorf("unknown style profile %q", args[0])
+		}
+
+		guide := p.Guide()
+		if guide == "" {
+			guide = "(empty profile)"
+		}
+		fmt.Println(guide)
+		return nil
+	},
+}
+
+var styleDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a style profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := store.New(styleDBPath)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		deleted, err := db.DeleteStyleProfile(context.Background(), args[0])
+		if err != nil {
+			return fmt.Errorf("failed to delete style profile: %w", err)
+		}
+		if !deleted {
+			return fmt.Errorf("unknown style profile %q", args[0])
+		}
+		fmt.Printf("Deleted style profile: %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(styleCmd)
+
+	styleCmd.PersistentFlags().StringVar(&styleDBPath, "db", "./data/peretran.db", "Database path")
+
+	styleAddCmd.Flags().StringVar(&styleAddFormality, "formality", "", "Formality: formal, informal, or default")
+	styleAddCmd.Flags().StringVar(&styleAddAudience, "audience", "", `Target audience (e.g. "developers")`)
+	styleAddCmd.Flags().StringVar(&styleAddRegister, "register", "", `Register (e.g. "marketing", "legal", "UI copy")`)
+	styleAddCmd.Flags().StringArrayVar(&styleAddForbidden, "forbid", nil, "Word the translation must not use (repeatable)")
+	styleAddCmd.Flags().StringVar(&styleAddQuotes, "quotes", "", `Quotation marks (e.g. "«»")`)
+	styleAddCmd.Flags().StringVar(&styleAddPunctuation, "punctuation", "", "Punctuation conventions")
+	styleAddCmd.Flags().StringVar(&styleAddNumbers, "numbers", "", "Number, date and currency formatting")
+	styleAddCmd.Flags().StringVar(&styleAddNotes, "notes", "", "Further instructions")
+
+	styleCmd.AddCommand(styleAddCmd)
+	styleCmd.AddCommand(styleListCmd)
+	styleCmd.AddCommand(styleShowCmd)
+	styleCmd.AddCommand(styleDeleteCmd)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/subtitle"
+)
+
+var (
+	subFlags  docFlags
+	subLimits subtitle.Limits
+)
+
+var subtitlesCmd = &cobra.Command{
+	Use:     "subtitles",
+	Aliases: []string{"srt", "vtt"},
+	Short:   "Translate SRT or WebVTT subtitles",
+	Long: `Translate SRT or WebVTT subtitles, keeping cue numbers and timings.
+
+Cues that make up one sentence are merged and translated together, with
+the neighbouring cues given to LLMs as context. The translation is spread
+back over the cues in proportion to how long each is shown, breaking after
+punctuation where it can, and wrapped into lines of at most
+--max-line-chars characters. Dialogue cues (a line per speaker starting
+with "-") and cues with markup inside their text are translated on their
+own; markup around a whole cue, such as <i>…</i> or {\an8}, is kept.
+
+After translation the cues that have more than --max-lines lines, a line
+longer than --max-line-chars, or more than --max-cps characters per second
+are reported for editing.
+
+Example:
+  peretran translate subtitles -i movie.en.srt -o movie.uk.srt -t uk --services ollama`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.ReadFile(subFlags.inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		file, err := subtitle.Parse(string(src))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", subFlags.inputFile, err)
+		}
+		units := file.Units()
+
+		var sample strings.Builder
+		for _, u := range units {
+			sample.WriteString(u.PlainText() + "\n")
+		}
+
+		ctx := context.Background()
+		tr, err := newDocTranslator(ctx, &subFlags, sample.String(), placeholder.InstructionHint())
+		if err != nil {
+			return err
+		}
+		defer tr.Close()
+
+		kept := 0
+		for i, u := range units {
+			fmt.Fprintf(os.Stderr, "Translating unit %d/%d...\n", i+1, len(units))
+			var before, after string
+			if i > 0 {
+				before = units[i-1].PlainText()
+			}
+			if i+1 < len(units) {
+				after = units[i+1].PlainText()
+			}
+			err := translateSubtitle(ctx, tr, file, u, before, after)
+			if err != nil && len(u.Cues) > 1 {
+				fmt.Fprintf(os.Stderr, "%s: %v; translating its cues one by one\n", subtitleLabel(file, u), err)
+				err = nil
+				for _, one := range file.Split(u) {
+					if e := translateSubtitle(ctx, tr, file, one, before, after); e != nil {
+						fmt.Fprintf(os.Stderr, "%s: %v; keeping the source text\n", subtitleLabel(file, one), e)
+						kept++
+					}
+				}
+				continue
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v; keeping the source text\n", subtitleLabel(file, u), err)
+				kept += len(u.Cues)
+			}
+		}
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d cue(s) left untranslated\n", kept)
+		}
+
+		if violations := file.Check(subLimits); len(violations) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d of %d cue(s) exceed the limits:\n", len(violations), len(file.Cues))
+			for _, v := range violations {
+				fmt.Fprintf(os.Stderr, "  %s\n", v)
+			}
+		}
+
+		return writeOutput(subFlags.outputFile, file.String(), tr.sourceLang, subFlags.targetLang, false)
+	},
+}
+
+// translateSubtitle translates one unit and writes it into its cues.
+func translateSubtitle(ctx context.Context, tr *docTranslator, file *subtitle.File, u *subtitle.Unit, before, after string) error {
+	unit := docUnit{Text: u.Text, Label: subtitleLabel(file, u)}
+	unit.Before, unit.After = tr.surrounding(before, after)
+	if u.Dialogue() {
+		unit.Note = `Keep each speaker's line, starting with "-", on a line of its own.`
+	}
+	translation, err := tr.Translate(ctx, unit)
+	if err != nil {
+		return err
+	}
+	return file.Apply(u, translation, subLimits)
+}
+
+// subtitleLabel names a unit by its cues.
+func subtitleLabel(file *subtitle.File, u *subtitle.Unit) string {
+	first := file.Cues[u.Cues[0]].Label()
+	if len(u.Cues) == 1 {
+		return first
+	}
+	return fmt.Sprintf("%s and %d following cue(s)", first, len(u.Cues)-1)
+}
+
+func init() {
+	translateCmd.AddCommand(subtitlesCmd)
+	addDocFlags(subtitlesCmd, &subFlags)
+	subtitlesCmd.Flags().IntVar(&subLimits.MaxLineChars, "max-line-chars", 42, "Maximum characters per subtitle line")
+	subtitlesCmd.Flags().IntVar(&subLimits.MaxLines, "max-lines", 2, "Maximum lines per cue (checked and reported)")
+	subtitlesCmd.Flags().Float64Var(&subLimits.MaxCPS, "max-cps", 17, "Maximum reading speed in characters per second (checked and reported)")
+}
@@ -23,15 +23,16 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
 	"github.com/valpere/peretran/internal"
 	"github.com/valpere/peretran/internal/arbiter"
 	"github.com/valpere/peretran/internal/chunker"
+	"github.com/valpere/peretran/internal/consistency"
 	"github.com/valpere/peretran/internal/detector"
 	"github.com/valpere/peretran/internal/orchestrator"
 	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/prompts"
 	"github.com/valpere/peretran/internal/refiner"
 	"github.com/valpere/peretran/internal/store"
 	"github.com/valpere/peretran/internal/translator"
@@ -45,15 +46,27 @@ var (
 	credentials string
 	projectID   string
 
-	services     []string
-	useArbiter   bool
-	arbiterModel string
-	arbiterURL   string
+	services        []string
+	useArbiter      bool
+	arbiterProvider string
+	arbiterModel    string
+	arbiterURL      string
+	arbiterKey      string
+	arbiterMode     string
+	reviewBelow     float64
+	promptsDir      string
+	styleName       string
 
 	ollamaURL        string
 	ollamaModels     []string
 	openrouterKey    string
 	openrouterModels []string
+	modelRotation    string
+	seed             int64
+
+	ollamaConcurrency     int
+	openrouterConcurrency int
+	modelConcurrency      []string
 
 	systranKey    string
 	mymemoryEmail string
@@ -62,15 +75,32 @@ var (
 	noCache    bool
 	maxRetries int
 
-	useRefine    bool
-	refinerModel string
-	refinerURL   string
+	useRefine       bool
+	refineOnly      bool
+	refineMode      string
+	refineRounds    int
+	refinerProvider string
+	refinerModel    string
+	refinerURL      string
+	refinerKey      string
+
+	noRefineGuard       bool
+	refineBacktranslate bool
 
 	// Phase 6 flags
 	fuzzyThreshold float64
 	usePlaceholder bool
 	chunkSize      int
 	useGlossary    bool
+
+	useHedge bool
+
+	chunkMode     string
+	contextTokens int
+	shrinkRetries int
+	contextWords  int
+
+	checkConsistency bool
 )
 
 var translateCmd = &cobra.Command{
@@ -88,18 +118,57 @@ Available services:
 
 Use multiple services: --services google,ollama,openrouter
 
+Model rotation (Ollama/OpenRouter, --model-rotation):
+  random       Pick a random model per call (default)
+  round-robin  Cycle through the models in order
+  weighted     Random, proportional to weights given as --ollama-models gemma2:27b=3,qwen3:14b=1
+  sticky       Pick one model for the whole document
+  fixed        Always use the first model
+  fan-out      Run every model as a separate candidate (ollama/<model>)
+Use --seed for reproducible runs. With fan-out, --ollama-concurrency and
+--model-concurrency keep a single GPU box from being overloaded.
+
 Two-pass translation:
   --refine      Enable Stage 2 literary refinement pass
+  --refine-mode critique
+                Critique the draft and revise it against the critique,
+                repeating up to --refine-rounds times
+  --refine-only Skip Stage 1 and refine the drafts saved by an earlier run
+
+Stage 1 drafts are saved in the database. When only the refiner settings
+change, a --refine run reuses them instead of translating again.
 
 Phase 6 options:
   --fuzzy-threshold  Fuzzy cache matching (0 to disable, e.g. 0.85)
   --placeholder      Protect HTML/Markdown markup during translation
   --chunk-size       Split large texts into chunks of N characters
-  --glossary         Load terminology glossary from database`,
+  --chunk-mode tokens
+                     Size chunks to the token budget of the smallest model
+                     context (override it with --context-tokens)
+  --glossary         Load terminology glossary from database
+
+Latency:
+  --hedge            Duplicate slow Ollama/OpenRouter calls with another model
+                     once they exceed the service's p95 latency (needs --db history)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if inputFile == outputFile {
 			return fmt.Errorf("input file and output file cannot be the same")
 		}
+		if err := validateRefineMode(refineMode); err != nil {
+			return err
+		}
+		if chunkMode != "chars" && chunkMode != "tokens" {
+			return fmt.Errorf("unknown chunk mode %q (valid: chars, tokens)", chunkMode)
+		}
+		if contextWords < 0 {
+			return fmt.Errorf("--context-words must not be negative")
+		}
+		if refineOnly {
+			if noCache || dbPath == "" {
+				return fmt.Errorf("--refine-only reads the saved Stage 1 drafts and cannot be used with --no-cache")
+			}
+			useRefine = true
+		}
 
 		strInp, err := os.ReadFile(inputFile)
 		if err != nil {
@@ -119,6 +188,11 @@ Phase 6 options:
 			}
 		}
 
+		styleProfile, err := loadStyleProfile(ctx, dbPath, styleName)
+		if err != nil {
+			return err
+		}
+
 		var db *store.Store
 		if !noCache && dbPath != "" {
 			db, err = store.New(dbPath)
@@ -126,20 +200,6 @@ Phase 6 options:
 				return fmt.Errorf("failed to open database: %w", err)
 			}
 			defer db.Close()
-
-			// Exact cache check.
-			if cached, found, cacheErr := db.GetCachedTranslation(ctx, text, sourceLang, targetLang); cacheErr == nil && found {
-				fmt.Fprintf(os.Stderr, "Using cached translation\n")
-				return writeOutput(outputFile, cached, sourceLang, targetLang, true)
-			}
-
-			// Fuzzy cache check.
-			if fuzzyThreshold > 0 {
-				if cached, found, cacheErr := db.FuzzyGetCachedTranslation(ctx, text, sourceLang, targetLang, fuzzyThreshold); cacheErr == nil && found {
-					fmt.Fprintf(os.Stderr, "Using fuzzy-matched cached translation\n")
-					return writeOutput(outputFile, cached, sourceLang, targetLang, true)
-				}
-			}
 		}
 
 		// Load glossary from DB.
@@ -164,10 +224,86 @@ Phase 6 options:
 			}
 		}
 
-		// Split into chunks if requested.
-		chunks := chunker.Chunk(text, chunkSize)
-		if len(chunks) > 1 {
-			fmt.Fprintf(os.Stderr, "Splitting into %d chunks (max %d chars each)\n", len(chunks), chunkSize)
+		promptSet, err := prompts.Load(promptsDir)
+		if err != nil {
+			return err
+		}
+
+		svcOpts := serviceOptions{
+			ollamaURL:        ollamaURL,
+			ollamaModels:     ollamaModels,
+			openrouterKey:    openrouterKey,
+			openrouterModels: openrouterModels,
+			systranKey:       systranKey,
+			mymemoryEmail:    mymemoryEmail,
+			rotation:         modelRotation,
+			seed:             seed,
+
+			ollamaConcurrency:     ollamaConcurrency,
+			openrouterConcurrency: openrouterConcurrency,
+			modelConcurrency:      modelConcurrency,
+
+			prompts: promptSet,
+		}
+		arbOpts := arbiterOptions{
+			provider:      arbiterProvider,
+			model:         arbiterModel,
+			baseURL:       arbiterURL,
+			apiKey:        arbiterKey,
+			mode:          arbiterMode,
+			openrouterKey: openrouterKey,
+			glossary:      glossaryTerms,
+			prompts:       promptSet,
+			style:         styleProfile,
+		}
+		refOpts := refinerOptions{
+			provider:      refinerProvider,
+			model:         refinerModel,
+			baseURL:       refinerURL,
+			apiKey:        refinerKey,
+			openrouterKey: openrouterKey,
+			prompts:       promptSet,
+			style:         styleProfile,
+			glossary:      glossaryTerms,
+			instructions:  phHint,
+			maxRounds:     refineRounds,
+		}
+
+		var keyArbiter *arbiterOptions
+		if useArbiter {
+			keyArbiter = &arbOpts
+		}
+		draftKey := stage1Key(services, svcOpts, keyArbiter, glossaryTerms, styleProfile.Guide(), phHint)
+		refKey := ""
+		if useRefine {
+			refKey = refineKey(refOpts, refineMode, !noRefineGuard, refineBacktranslate)
+		}
+
+		// Translation memory is not style-specific, so it is bypassed
+		// when a style profile is active. A refined entry is reused only
+		// if it was refined with the current settings; otherwise the
+		// Stage 1 draft is refined again.
+		useMemory := db != nil && styleProfile == nil && !refineOnly
+		if useMemory && useRefine {
+			if key, found, keyErr := db.GetMemoryRefineKey(ctx, string(strInp), sourceLang, targetLang, ""); keyErr == nil && found && key != refKey {
+				fmt.Fprintf(os.Stderr, "Refiner settings changed since the cached translation; refining again\n")
+				useMemory = false
+			}
+		}
+		if useMemory {
+			// Exact cache check.
+			if cached, found, cacheErr := db.GetCachedTranslation(ctx, string(strInp), sourceLang, targetLang); cacheErr == nil && found {
+				fmt.Fprintf(os.Stderr, "Using cached translation\n")
+				return writeOutput(outputFile, cached, sourceLang, targetLang, true)
+			}
+
+			// Fuzzy cache check.
+			if fuzzyThreshold > 0 {
+				if cached, found, cacheErr := db.FuzzyGetCachedTranslation(ctx, string(strInp), sourceLang, targetLang, fuzzyThreshold); cacheErr == nil && found {
+					fmt.Fprintf(os.Stderr, "Using fuzzy-matched cached translation\n")
+					return writeOutput(outputFile, cached, sourceLang, targetLang, true)
+				}
+			}
 		}
 
 		cfg := translator.ServiceConfig{
@@ -175,48 +311,181 @@ Phase 6 options:
 			ProjectID:   projectID,
 		}
 
-		serviceList, err := buildServices(services, ollamaURL, openrouterKey, systranKey, mymemoryEmail, ollamaModels, openrouterModels)
+		serviceList, err := buildServices(services, svcOpts)
 		if err != nil {
 			return err
 		}
 
+		// Split into chunks if requested: by characters, or by the token
+		// budget of the most constrained model. Sentence ends follow the
+		// source language's rules.
+		chk := chunker.New(sourceLang)
+		budget, tok := tokenBudget(serviceList, contextTokens)
+		var chunks []string
+		if chunkMode == "tokens" {
+			budget.OverheadTokens = promptOverhead(promptSet, tok, contextWords, translator.TranslateRequest{
+				SourceLang:    sourceLang,
+				TargetLang:    targetLang,
+				GlossaryTerms: glossaryTerms,
+				Instructions:  phHint,
+				StyleGuide:    styleProfile.Guide(),
+			})
+			maxTokens := budget.MaxChunkTokens()
+			if maxTokens <= 0 {
+				return fmt.Errorf("prompt overhead of %d tokens leaves no room for text in a %d-token context; raise --context-tokens", budget.OverheadTokens, budget.ContextTokens)
+			}
+			chunks = chk.ChunkTokens(text, maxTokens, tok)
+			if len(chunks) > 1 {
+				fmt.Fprintf(os.Stderr, "Splitting into %d chunks (max %d tokens each: %d-token context, %d-token prompt overhead)\n", len(chunks), maxTokens, budget.ContextTokens, budget.OverheadTokens)
+			}
+		} else {
+			chunks = chk.Chunk(text, chunkSize)
+			if len(chunks) > 1 {
+				fmt.Fprintf(os.Stderr, "Splitting into %d chunks (max %d chars each)\n", len(chunks), chunkSize)
+			}
+		}
+
+		// seps[i] joins the translation of chunk i to the next one;
+		// shrinks[i] counts how often chunk i was split after a truncated
+		// translation.
+		seps := make([]string, len(chunks))
+		for i := range seps[:len(seps)-1] {
+			seps[i] = "\n\n"
+		}
+		shrinks := make([]int, len(chunks))
+
+		var hedgeAfter map[string]time.Duration
+		if useHedge {
+			hedgeAfter = buildHedgeDelays(ctx, db, serviceList)
+		}
+
 		orch := orchestrator.New(serviceList, orchestrator.OrchestratorConfig{
 			Timeout:     30 * time.Second,
 			MinServices: 1,
 			MaxAttempts: maxRetries,
+			HedgeAfter:  hedgeAfter,
 		})
 
+		var arb arbiter.Arbiter
+		if useArbiter && !refineOnly {
+			arb, err = buildArbiter(arbOpts)
+			if err != nil {
+				return err
+			}
+		}
+
+		var ref refiner.LLMRefiner
+		if useRefine {
+			ref, err = buildRefiner(refOpts)
+			if err != nil {
+				return err
+			}
+		}
+		guard := buildRefineGuard(useRefine && !noRefineGuard, refineBacktranslate, serviceList, cfg)
+
 		// Translate all chunks sequentially with sliding context.
-		var translatedChunks []string
+		// chunkHashes[i] is the context hash chunk i is remembered under.
+		var translatedChunks, chunkHashes []string
 		previousContext := ""
 
-		for i, chunk := range chunks {
+		for i := 0; i < len(chunks); i++ {
+			chunk := chunks[i]
 			if len(chunks) > 1 {
 				fmt.Fprintf(os.Stderr, "Translating chunk %d/%d...\n", i+1, len(chunks))
 			}
 
+			// The source text around the chunk is shown to the model, so
+			// the chunk's cached translations are keyed by it too.
+			var sourceBefore, sourceAfter string
+			if contextWords > 0 {
+				if i > 0 {
+					sourceBefore = chunker.ExtractContext(chunks[i-1], contextWords)
+				}
+				if i+1 < len(chunks) {
+					sourceAfter = chunker.ExtractLeadingContext(chunks[i+1], contextWords)
+				}
+			}
+			ctxHash := store.ContextHash(sourceBefore, sourceAfter)
+
+			if db != nil && len(chunks) > 1 && styleProfile == nil && !refineOnly {
+				if cached, found := loadChunkMemory(ctx, db, chunk, sourceLang, targetLang, ctxHash, refKey); found {
+					fmt.Fprintf(os.Stderr, "Using cached translation (chunk %d)\n", i+1)
+					if contextWords > 0 {
+						previousContext = chunker.ExtractContext(cached, contextWords)
+					}
+					translatedChunks = append(translatedChunks, cached)
+					chunkHashes = append(chunkHashes, ctxHash)
+					continue
+				}
+			}
+
 			req := translator.TranslateRequest{
 				Text:            chunk,
 				SourceLang:      sourceLang,
 				TargetLang:      targetLang,
 				PreviousContext: previousContext,
+				SourceBefore:    sourceBefore,
+				SourceAfter:     sourceAfter,
 				GlossaryTerms:   glossaryTerms,
 				Instructions:    phHint,
-			}
-
-			// Stage 1: parallel translation.
-			result := orch.Execute(ctx, cfg, req)
-			if result.Succeeded == 0 {
-				return fmt.Errorf("all translation services failed (chunk %d)", i+1)
+				StyleGuide:      styleProfile.Guide(),
 			}
 
 			var draftText string
 			var selectedService string
+			var draftReused bool
 			var isComposite bool
 			var arbiterReasoning string
+			var arbiterScores map[string]internal.RubricScores
+			var arbiterPrompt, refinerPrompt string
+			var refineRoundsRun []refiner.Round
+
+			// Stage 1: reuse a saved draft when only Stage 2 is re-run,
+			// otherwise translate in parallel.
+			result := &orchestrator.OrchestratorResult{}
+			if db != nil && useRefine {
+				draftText, selectedService, draftReused = loadStage1Draft(ctx, db, chunk, sourceLang, targetLang, contextKey(draftKey, ctxHash), refineOnly)
+			}
+			if draftReused {
+				fmt.Fprintf(os.Stderr, "Reusing Stage 1 draft from %s (chunk %d)\n", selectedService, i+1)
+			} else if refineOnly {
+				return fmt.Errorf("no saved Stage 1 draft for chunk %d; run once without --refine-only first", i+1)
+			} else {
+				result = orch.Execute(ctx, cfg, req)
+				if result.Succeeded == 0 {
+					return fmt.Errorf("all translation services failed (chunk %d)", i+1)
+				}
+
+				// Adaptive chunking: a chunk whose every translation came
+				// back truncated is split into smaller chunks and retried.
+				complete, truncated := splitTruncated(chunk, result.Results)
+				if len(complete) == 0 && shrinks[i] < shrinkRetries {
+					if pieces, pieceSeps := chk.Shrink(chunk, chunker.DefaultShrinkFactor, tok); len(pieces) > 1 {
+						fmt.Fprintf(os.Stderr, "Translation of chunk %d was truncated; retrying as %d smaller chunks\n", i+1, len(pieces))
+						pieceSeps[len(pieceSeps)-1] = seps[i]
+						pieceShrinks := make([]int, len(pieces))
+						for j := range pieceShrinks {
+							pieceShrinks[j] = shrinks[i] + 1
+						}
+						chunks = append(chunks[:i], append(pieces, chunks[i+1:]...)...)
+						seps = append(seps[:i], append(pieceSeps, seps[i+1:]...)...)
+						shrinks = append(shrinks[:i], append(pieceShrinks, shrinks[i+1:]...)...)
+						i--
+						continue
+					}
+				}
+				switch {
+				case len(complete) == 0:
+					fmt.Fprintf(os.Stderr, "Warning: translation of chunk %d looks truncated\n", i+1)
+				case len(truncated) > 0:
+					fmt.Fprintf(os.Stderr, "Dropped %d truncated translation(s) of chunk %d\n", len(truncated), i+1)
+					result.Results = complete
+				}
+			}
 
-			if useArbiter && len(result.Results) > 1 {
-				arb := arbiter.NewOllamaArbiter(arbiterModel, arbiterURL)
+			switch {
+			case draftReused:
+			case arb != nil && len(result.Results) > 1:
 				evalResult, evalErr := arb.Evaluate(ctx, chunk, sourceLang, targetLang, result.Results)
 				if evalErr != nil {
 					fmt.Fprintf(os.Stderr, "Arbiter failed: %v, using first result\n", evalErr)
@@ -227,56 +496,137 @@ Phase 6 options:
 					selectedService = evalResult.SelectedService
 					isComposite = evalResult.IsComposite
 					arbiterReasoning = evalResult.Reasoning
+					arbiterScores = evalResult.Scores
+					arbiterPrompt = evalResult.PromptTemplate
 					fmt.Fprintf(os.Stderr, "Arbiter selected: %s\n", evalResult.SelectedService)
+					if best, ok := evalResult.BestAccuracy(); ok && best < reviewBelow {
+						fmt.Fprintf(os.Stderr, "Needs human review: chunk %d best accuracy %.1f/5 is below %.1f\n", i+1, best, reviewBelow)
+					}
 				}
-			} else {
+			default:
 				draftText = result.Results[0].TranslatedText
 				selectedService = result.Results[0].ServiceName
 			}
 
 			// Stage 2: optional literary refinement.
 			chunkTranslation := draftText
-			if useRefine {
+			if ref != nil {
 				fmt.Fprintf(os.Stderr, "Running Stage 2 refinement (chunk %d)...\n", i+1)
-				ref := refiner.NewOllamaRefiner(refinerModel, refinerURL)
-				refined, refErr := ref.Refine(ctx, sourceLang, targetLang, chunk, draftText)
-				if refErr != nil {
-					fmt.Fprintf(os.Stderr, "Refiner failed: %v, using draft\n", refErr)
+				if refineMode == "critique" {
+					res, refErr := ref.RefineWithCritique(ctx, sourceLang, targetLang, chunk, draftText)
+					if refErr != nil {
+						fmt.Fprintf(os.Stderr, "Refiner failed: %v, using draft\n", refErr)
+					} else {
+						printCritiqueReport(fmt.Sprintf("chunk %d", i+1), res)
+						chunkTranslation = res.Text
+						refinerPrompt = res.PromptTemplate
+						refineRoundsRun = res.Rounds
+					}
 				} else {
-					chunkTranslation = refined
+					refinerPrompt = ref.PromptTemplate()
+					refined, refErr := ref.Refine(ctx, sourceLang, targetLang, chunk, draftText)
+					if refErr != nil {
+						fmt.Fprintf(os.Stderr, "Refiner failed: %v, using draft\n", refErr)
+					} else {
+						chunkTranslation = refined
+					}
+				}
+				if guard != nil && chunkTranslation != draftText {
+					if gErr := guard.Check(ctx, sourceLang, targetLang, chunk, draftText, chunkTranslation); gErr != nil {
+						fmt.Fprintf(os.Stderr, "Refinement rejected (chunk %d): %v; using draft\n", i+1, gErr)
+						chunkTranslation = draftText
+					}
 				}
 			}
 
 			// Update sliding context for the next chunk.
-			previousContext = chunker.ExtractContext(chunkTranslation, chunker.DefaultContextWords)
+			if contextWords > 0 {
+				previousContext = chunker.ExtractContext(chunkTranslation, contextWords)
+			}
 
 			translatedChunks = append(translatedChunks, chunkTranslation)
+			chunkHashes = append(chunkHashes, ctxHash)
 
 			// Persist chunk result to cache and DB.
 			if db != nil && !noCache && len(chunks) == 1 {
 				// Only save single-chunk translations to full-text cache.
-				reqID := uuid.New().String()
-				memReq := internal.TranslationRequest{
-					ID:         reqID,
-					SourceText: string(strInp),
+				reqID := saveRunRecord(ctx, db, runRecord{
+					Source:     string(strInp),
 					SourceLang: sourceLang,
 					TargetLang: targetLang,
-					Timestamp:  time.Now(),
+					Results:    result.Results,
+					Scores:     arbiterScores,
+					Rounds:     refineRoundsRun,
+				})
+				_ = db.SaveFinalTranslation(ctx, reqID, selectedService, chunkTranslation, isComposite, arbiterReasoning)
+				_ = db.SaveFinalPromptTemplates(ctx, reqID, arbiterPrompt, refinerPrompt)
+				if styleProfile == nil {
+					_ = db.SaveToMemory(ctx, string(strInp), sourceLang, targetLang, chunkTranslation, draftText, selectedService)
+					_ = db.SetMemoryRefineKey(ctx, string(strInp), sourceLang, targetLang, "", refKey)
 				}
-				_ = db.SaveRequest(ctx, memReq)
-				for _, r := range result.Results {
-					_ = db.SaveResult(ctx, reqID, r.ServiceName, r.TranslatedText, r.Confidence, int(r.Latency.Milliseconds()), r.Error)
+			}
+			// Chunks of a longer document are remembered with their
+			// surrounding source text.
+			if db != nil && len(chunks) > 1 && styleProfile == nil {
+				_ = db.SaveToMemoryInContext(ctx, chunk, sourceLang, targetLang, chunkTranslation, draftText, selectedService, ctxHash)
+				_ = db.SetMemoryRefineKey(ctx, chunk, sourceLang, targetLang, ctxHash, refKey)
+			}
+			if db != nil && len(chunks) > 1 && (len(result.Results) > 0 || len(refineRoundsRun) > 0) {
+				saveRunRecord(ctx, db, runRecord{
+					Source:     chunk,
+					SourceLang: sourceLang,
+					TargetLang: targetLang,
+					Results:    result.Results,
+					Scores:     arbiterScores,
+					Rounds:     refineRoundsRun,
+				})
+			}
+			// Keep every fresh draft so a later run can re-refine it
+			// without Stage 1.
+			if db != nil && !draftReused {
+				_ = db.SaveStage1Draft(ctx, chunk, sourceLang, targetLang, draftText, selectedService, contextKey(draftKey, ctxHash))
+			}
+		}
+
+		// Document consistency pass: give recurring names and terms the
+		// same rendering in every chunk.
+		if checkConsistency && len(translatedChunks) > 1 {
+			model := ref
+			if model == nil {
+				if model, err = buildRefiner(refOpts); err != nil {
+					return err
 				}
-				_ = db.SaveFinalTranslation(ctx, reqID, selectedService, chunkTranslation, isComposite, arbiterReasoning)
-				_ = db.SaveToMemory(ctx, string(strInp), sourceLang, targetLang, chunkTranslation, draftText, selectedService)
-				if useRefine {
-					_ = db.SaveToStage1Cache(ctx, string(strInp), sourceLang, targetLang, draftText, selectedService)
+			}
+			checker := consistency.New(model)
+			checker.SetPrompts(promptSet)
+			checker.SetGlossary(glossaryTerms)
+			checker.SetInstructions(phHint)
+			if g := buildRefineGuard(!noRefineGuard, refineBacktranslate, serviceList, cfg); g != nil {
+				checker.SetGuard(g)
+			}
+			fmt.Fprintf(os.Stderr, "Running consistency pass over %d chunks...\n", len(translatedChunks))
+			harmonized, report, cErr := checker.Harmonize(ctx, sourceLang, targetLang, chunks, translatedChunks)
+			if cErr != nil {
+				return fmt.Errorf("consistency pass failed: %w", cErr)
+			}
+			printConsistencyReport(report)
+			// Remember the revised chunks, so a re-run serves them.
+			if db != nil && styleProfile == nil {
+				for i := range harmonized {
+					if harmonized[i] != translatedChunks[i] {
+						_ = db.UpdateMemoryText(ctx, chunks[i], sourceLang, targetLang, chunkHashes[i], harmonized[i])
+					}
 				}
 			}
+			translatedChunks = harmonized
 		}
 
 		// Join chunk translations.
-		finalText := strings.Join(translatedChunks, "\n\n")
+		var joined strings.Builder
+		for i, t := range translatedChunks {
+			joined.WriteString(t + seps[i])
+		}
+		finalText := joined.String()
 
 		// Restore placeholders.
 		if usePlaceholder && len(phMarkers) > 0 {
@@ -318,17 +668,37 @@ func init() {
 
 	translateCmd.Flags().StringSliceVar(&services, "services", []string{"google"}, "Translation services to use (comma-separated)")
 	translateCmd.Flags().BoolVar(&useArbiter, "arbiter", false, "Use LLM arbiter to select best translation")
-	translateCmd.Flags().StringVar(&arbiterModel, "arbiter-model", "llama3.2", "Arbiter model name")
-	translateCmd.Flags().StringVar(&arbiterURL, "arbiter-url", "http://localhost:11434", "Arbiter Ollama URL")
+	translateCmd.Flags().StringVar(&arbiterProvider, "arbiter-provider", "ollama", "Arbiter backend: ollama, openrouter, openai (any OpenAI-compatible endpoint), consensus (metric-based, no LLM)")
+	translateCmd.Flags().StringVar(&arbiterModel, "arbiter-model", "", "Arbiter model name (default depends on provider: llama3.2, first OpenRouter model, gpt-4o-mini)")
+	translateCmd.Flags().StringVar(&arbiterURL, "arbiter-url", "", "Arbiter endpoint URL (default depends on provider)")
+	translateCmd.Flags().StringVar(&arbiterKey, "arbiter-key", "", "Arbiter API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
+	translateCmd.Flags().StringVar(&arbiterMode, "arbiter-mode", "single", "Arbiter mode: single (one prompt with all candidates), tournament (pairwise comparisons with order swapping, Bradley-Terry ranking)")
+	translateCmd.Flags().Float64Var(&reviewBelow, "review-below", 0, "Flag chunks for human review when the best arbiter accuracy score (1-5) is below this value; 0 disables")
+
+	translateCmd.Flags().StringVar(&styleName, "style", "", "Style profile from the database to translate with (see: peretran style list); bypasses the translation memory")
+	translateCmd.Flags().StringVar(&promptsDir, "prompts-dir", "", "Directory of *.tmpl prompt templates overriding the built-in ones (see: peretran prompts list)")
 
 	translateCmd.Flags().BoolVar(&useRefine, "refine", false, "Enable Stage 2 literary refinement (two-pass translation)")
-	translateCmd.Flags().StringVar(&refinerModel, "refiner-model", "llama3.2", "Refiner model name")
-	translateCmd.Flags().StringVar(&refinerURL, "refiner-url", "http://localhost:11434", "Refiner Ollama URL")
+	translateCmd.Flags().BoolVar(&refineOnly, "refine-only", false, "Skip Stage 1 and refine the Stage 1 drafts saved by an earlier run (implies --refine)")
+	translateCmd.Flags().StringVar(&refineMode, "refine-mode", "literary", "Refinement mode: literary (one polishing pass), critique (critique-then-revise rounds)")
+	translateCmd.Flags().IntVar(&refineRounds, "refine-rounds", refiner.DefaultCritiqueRounds, "Maximum critique-then-revise rounds in critique mode")
+	translateCmd.Flags().BoolVar(&noRefineGuard, "no-refine-guard", false, "Accept refinements and consistency revisions without checking length, numbers, URLs, placeholders and language against the draft")
+	translateCmd.Flags().BoolVar(&refineBacktranslate, "refine-backtranslate", false, "Also reject refinements whose back-translation (by the first service) drifts from the source")
+	translateCmd.Flags().BoolVar(&checkConsistency, "consistency", false, "After translating a multi-chunk document, harmonize names and terms rendered differently across chunks (uses the refiner model)")
+	translateCmd.Flags().StringVar(&refinerProvider, "refiner-provider", "ollama", "Refiner backend: ollama, openrouter, openai (any OpenAI-compatible endpoint)")
+	translateCmd.Flags().StringVar(&refinerModel, "refiner-model", "", "Refiner model name (default depends on provider: llama3.2, first OpenRouter model, gpt-4o-mini)")
+	translateCmd.Flags().StringVar(&refinerURL, "refiner-url", "", "Refiner endpoint URL (default depends on provider)")
+	translateCmd.Flags().StringVar(&refinerKey, "refiner-key", "", "Refiner API key (openrouter falls back to --openrouter-key, openai to OPENAI_API_KEY)")
 
 	translateCmd.Flags().StringVar(&ollamaURL, "ollama-url", "http://localhost:11434", "Ollama base URL")
-	translateCmd.Flags().StringSliceVar(&ollamaModels, "ollama-models", nil, "Ollama models to rotate (default list used if empty)")
+	translateCmd.Flags().StringSliceVar(&ollamaModels, "ollama-models", nil, "Ollama models to rotate, optionally weighted as model=N (default list used if empty)")
 	translateCmd.Flags().StringVar(&openrouterKey, "openrouter-key", "", "OpenRouter API key")
-	translateCmd.Flags().StringSliceVar(&openrouterModels, "openrouter-models", nil, "OpenRouter models to rotate (default list used if empty)")
+	translateCmd.Flags().StringSliceVar(&openrouterModels, "openrouter-models", nil, "OpenRouter models to rotate, optionally weighted as model=N (default list used if empty)")
+	translateCmd.Flags().StringVar(&modelRotation, "model-rotation", "random", "Ollama/OpenRouter model selection: random, round-robin, weighted, sticky, fixed, fan-out")
+	translateCmd.Flags().Int64Var(&seed, "seed", 0, "Seed for model rotation (0 = non-reproducible)")
+	translateCmd.Flags().IntVar(&ollamaConcurrency, "ollama-concurrency", 0, "Max in-flight Ollama requests across all models (0 = unlimited)")
+	translateCmd.Flags().IntVar(&openrouterConcurrency, "openrouter-concurrency", 0, "Max in-flight OpenRouter requests across all models (0 = unlimited)")
+	translateCmd.Flags().StringSliceVar(&modelConcurrency, "model-concurrency", nil, "Per-model max in-flight requests as model=N, or N for every model")
 	translateCmd.Flags().StringVar(&systranKey, "systran-key", "", "Systran API key")
 	translateCmd.Flags().StringVar(&mymemoryEmail, "mymemory-email", "", "MyMemory email (for higher limits)")
 
@@ -340,8 +710,14 @@ func init() {
 	translateCmd.Flags().Float64Var(&fuzzyThreshold, "fuzzy-threshold", 0, "Fuzzy cache similarity threshold (0 to disable, e.g. 0.85)")
 	translateCmd.Flags().BoolVar(&usePlaceholder, "placeholder", false, "Protect HTML/Markdown markup with placeholders during translation")
 	translateCmd.Flags().IntVar(&chunkSize, "chunk-size", 0, "Split input into chunks of N characters (0 = no chunking)")
+	translateCmd.Flags().StringVar(&chunkMode, "chunk-mode", "chars", "Chunking: chars (--chunk-size characters), tokens (fit the token budget of the smallest model context)")
+	translateCmd.Flags().IntVar(&contextTokens, "context-tokens", 0, "Context window in tokens for --chunk-mode tokens, e.g. the Ollama num_ctx (0 = per model)")
+	translateCmd.Flags().IntVar(&contextWords, "context-words", chunker.DefaultContextWords, "Words of surrounding text shown to LLMs on each side of a chunk, and of the previous chunk's translation (0 = none of either)")
+	translateCmd.Flags().IntVar(&shrinkRetries, "shrink-retries", chunker.DefaultShrinkAttempts, "Times a chunk is split by 60% and retried when its translation is truncated (0 = never)")
 	translateCmd.Flags().BoolVar(&useGlossary, "glossary", false, "Load terminology glossary from database for LLM services")
 
+	translateCmd.Flags().BoolVar(&useHedge, "hedge", false, "Fire a duplicate request with another model when an LLM service exceeds its p95 latency")
+
 	translateCmd.MarkFlagRequired("input")
 	translateCmd.MarkFlagRequired("output")
 	translateCmd.MarkFlagRequired("target")
@@ -0,0 +1,127 @@
+/*
+Copyright © 2025 Valentyn Solomko <valentyn.solomko@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/xliff"
+)
+
+var xliffFlags docFlags
+
+var xliffCmd = &cobra.Command{
+	Use:     "xliff",
+	Aliases: []string{"xlf"},
+	Short:   "Fill in the targets of an XLIFF 1.2 or 2.0 file",
+	Long: `Translate the units of an XLIFF 1.2 or 2.0 file and write their targets.
+
+Each 1.2 <trans-unit> or 2.0 <segment> is translated on its own, with the
+notes of its unit given to LLMs as context. Inline elements reach the
+services as [PHn] placeholders: <g>, <pc> and <mrk> keep their content
+translatable, while codes such as <x/>, <ph>, <bpt> or <sc/> are kept whole.
+
+Units marked translate="no", approved 1.2 units, and units that already have
+a target are left alone, unless the target's state asks for a translation
+(1.2 "new" or "needs-translation", 2.0 "initial"). Machine translations get
+state="needs-review-translation" in 1.2 and state="translated" in 2.0, and
+the target language is set on the file. Everything else in the file is
+written back unchanged.
+
+A unit whose translation lost or misplaced a placeholder keeps no target and
+is reported, so the XML is never broken.
+
+Example:
+  peretran translate xliff -i messages.xlf -o messages.uk.xlf -t uk --services ollama`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.ReadFile(xliffFlags.inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		doc, err := xliff.Parse(string(src))
+		if err != nil {
+			return err
+		}
+		if xliffFlags.sourceLang == "auto" && doc.SourceLang != "" {
+			xliffFlags.sourceLang = doc.SourceLang
+		}
+
+		var sample strings.Builder
+		for _, u := range doc.Units {
+			sample.WriteString(u.PlainText() + "\n")
+		}
+
+		ctx := context.Background()
+		tr, err := newDocTranslator(ctx, &xliffFlags, sample.String(), placeholder.InstructionHint())
+		if err != nil {
+			return err
+		}
+		defer tr.Close()
+
+		translated, skipped, kept := 0, 0, 0
+		for i, u := range doc.Units {
+			if !u.NeedsTranslation() {
+				skipped++
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Translating unit %d/%d...\n", i+1, len(doc.Units))
+			var before, after string
+			if i > 0 {
+				before = doc.Units[i-1].PlainText()
+			}
+			if i+1 < len(doc.Units) {
+				after = doc.Units[i+1].PlainText()
+			}
+			unit := docUnit{Text: strings.TrimSpace(u.Text), Label: fmt.Sprintf("unit %s", u.ID)}
+			unit.Before, unit.After = tr.surrounding(before, after)
+			if u.Note != "" {
+				unit.Note = "Note for translators: " + strings.ReplaceAll(u.Note, "\n", "; ")
+			}
+
+			translation, err := tr.Translate(ctx, unit)
+			if err == nil {
+				err = doc.Apply(i, translation)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unit %s: %v; left untranslated\n", u.ID, err)
+				kept++
+				continue
+			}
+			translated++
+		}
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Skipped %d unit(s) that are translated, approved or marked translate=\"no\"\n", skipped)
+		}
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d of %d unit(s) left untranslated\n", kept, len(doc.Units))
+		}
+		fmt.Fprintf(os.Stderr, "Translated %d unit(s), marked for review\n", translated)
+
+		doc.SetTargetLang(xliffFlags.targetLang)
+		return writeOutput(xliffFlags.outputFile, doc.Render(), tr.sourceLang, xliffFlags.targetLang, false)
+	},
+}
+
+func init() {
+	translateCmd.AddCommand(xliffCmd)
+	addDocFlags(xliffCmd, &xliffFlags)
+}
@@ -8,8 +8,10 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/pemistahl/lingua-go v1.4.0
 	github.com/spf13/cobra v1.8.1
+	golang.org/x/net v0.43.0
 	golang.org/x/text v0.28.0
 	google.golang.org/api v0.247.0
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.46.0
 )
 
@@ -40,7 +42,6 @@ require (
 	go.opentelemetry.io/otel/trace v1.36.0 // indirect
 	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
-	golang.org/x/net v0.43.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250818200422-3122310a409c // indirect
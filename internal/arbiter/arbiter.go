@@ -3,6 +3,7 @@ package arbiter
 import (
 	"context"
 
+	"github.com/valpere/peretran/internal"
 	"github.com/valpere/peretran/internal/translator"
 )
 
@@ -11,6 +12,28 @@ type EvaluationResult struct {
 	CompositeText   string
 	IsComposite     bool
 	Reasoning       string
+
+	// Scores holds per-candidate rubric scores keyed by service name.
+	// It is nil for arbiters that do not score (consensus, tournament).
+	Scores map[string]internal.RubricScores
+
+	// PromptTemplate identifies the prompt template that produced the
+	// verdict (see prompts.Set.ID); empty for arbiters without a prompt.
+	PromptTemplate string
+}
+
+// BestAccuracy returns the highest accuracy score among the candidates.
+// ok is false when the arbiter scored no candidate's accuracy.
+func (e *EvaluationResult) BestAccuracy() (best float64, ok bool) {
+	for _, s := range e.Scores {
+		if s.Accuracy == 0 {
+			continue
+		}
+		if !ok || s.Accuracy > best {
+			best, ok = s.Accuracy, true
+		}
+	}
+	return best, ok
 }
 
 type Arbiter interface {
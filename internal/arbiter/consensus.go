@@ -0,0 +1,243 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/valpere/peretran/internal/metrics"
+	"github.com/valpere/peretran/internal/translator"
+)
+
+// LanguageValidator reports whether text is written in lang.
+// *validator.Validator satisfies it.
+type LanguageValidator interface {
+	IsValid(text, lang string) (bool, error)
+}
+
+// ConsensusWeights sets how much each signal contributes to a candidate's
+// expected utility. Weights are normalised, so only their ratios matter.
+type ConsensusWeights struct {
+	Consensus float64
+	Length    float64
+	Glossary  float64
+	Language  float64
+}
+
+// DefaultConsensusWeights favours cross-candidate agreement, with glossary
+// compliance as the strongest secondary signal.
+var DefaultConsensusWeights = ConsensusWeights{
+	Consensus: 0.6,
+	Length:    0.1,
+	Glossary:  0.2,
+	Language:  0.1,
+}
+
+// ConsensusArbiter is a reference-free, non-LLM arbiter. It selects the
+// candidate with the highest expected utility (minimum Bayes risk): a
+// translation that agrees with the other candidates is likely correct. The
+// score combines:
+//   - consensus: mean chrF/BLEU agreement with every other candidate
+//   - length: how close the candidate's length ratio to the source is to the
+//     candidates' median ratio (robust across scripts)
+//   - glossary: fraction of applicable glossary terms rendered as required
+//   - language: whether the validator accepts the target language
+//
+// It is fast, free and deterministic: ties are broken by service name.
+type ConsensusArbiter struct {
+	glossary  map[string]string
+	validator LanguageValidator
+	weights   ConsensusWeights
+}
+
+// NewConsensusArbiter creates a consensus arbiter. glossary and v may be nil.
+func NewConsensusArbiter(glossary map[string]string, v LanguageValidator) *ConsensusArbiter {
+	return &ConsensusArbiter{
+		glossary:  glossary,
+		validator: v,
+		weights:   DefaultConsensusWeights,
+	}
+}
+
+// SetWeights overrides DefaultConsensusWeights.
+func (a *ConsensusArbiter) SetWeights(w ConsensusWeights) {
+	a.weights = w
+}
+
+// CandidateScore holds the per-signal scores of one candidate.
+type CandidateScore struct {
+	ServiceName string
+	Consensus   float64
+	Length      float64
+	Glossary    float64
+	Language    float64
+	Utility     float64
+}
+
+func (a *ConsensusArbiter) Evaluate(ctx context.Context, source string, sourceLang, targetLang string, results []translator.ServiceResult) (*EvaluationResult, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results to evaluate")
+	}
+
+	if len(results) == 1 {
+		return &EvaluationResult{
+			SelectedService: results[0].ServiceName,
+			CompositeText:   results[0].TranslatedText,
+			IsComposite:     false,
+			Reasoning:       "Only one service available",
+		}, nil
+	}
+
+	scores := a.Score(source, targetLang, results)
+
+	best := 0
+	for i := 1; i < len(scores); i++ {
+		if scores[i].Utility > scores[best].Utility ||
+			(scores[i].Utility == scores[best].Utility && scores[i].ServiceName < scores[best].ServiceName) {
+			best = i
+		}
+	}
+
+	return &EvaluationResult{
+		SelectedService: results[best].ServiceName,
+		CompositeText:   results[best].TranslatedText,
+		IsComposite:     false,
+		Reasoning:       formatConsensusReasoning(scores, best),
+	}, nil
+}
+
+// Score computes the per-signal scores and expected utility of every
+// candidate, in the order of results.
+func (a *ConsensusArbiter) Score(source, targetLang string, results []translator.ServiceResult) []CandidateScore {
+	n := len(results)
+	scores := make([]CandidateScore, n)
+
+	// Pairwise agreement: average of chrF in both directions and BLEU, so the
+	// utility is symmetric and robust for morphologically rich languages.
+	agreement := make([][]float64, n)
+	for i := range agreement {
+		agreement[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			hi, hj := results[i].TranslatedText, results[j].TranslatedText
+			chrf := (metrics.ChrF(hi, hj) + metrics.ChrF(hj, hi)) / 2
+			bleu := (metrics.BLEU(hi, hj) + metrics.BLEU(hj, hi)) / 2
+			u := 0.75*chrf + 0.25*bleu
+			agreement[i][j], agreement[j][i] = u, u
+		}
+	}
+
+	srcLen := float64(len([]rune(strings.TrimSpace(source))))
+	ratios := make([]float64, n)
+	for i, r := range results {
+		if srcLen > 0 {
+			ratios[i] = float64(len([]rune(strings.TrimSpace(r.TranslatedText)))) / srcLen
+		}
+	}
+	median := medianOf(ratios)
+
+	applicable := applicableTerms(source, a.glossary)
+
+	w := a.weights
+	wTotal := w.Consensus + w.Length + w.Glossary + w.Language
+	if wTotal <= 0 {
+		w, wTotal = DefaultConsensusWeights, 1
+	}
+
+	for i, r := range results {
+		s := CandidateScore{ServiceName: r.ServiceName}
+
+		for j := 0; j < n; j++ {
+			if j != i {
+				s.Consensus += agreement[i][j]
+			}
+		}
+		s.Consensus /= float64(n - 1)
+
+		s.Length = lengthScore(ratios[i], median)
+		s.Glossary = glossaryCompliance(r.TranslatedText, applicable)
+
+		s.Language = 1
+		if a.validator != nil {
+			if ok, _ := a.validator.IsValid(r.TranslatedText, targetLang); !ok {
+				s.Language = 0
+			}
+		}
+
+		s.Utility = (w.Consensus*s.Consensus + w.Length*s.Length +
+			w.Glossary*s.Glossary + w.Language*s.Language) / wTotal
+		scores[i] = s
+	}
+	return scores
+}
+
+// lengthScore is 1 when ratio equals the median ratio and decays as the
+// candidate gets relatively longer or shorter.
+func lengthScore(ratio, median float64) float64 {
+	if ratio <= 0 || median <= 0 {
+		return 0
+	}
+	return math.Min(ratio, median) / math.Max(ratio, median)
+}
+
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// applicableTerms returns the glossary entries whose source term occurs in source.
+func applicableTerms(source string, glossary map[string]string) map[string]string {
+	terms := make(map[string]string)
+	lower := strings.ToLower(source)
+	for src, tgt := range glossary {
+		if src != "" && strings.Contains(lower, strings.ToLower(src)) {
+			terms[src] = tgt
+		}
+	}
+	return terms
+}
+
+// glossaryCompliance returns the fraction of terms whose required target
+// rendering appears in text, or 1 when no glossary term applies.
+func glossaryCompliance(text string, terms map[string]string) float64 {
+	if len(terms) == 0 {
+		return 1
+	}
+	lower := strings.ToLower(text)
+	hit := 0
+	for _, tgt := range terms {
+		if strings.Contains(lower, strings.ToLower(tgt)) {
+			hit++
+		}
+	}
+	return float64(hit) / float64(len(terms))
+}
+
+func formatConsensusReasoning(scores []CandidateScore, best int) string {
+	ordered := append([]CandidateScore(nil), scores...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Utility != ordered[j].Utility {
+			return ordered[i].Utility > ordered[j].Utility
+		}
+		return ordered[i].ServiceName < ordered[j].ServiceName
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Consensus selected %s (highest expected utility).", scores[best].ServiceName))
+	for _, s := range ordered {
+		sb.WriteString(fmt.Sprintf("\n  %s: utility=%.3f consensus=%.3f length=%.3f glossary=%.3f language=%.0f",
+			s.ServiceName, s.Utility, s.Consensus, s.Length, s.Glossary, s.Language))
+	}
+	return sb.String()
+}
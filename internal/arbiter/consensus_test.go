@@ -0,0 +1,114 @@
+package arbiter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/translator"
+)
+
+// stubValidator accepts only the texts listed in valid.
+type stubValidator struct {
+	valid map[string]bool
+}
+
+func (v stubValidator) IsValid(text, lang string) (bool, error) {
+	return v.valid[text], nil
+}
+
+func TestConsensusArbiter_SelectsMajority(t *testing.T) {
+	a := NewConsensusArbiter(nil, nil)
+
+	results := []translator.ServiceResult{
+		{ServiceName: "outlier", TranslatedText: "Собака бігає в парку біля озера"},
+		{ServiceName: "google", TranslatedText: "Кіт сидить на килимку біля дверей"},
+		{ServiceName: "systran", TranslatedText: "Кіт сидить на килимі біля дверей"},
+		{ServiceName: "ollama/gemma2:27b", TranslatedText: "Кіт сидів на килимку біля дверей"},
+	}
+
+	res, err := a.Evaluate(context.Background(), "The cat sits on the mat by the door", "en", "uk", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService == "outlier" {
+		t.Error("expected consensus to reject the outlier")
+	}
+	if res.IsComposite {
+		t.Error("consensus arbiter never composes")
+	}
+	if !strings.Contains(res.Reasoning, "utility=") {
+		t.Errorf("expected per-candidate scores in reasoning, got %q", res.Reasoning)
+	}
+}
+
+func TestConsensusArbiter_GlossaryCompliance(t *testing.T) {
+	a := NewConsensusArbiter(map[string]string{"Kyiv": "Київ"}, nil)
+
+	results := []translator.ServiceResult{
+		{ServiceName: "a", TranslatedText: "Я живу в Києві"},
+		{ServiceName: "b", TranslatedText: "Я живу в Київ"},
+	}
+
+	res, err := a.Evaluate(context.Background(), "I live in Kyiv", "en", "uk", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService != "b" {
+		t.Errorf("expected glossary-compliant candidate 'b', got %q", res.SelectedService)
+	}
+}
+
+func TestConsensusArbiter_LanguageValidation(t *testing.T) {
+	v := stubValidator{valid: map[string]bool{"Добрий ранок": true}}
+	a := NewConsensusArbiter(nil, v)
+
+	results := []translator.ServiceResult{
+		{ServiceName: "a", TranslatedText: "Good morning"},
+		{ServiceName: "b", TranslatedText: "Добрий ранок"},
+	}
+
+	res, err := a.Evaluate(context.Background(), "Good morning", "en", "uk", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService != "b" {
+		t.Errorf("expected target-language candidate 'b', got %q", res.SelectedService)
+	}
+}
+
+func TestConsensusArbiter_DeterministicTieBreak(t *testing.T) {
+	a := NewConsensusArbiter(nil, nil)
+
+	results := []translator.ServiceResult{
+		{ServiceName: "zeta", TranslatedText: "Привіт"},
+		{ServiceName: "alpha", TranslatedText: "Привіт"},
+	}
+
+	for i := 0; i < 5; i++ {
+		res, _ := a.Evaluate(context.Background(), "Hello", "en", "uk", results)
+		if res.SelectedService != "alpha" {
+			t.Fatalf("expected tie broken by name ('alpha'), got %q", res.SelectedService)
+		}
+	}
+}
+
+func TestConsensusArbiter_Evaluate_NoResults(t *testing.T) {
+	a := NewConsensusArbiter(nil, nil)
+
+	if _, err := a.Evaluate(context.Background(), "Hello", "en", "uk", nil); err == nil {
+		t.Error("expected error for empty results")
+	}
+}
+
+func TestLengthScore(t *testing.T) {
+	if got := lengthScore(1.2, 1.2); got != 1 {
+		t.Errorf("expected 1 at the median, got %v", got)
+	}
+	if got := lengthScore(0.6, 1.2); got != 0.5 {
+		t.Errorf("expected 0.5 for half the median ratio, got %v", got)
+	}
+	if got := lengthScore(0, 1.2); got != 0 {
+		t.Errorf("expected 0 for empty candidate, got %v", got)
+	}
+}
@@ -5,18 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/valpere/peretran/internal"
 	"github.com/valpere/peretran/internal/postprocess"
+	"github.com/valpere/peretran/internal/prompts"
+	"github.com/valpere/peretran/internal/style"
 	"github.com/valpere/peretran/internal/translator"
 )
 
 type OllamaArbiter struct {
-	model   string
-	baseURL string
-	client  *http.Client
+	model     string
+	baseURL   string
+	client    *http.Client
+	validator LanguageValidator
+	prompts   *prompts.Set
+	style     *style.Profile
 }
 
 type OllamaRequest struct {
@@ -55,8 +61,56 @@ func (a *OllamaArbiter) Evaluate(ctx context.Context, source string, sourceLang,
 		}, nil
 	}
 
-	prompt := buildArbiterPrompt(source, sourceLang, targetLang, results)
+	prompt, err := buildArbiterPrompt(a.prompts, a.style.Guide(), source, sourceLang, targetLang, results)
+	if err != nil {
+		return nil, err
+	}
+	eval, err := evaluateWithRepair(ctx, a.generate, prompt, source, targetLang, results, a.validator, a.style)
+	if err != nil {
+		return nil, err
+	}
+	eval.PromptTemplate = a.prompts.ID(prompts.Arbiter)
+	return eval, nil
+}
+
+// SetPrompts sets the prompt templates; nil means the embedded defaults.
+func (a *OllamaArbiter) SetPrompts(p *prompts.Set) {
+	a.prompts = p
+}
 
+// SetStyle sets the style profile the candidates are judged against;
+// composites containing its forbidden words are rejected.
+func (a *OllamaArbiter) SetStyle(p *style.Profile) {
+	a.style = p
+}
+
+// SetValidator sets the target-language validator applied to composite
+// verdicts. Without one, composites are not language-checked.
+func (a *OllamaArbiter) SetValidator(v LanguageValidator) {
+	a.validator = v
+}
+
+// Compare asks the model which of two candidates is the better translation.
+// Candidate names are withheld from the prompt to avoid brand bias.
+func (a *OllamaArbiter) Compare(ctx context.Context, source, sourceLang, targetLang string, first, second translator.ServiceResult) (*PairwiseVerdict, error) {
+	prompt, err := buildPairwisePrompt(a.prompts, a.style.Guide(), source, sourceLang, targetLang, first.TranslatedText, second.TranslatedText)
+	if err != nil {
+		return nil, err
+	}
+	response, err := a.generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parsePairwiseResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	v.PromptTemplate = a.prompts.ID(prompts.ArbiterPairwise)
+	return v, nil
+}
+
+// generate sends a JSON-mode prompt to Ollama and returns the raw response.
+func (a *OllamaArbiter) generate(ctx context.Context, prompt string) (string, error) {
 	reqBody := OllamaRequest{
 		Model:  a.model,
 		Prompt: prompt,
@@ -66,63 +120,58 @@ func (a *OllamaArbiter) Evaluate(ctx context.Context, source string, sourceLang,
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/generate", a.baseURL), bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := a.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("arbiter request failed: %w", err)
+		return "", fmt.Errorf("arbiter request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("arbiter returned status %d", resp.StatusCode)
+		return "", fmt.Errorf("arbiter returned status %d", resp.StatusCode)
 	}
 
 	var ollamaResp OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return parseArbiterResponse(ollamaResp.Response)
+	return ollamaResp.Response, nil
 }
 
-func buildArbiterPrompt(source, sourceLang, targetLang string, results []translator.ServiceResult) string {
-	var sb strings.Builder
-	sb.WriteString("You are a professional translator evaluator.\n")
-	sb.WriteString(fmt.Sprintf("Given the original text in %s:\n", sourceLang))
-	sb.WriteString(fmt.Sprintf(`"%s"`, source))
-	sb.WriteString(fmt.Sprintf("\n\nAnd these translations to %s:\n", targetLang))
-
-	for i, r := range results {
-		sb.WriteString(fmt.Sprintf("  %d. [%s]: \"%s\"\n", i+1, r.ServiceName, r.TranslatedText))
+func buildArbiterPrompt(set *prompts.Set, styleGuide, source, sourceLang, targetLang string, results []translator.ServiceResult) (string, error) {
+	candidates := make([]prompts.Candidate, 0, len(results))
+	for _, r := range results {
+		candidates = append(candidates, prompts.Candidate{Name: r.ServiceName, Text: r.TranslatedText})
 	}
-
-	sb.WriteString(`Select the best translation or compose an improved one from the available options.
-Respond ONLY in JSON:
-{
-  "selected_service": "google|systran|ollama|composite",
-  "final_text": "...",
-  "reasoning": "..."
-}
-`)
-
-	return sb.String()
+	return set.Render(prompts.Arbiter, prompts.Data{
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+		Source:     source,
+		StyleGuide: styleGuide,
+		Candidates: candidates,
+	})
 }
 
 func parseArbiterResponse(response string) (*EvaluationResult, error) {
-	response = strings.TrimSpace(response)
+	response = extractJSON(response)
 
 	var parsed struct {
 		SelectedService string `json:"selected_service"`
 		FinalText       string `json:"final_text"`
 		Reasoning       string `json:"reasoning"`
+		Scores          []struct {
+			Service string `json:"service"`
+			internal.RubricScores
+		} `json:"scores"`
 	}
 
 	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
@@ -131,10 +180,40 @@ func parseArbiterResponse(response string) (*EvaluationResult, error) {
 
 	isComposite := parsed.SelectedService == "composite"
 
+	var scores map[string]internal.RubricScores
+	for _, sc := range parsed.Scores {
+		if sc.Service == "" {
+			continue
+		}
+		if scores == nil {
+			scores = make(map[string]internal.RubricScores)
+		}
+		scores[sc.Service] = clampRubric(sc.RubricScores)
+	}
+
 	return &EvaluationResult{
 		SelectedService: parsed.SelectedService,
 		CompositeText:   postprocess.Clean(parsed.FinalText),
 		IsComposite:     isComposite,
 		Reasoning:       parsed.Reasoning,
+		Scores:          scores,
 	}, nil
 }
+
+// clampRubric keeps every scored dimension within the 1–5 scale. A
+// dimension the model left out or set to 0 stays 0, meaning not scored.
+func clampRubric(r internal.RubricScores) internal.RubricScores {
+	clamp := func(v float64) float64 {
+		if v == 0 {
+			return 0
+		}
+		return math.Max(1, math.Min(5, v))
+	}
+	return internal.RubricScores{
+		Accuracy:    clamp(r.Accuracy),
+		Fluency:     clamp(r.Fluency),
+		Terminology: clamp(r.Terminology),
+		Style:       clamp(r.Style),
+		Formatting:  clamp(r.Formatting),
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/valpere/peretran/internal/translator"
@@ -144,7 +145,10 @@ func TestBuildArbiterPrompt(t *testing.T) {
 		{ServiceName: "systran", TranslatedText: "Прівет"},
 	}
 
-	prompt := buildArbiterPrompt("Hello", "en", "uk", results)
+	prompt, err := buildArbiterPrompt(nil, "", "Hello", "en", "uk", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	if len(prompt) == 0 {
 		t.Error("expected non-empty prompt")
@@ -199,3 +203,72 @@ func TestParseArbiterResponse_WithWhitespace(t *testing.T) {
 		t.Errorf("expected 'google', got %q", res.SelectedService)
 	}
 }
+
+func TestBuildArbiterPrompt_ListsCandidateNames(t *testing.T) {
+	results := []translator.ServiceResult{
+		{ServiceName: "ollama/gemma2:27b", TranslatedText: "Привіт"},
+		{ServiceName: "ollama/qwen3:14b", TranslatedText: "Вітаю"},
+	}
+
+	prompt, err := buildArbiterPrompt(nil, "", "Hello", "en", "uk", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(prompt, `"ollama/gemma2:27b|ollama/qwen3:14b|composite"`) {
+		t.Errorf("expected candidate names in response schema, got:\n%s", prompt)
+	}
+}
+
+func TestParseArbiterResponse_RubricScores(t *testing.T) {
+	response := `{"selected_service":"google","final_text":"Привіт","reasoning":"ok",
+		"scores":[{"service":"google","accuracy":5,"fluency":4,"terminology":4,"style":4,"formatting":9},
+		          {"service":"mymemory","accuracy":2,"fluency":2,"terminology":3,"style":2,"formatting":-1}]}`
+
+	res, err := parseArbiterResponse(response)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Scores) != 2 {
+		t.Fatalf("expected 2 scored candidates, got %d", len(res.Scores))
+	}
+	if g := res.Scores["google"]; g.Accuracy != 5 || g.Formatting != 5 {
+		t.Errorf("expected google scores clamped to 1–5, got %+v", g)
+	}
+	if m := res.Scores["mymemory"]; m.Formatting != 1 {
+		t.Errorf("expected mymemory formatting clamped to 1, got %v", m.Formatting)
+	}
+	if best, ok := res.BestAccuracy(); !ok || best != 5 {
+		t.Errorf("expected best accuracy 5, got %v (ok=%v)", best, ok)
+	}
+}
+
+func TestParseArbiterResponse_OmittedDimension(t *testing.T) {
+	response := `{"selected_service":"google","final_text":"Привіт","reasoning":"ok",
+		"scores":[{"service":"google","fluency":4,"terminology":4,"style":4,"formatting":0}]}`
+
+	res, err := parseArbiterResponse(response)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g := res.Scores["google"]
+	if g.Accuracy != 0 || g.Formatting != 0 {
+		t.Errorf("expected omitted and zero dimensions to stay unscored, got %+v", g)
+	}
+	if g.Mean() != 4 {
+		t.Errorf("expected the mean of the scored dimensions, got %v", g.Mean())
+	}
+	if _, ok := res.BestAccuracy(); ok {
+		t.Error("expected no best accuracy when accuracy was not scored")
+	}
+}
+
+func TestParseArbiterResponse_NoScores(t *testing.T) {
+	res, err := parseArbiterResponse(`{"selected_service":"google","final_text":"Привіт","reasoning":"ok"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := res.BestAccuracy(); ok {
+		t.Error("expected no best accuracy without scores")
+	}
+}
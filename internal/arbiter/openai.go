@@ -0,0 +1,272 @@
+package arbiter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/valpere/peretran/internal/prompts"
+	"github.com/valpere/peretran/internal/style"
+	"github.com/valpere/peretran/internal/translator"
+)
+
+const (
+	// DefaultOpenAIBaseURL is the base URL of the OpenAI API.
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	// DefaultOpenRouterBaseURL is the base URL of the OpenRouter API.
+	DefaultOpenRouterBaseURL = "https://openrouter.ai/api/v1"
+)
+
+// OpenAIArbiter evaluates candidates with any OpenAI-compatible chat
+// completions endpoint (OpenAI, OpenRouter, vLLM, LM Studio, Ollama's /v1).
+// The verdict is requested as structured JSON via response_format, with the
+// selected service constrained to the candidate names.
+type OpenAIArbiter struct {
+	model   string
+	baseURL string
+	apiKey  string
+	headers map[string]string
+	client  *http.Client
+
+	validator LanguageValidator
+	prompts   *prompts.Set
+	style     *style.Profile
+}
+
+// NewOpenAIArbiter creates an arbiter for an OpenAI-compatible endpoint.
+// An empty baseURL means the OpenAI API.
+func NewOpenAIArbiter(model, baseURL, apiKey string) *OpenAIArbiter {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAIArbiter{
+		model:   model,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// NewOpenRouterArbiter creates an arbiter backed by OpenRouter.
+// An empty baseURL means the public OpenRouter API.
+func NewOpenRouterArbiter(model, baseURL, apiKey string) *OpenAIArbiter {
+	if baseURL == "" {
+		baseURL = DefaultOpenRouterBaseURL
+	}
+	a := NewOpenAIArbiter(model, baseURL, apiKey)
+	a.headers = map[string]string{
+		"HTTP-Referer": "https://peretran.local",
+		"X-Title":      "PereTran",
+	}
+	return a
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string                 `json:"model"`
+	Messages       []chatMessage          `json:"messages"`
+	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
+	Temperature    float64                `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+func (a *OpenAIArbiter) Evaluate(ctx context.Context, source string, sourceLang, targetLang string, results []translator.ServiceResult) (*EvaluationResult, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results to evaluate")
+	}
+
+	if len(results) == 1 {
+		return &EvaluationResult{
+			SelectedService: results[0].ServiceName,
+			CompositeText:   results[0].TranslatedText,
+			IsComposite:     false,
+			Reasoning:       "Only one service available",
+		}, nil
+	}
+
+	if a.apiKey == "" {
+		return nil, fmt.Errorf("arbiter API key required")
+	}
+
+	format := arbiterResponseFormat(results)
+	ask := func(ctx context.Context, prompt string) (string, error) {
+		return a.complete(ctx, chatRequest{
+			Model:          a.model,
+			Messages:       []chatMessage{{Role: "user", Content: prompt}},
+			ResponseFormat: format,
+		})
+	}
+	prompt, err := buildArbiterPrompt(a.prompts, a.style.Guide(), source, sourceLang, targetLang, results)
+	if err != nil {
+		return nil, err
+	}
+	eval, err := evaluateWithRepair(ctx, ask, prompt, source, targetLang, results, a.validator, a.style)
+	if err != nil {
+		return nil, err
+	}
+	eval.PromptTemplate = a.prompts.ID(prompts.Arbiter)
+	return eval, nil
+}
+
+// SetPrompts sets the prompt templates; nil means the embedded defaults.
+func (a *OpenAIArbiter) SetPrompts(p *prompts.Set) {
+	a.prompts = p
+}
+
+// SetStyle sets the style profile the candidates are judged against;
+// composites containing its forbidden words are rejected.
+func (a *OpenAIArbiter) SetStyle(p *style.Profile) {
+	a.style = p
+}
+
+// SetValidator sets the target-language validator applied to composite
+// verdicts. Without one, composites are not language-checked.
+func (a *OpenAIArbiter) SetValidator(v LanguageValidator) {
+	a.validator = v
+}
+
+// Compare asks the model which of two candidates is the better translation.
+// Candidate names are withheld from the prompt to avoid brand bias.
+func (a *OpenAIArbiter) Compare(ctx context.Context, source, sourceLang, targetLang string, first, second translator.ServiceResult) (*PairwiseVerdict, error) {
+	if a.apiKey == "" {
+		return nil, fmt.Errorf("arbiter API key required")
+	}
+
+	prompt, err := buildPairwisePrompt(a.prompts, a.style.Guide(), source, sourceLang, targetLang, first.TranslatedText, second.TranslatedText)
+	if err != nil {
+		return nil, err
+	}
+	content, err := a.complete(ctx, chatRequest{
+		Model:          a.model,
+		Messages:       []chatMessage{{Role: "user", Content: prompt}},
+		ResponseFormat: pairwiseResponseFormat(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	v, err := parsePairwiseResponse(content)
+	if err != nil {
+		return nil, err
+	}
+	v.PromptTemplate = a.prompts.ID(prompts.ArbiterPairwise)
+	return v, nil
+}
+
+// complete sends a chat completion request and returns the first choice's content.
+func (a *OpenAIArbiter) complete(ctx context.Context, reqBody chatRequest) (string, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/chat/completions", a.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", a.apiKey))
+	for k, v := range a.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("arbiter request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("arbiter returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("arbiter returned no choices")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// arbiterResponseFormat returns a json_schema response_format describing the
+// verdict, with selected_service restricted to the candidates or "composite"
+// and one rubric score entry per candidate.
+func arbiterResponseFormat(results []translator.ServiceResult) map[string]interface{} {
+	candidates := make([]string, 0, len(results))
+	for _, r := range results {
+		candidates = append(candidates, r.ServiceName)
+	}
+	names := append(append([]string(nil), candidates...), "composite")
+
+	dimension := map[string]interface{}{"type": "number"}
+	score := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"service":     map[string]interface{}{"type": "string", "enum": candidates},
+			"accuracy":    dimension,
+			"fluency":     dimension,
+			"terminology": dimension,
+			"style":       dimension,
+			"formatting":  dimension,
+		},
+		"required":             []string{"service", "accuracy", "fluency", "terminology", "style", "formatting"},
+		"additionalProperties": false,
+	}
+
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   "arbiter_verdict",
+			"strict": true,
+			"schema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"selected_service": map[string]interface{}{"type": "string", "enum": names},
+					"final_text":       map[string]interface{}{"type": "string"},
+					"reasoning":        map[string]interface{}{"type": "string"},
+					"scores":           map[string]interface{}{"type": "array", "items": score},
+				},
+				"required":             []string{"selected_service", "final_text", "reasoning", "scores"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// pairwiseResponseFormat returns a json_schema response_format for a
+// pairwise verdict.
+func pairwiseResponseFormat() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   "pairwise_verdict",
+			"strict": true,
+			"schema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"winner":    map[string]interface{}{"type": "string", "enum": []string{"A", "B", "tie"}},
+					"reasoning": map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"winner", "reasoning"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package arbiter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/translator"
+)
+
+func TestOpenAIArbiter_New_Defaults(t *testing.T) {
+	a := NewOpenAIArbiter("gpt-4o-mini", "", "key")
+	if a.baseURL != DefaultOpenAIBaseURL {
+		t.Errorf("expected default OpenAI base URL, got %q", a.baseURL)
+	}
+
+	or := NewOpenRouterArbiter("qwen/qwen2.5-72b-instruct:free", "", "key")
+	if or.baseURL != DefaultOpenRouterBaseURL {
+		t.Errorf("expected default OpenRouter base URL, got %q", or.baseURL)
+	}
+	if or.headers["X-Title"] == "" {
+		t.Error("expected OpenRouter attribution headers")
+	}
+}
+
+func TestOpenAIArbiter_Evaluate_SingleResult(t *testing.T) {
+	a := NewOpenAIArbiter("gpt-4o-mini", "", "")
+
+	res, err := a.Evaluate(context.Background(), "Hello", "en", "uk", []translator.ServiceResult{
+		{ServiceName: "google", TranslatedText: "Привіт"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService != "google" {
+		t.Errorf("expected 'google', got %q", res.SelectedService)
+	}
+}
+
+func TestOpenAIArbiter_Evaluate_NoAPIKey(t *testing.T) {
+	a := NewOpenAIArbiter("gpt-4o-mini", "", "")
+
+	_, err := a.Evaluate(context.Background(), "Hello", "en", "uk", []translator.ServiceResult{
+		{ServiceName: "google", TranslatedText: "Привіт"},
+		{ServiceName: "ollama/gemma2:27b", TranslatedText: "Вітаю"},
+	})
+	if err == nil {
+		t.Error("expected error without API key")
+	}
+}
+
+func TestOpenAIArbiter_Evaluate_StructuredOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-4o-mini" {
+			t.Errorf("expected model 'gpt-4o-mini', got %q", req.Model)
+		}
+		if req.ResponseFormat["type"] != "json_schema" {
+			t.Errorf("expected json_schema response_format, got %v", req.ResponseFormat["type"])
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{
+					"content": `{"selected_service": "ollama/gemma2:27b", "final_text": "Вітаю", "reasoning": "More natural"}`,
+				}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	a := NewOpenAIArbiter("gpt-4o-mini", server.URL, "test-key")
+
+	res, err := a.Evaluate(context.Background(), "Hello", "en", "uk", []translator.ServiceResult{
+		{ServiceName: "google", TranslatedText: "Привіт"},
+		{ServiceName: "ollama/gemma2:27b", TranslatedText: "Вітаю"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService != "ollama/gemma2:27b" {
+		t.Errorf("expected 'ollama/gemma2:27b', got %q", res.SelectedService)
+	}
+	if res.CompositeText != "Вітаю" {
+		t.Errorf("expected 'Вітаю', got %q", res.CompositeText)
+	}
+}
+
+func TestOpenAIArbiter_Evaluate_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": "rate limited"}`))
+	}))
+	defer server.Close()
+
+	a := NewOpenRouterArbiter("qwen/qwen2.5-72b-instruct:free", server.URL, "key")
+
+	_, err := a.Evaluate(context.Background(), "Hello", "en", "uk", []translator.ServiceResult{
+		{ServiceName: "google", TranslatedText: "Привіт"},
+		{ServiceName: "systran", TranslatedText: "Вітаю"},
+	})
+	if err == nil {
+		t.Error("expected error for non-OK status")
+	}
+}
+
+func TestArbiterResponseFormat_RestrictsServices(t *testing.T) {
+	format := arbiterResponseFormat([]translator.ServiceResult{
+		{ServiceName: "google"},
+		{ServiceName: "systran"},
+	})
+
+	schema := format["json_schema"].(map[string]interface{})["schema"].(map[string]interface{})
+	props := schema["properties"].(map[string]interface{})
+	enum := props["selected_service"].(map[string]interface{})["enum"].([]string)
+
+	want := []string{"google", "systran", "composite"}
+	if len(enum) != len(want) {
+		t.Fatalf("expected enum %v, got %v", want, enum)
+	}
+	for i := range want {
+		if enum[i] != want[i] {
+			t.Errorf("enum[%d] = %q, want %q", i, enum[i], want[i])
+		}
+	}
+}
+
+func TestArbiterInterface(t *testing.T) {
+	var _ Arbiter = (*OllamaArbiter)(nil)
+	var _ Arbiter = (*OpenAIArbiter)(nil)
+	var _ Arbiter = (*ConsensusArbiter)(nil)
+}
+
+func TestOpenAIArbiter_Compare_HidesServiceNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if strings.Contains(req.Messages[0].Content, "google") {
+			t.Error("pairwise prompt must not reveal service names")
+		}
+		if req.ResponseFormat["type"] != "json_schema" {
+			t.Errorf("expected json_schema response_format, got %v", req.ResponseFormat["type"])
+		}
+		resp := chatResponse{}
+		resp.Choices = append(resp.Choices, struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		}{})
+		resp.Choices[0].Message.Content = `{"winner":"B","reasoning":"more natural"}`
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	a := NewOpenAIArbiter("gpt-4o-mini", server.URL, "test-key")
+	v, err := a.Compare(context.Background(), "Hello", "en", "uk",
+		translator.ServiceResult{ServiceName: "google", TranslatedText: "Привіт"},
+		translator.ServiceResult{ServiceName: "systran", TranslatedText: "Вітаю"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Winner != "B" {
+		t.Errorf("expected winner 'B', got %q", v.Winner)
+	}
+}
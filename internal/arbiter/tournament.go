@@ -0,0 +1,348 @@
+package arbiter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/valpere/peretran/internal/prompts"
+	"github.com/valpere/peretran/internal/translator"
+)
+
+// PairwiseVerdict is a judge's decision between two candidates shown as
+// "A" (first) and "B" (second).
+type PairwiseVerdict struct {
+	// Winner is "A", "B" or "tie".
+	Winner    string
+	Reasoning string
+	// PromptTemplate identifies the prompt template used (see prompts.Set.ID).
+	PromptTemplate string
+}
+
+// PairwiseJudge compares two candidate translations of the same source.
+// OllamaArbiter and OpenAIArbiter implement it.
+type PairwiseJudge interface {
+	Compare(ctx context.Context, source, sourceLang, targetLang string, first, second translator.ServiceResult) (*PairwiseVerdict, error)
+}
+
+const (
+	// defaultTournamentConcurrency bounds parallel judge calls.
+	defaultTournamentConcurrency = 4
+	// btIterations is the number of Bradley-Terry MM iterations; the
+	// estimate converges well before this for the bracket sizes we see.
+	btIterations = 200
+	// btPrior is a pseudo-count of drawn games added between every pair so
+	// that undefeated or winless candidates get finite strengths.
+	btPrior = 0.1
+)
+
+// TournamentArbiter selects the best candidate by pairwise comparison rather
+// than one prompt holding every candidate, which degrades with many or long
+// candidates. Every pair is judged twice with the order swapped to cancel
+// position bias; a pair whose verdicts disagree counts as a draw. Wins are
+// aggregated into Bradley-Terry strengths and the strongest candidate wins.
+// The full bracket is recorded in the Reasoning field.
+type TournamentArbiter struct {
+	judge       PairwiseJudge
+	concurrency int
+}
+
+// NewTournamentArbiter creates a round-robin tournament refereed by judge.
+func NewTournamentArbiter(judge PairwiseJudge) *TournamentArbiter {
+	return &TournamentArbiter{judge: judge, concurrency: defaultTournamentConcurrency}
+}
+
+// SetConcurrency sets the number of judge calls run in parallel (minimum 1).
+func (a *TournamentArbiter) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	a.concurrency = n
+}
+
+// match is one pair of candidates judged in both orders.
+type match struct {
+	i, j     int
+	forward  *PairwiseVerdict // i shown as A
+	backward *PairwiseVerdict // j shown as A
+	errs     []error
+}
+
+// score returns i's points against j: 1 for a win, 0.5 for a draw, 0 for a
+// loss. ok is false when neither ordering produced a verdict.
+func (m *match) score() (float64, bool) {
+	var points []float64
+	if m.forward != nil {
+		points = append(points, verdictPoints(m.forward.Winner, "A"))
+	}
+	if m.backward != nil {
+		points = append(points, verdictPoints(m.backward.Winner, "B"))
+	}
+	if len(points) == 0 {
+		return 0, false
+	}
+	if len(points) == 2 && points[0] != points[1] {
+		// Order-dependent verdict: position bias, not preference.
+		return 0.5, true
+	}
+	return points[0], true
+}
+
+func verdictPoints(winner, self string) float64 {
+	switch winner {
+	case self:
+		return 1
+	case "tie":
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+func (a *TournamentArbiter) Evaluate(ctx context.Context, source string, sourceLang, targetLang string, results []translator.ServiceResult) (*EvaluationResult, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results to evaluate")
+	}
+
+	if len(results) == 1 {
+		return &EvaluationResult{
+			SelectedService: results[0].ServiceName,
+			CompositeText:   results[0].TranslatedText,
+			IsComposite:     false,
+			Reasoning:       "Only one service available",
+		}, nil
+	}
+
+	matches := a.play(ctx, source, sourceLang, targetLang, results)
+
+	n := len(results)
+	wins := make([][]float64, n)
+	for i := range wins {
+		wins[i] = make([]float64, n)
+	}
+	decided := 0
+	for _, m := range matches {
+		s, ok := m.score()
+		if !ok {
+			continue
+		}
+		decided++
+		wins[m.i][m.j] += s
+		wins[m.j][m.i] += 1 - s
+	}
+	if decided == 0 {
+		return nil, fmt.Errorf("tournament failed: no pairwise comparison succeeded: %v", matches[0].errs)
+	}
+
+	strengths := bradleyTerry(wins)
+
+	var promptTemplate string
+	for _, m := range matches {
+		if m.forward != nil {
+			promptTemplate = m.forward.PromptTemplate
+			break
+		}
+	}
+
+	best := 0
+	for i := 1; i < n; i++ {
+		if strengths[i] > strengths[best] ||
+			(strengths[i] == strengths[best] && results[i].ServiceName < results[best].ServiceName) {
+			best = i
+		}
+	}
+
+	return &EvaluationResult{
+		SelectedService: results[best].ServiceName,
+		CompositeText:   results[best].TranslatedText,
+		IsComposite:     false,
+		Reasoning:       formatBracket(results, matches, wins, strengths, best),
+		PromptTemplate:  promptTemplate,
+	}, nil
+}
+
+// play judges every pair in both orders, running up to a.concurrency judge
+// calls at a time.
+func (a *TournamentArbiter) play(ctx context.Context, source, sourceLang, targetLang string, results []translator.ServiceResult) []*match {
+	var matches []*match
+	for i := 0; i < len(results); i++ {
+		for j := i + 1; j < len(results); j++ {
+			matches = append(matches, &match{i: i, j: j})
+		}
+	}
+
+	sem := make(chan struct{}, a.concurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, m := range matches {
+		for _, swapped := range []bool{false, true} {
+			wg.Add(1)
+			go func(m *match, swapped bool) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				first, second := results[m.i], results[m.j]
+				if swapped {
+					first, second = second, first
+				}
+				v, err := a.judge.Compare(ctx, source, sourceLang, targetLang, first, second)
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err != nil:
+					m.errs = append(m.errs, err)
+				case swapped:
+					m.backward = v
+				default:
+					m.forward = v
+				}
+			}(m, swapped)
+		}
+	}
+	wg.Wait()
+	return matches
+}
+
+// bradleyTerry estimates strengths from a win matrix (wins[i][j] = points i
+// scored against j) with the minorisation-maximisation algorithm. Strengths
+// are normalised to sum to 1.
+func bradleyTerry(wins [][]float64) []float64 {
+	n := len(wins)
+	p := make([]float64, n)
+	for i := range p {
+		p[i] = 1 / float64(n)
+	}
+
+	for iter := 0; iter < btIterations; iter++ {
+		next := make([]float64, n)
+		sum := 0.0
+		for i := 0; i < n; i++ {
+			w := 0.0
+			denom := 0.0
+			for j := 0; j < n; j++ {
+				if i == j {
+					continue
+				}
+				games := wins[i][j] + wins[j][i] + 2*btPrior
+				w += wins[i][j] + btPrior
+				denom += games / (p[i] + p[j])
+			}
+			next[i] = w / denom
+			sum += next[i]
+		}
+		delta := 0.0
+		for i := range next {
+			next[i] /= sum
+			delta = math.Max(delta, math.Abs(next[i]-p[i]))
+		}
+		p = next
+		if delta < 1e-9 {
+			break
+		}
+	}
+	return p
+}
+
+func formatBracket(results []translator.ServiceResult, matches []*match, wins [][]float64, strengths []float64, best int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Tournament selected %s (Bradley-Terry, %d candidates, order-swapped pairwise judging).\n",
+		results[best].ServiceName, len(results)))
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return strengths[order[a]] > strengths[order[b]] })
+
+	sb.WriteString("Standings:\n")
+	for rank, i := range order {
+		points, games := 0.0, 0
+		for j := range results {
+			if j != i {
+				points += wins[i][j]
+				if wins[i][j]+wins[j][i] > 0 {
+					games++
+				}
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  %d. %s strength=%.3f points=%.1f/%d\n",
+			rank+1, results[i].ServiceName, strengths[i], points, games))
+	}
+
+	sb.WriteString("Bracket:")
+	for _, m := range matches {
+		a, b := results[m.i].ServiceName, results[m.j].ServiceName
+		sb.WriteString(fmt.Sprintf("\n  %s vs %s: ", a, b))
+		s, ok := m.score()
+		switch {
+		case !ok:
+			sb.WriteString("no verdict")
+		case s == 1:
+			sb.WriteString(a)
+		case s == 0:
+			sb.WriteString(b)
+		default:
+			sb.WriteString("draw")
+		}
+		sb.WriteString(fmt.Sprintf(" [%s first: %s; %s first: %s]",
+			a, describeVerdict(m.forward, a, b), b, describeVerdict(m.backward, b, a)))
+		for _, err := range m.errs {
+			sb.WriteString(fmt.Sprintf(" error: %v", err))
+		}
+	}
+	return sb.String()
+}
+
+func describeVerdict(v *PairwiseVerdict, first, second string) string {
+	if v == nil {
+		return "-"
+	}
+	switch v.Winner {
+	case "A":
+		return first
+	case "B":
+		return second
+	default:
+		return "tie"
+	}
+}
+
+func buildPairwisePrompt(set *prompts.Set, styleGuide, source, sourceLang, targetLang, first, second string) (string, error) {
+	return set.Render(prompts.ArbiterPairwise, prompts.Data{
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+		Source:     source,
+		StyleGuide: styleGuide,
+		// Names are withheld to avoid brand bias.
+		Candidates: []prompts.Candidate{{Name: "A", Text: first}, {Name: "B", Text: second}},
+	})
+}
+
+func parsePairwiseResponse(response string) (*PairwiseVerdict, error) {
+	var parsed struct {
+		Winner    string `json:"winner"`
+		Reasoning string `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(response)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse pairwise verdict as JSON: %w", err)
+	}
+
+	winner := strings.TrimSpace(parsed.Winner)
+	switch strings.ToLower(winner) {
+	case "a":
+		winner = "A"
+	case "b":
+		winner = "B"
+	case "tie", "draw", "equal":
+		winner = "tie"
+	default:
+		return nil, fmt.Errorf("invalid pairwise winner %q", parsed.Winner)
+	}
+	return &PairwiseVerdict{Winner: winner, Reasoning: parsed.Reasoning}, nil
+}
@@ -0,0 +1,144 @@
+package arbiter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/valpere/peretran/internal/translator"
+)
+
+// rankJudge prefers the candidate with the lower rank; position-biased
+// judges always answer "A".
+type rankJudge struct {
+	rank   map[string]int
+	biased bool
+	fail   bool
+	calls  atomic.Int32
+}
+
+func (j *rankJudge) Compare(ctx context.Context, source, sourceLang, targetLang string, first, second translator.ServiceResult) (*PairwiseVerdict, error) {
+	j.calls.Add(1)
+	if j.fail {
+		return nil, errors.New("judge unavailable")
+	}
+	if j.biased {
+		return &PairwiseVerdict{Winner: "A"}, nil
+	}
+	if j.rank[first.ServiceName] < j.rank[second.ServiceName] {
+		return &PairwiseVerdict{Winner: "A"}, nil
+	}
+	return &PairwiseVerdict{Winner: "B"}, nil
+}
+
+func tournamentCandidates() []translator.ServiceResult {
+	return []translator.ServiceResult{
+		{ServiceName: "google", TranslatedText: "Привіт"},
+		{ServiceName: "systran", TranslatedText: "Вітаю"},
+		{ServiceName: "ollama/gemma2:27b", TranslatedText: "Добрий день"},
+		{ServiceName: "ollama/qwen3:14b", TranslatedText: "Здрастуйте"},
+		{ServiceName: "mymemory", TranslatedText: "Хелло"},
+	}
+}
+
+func TestTournamentArbiter_SelectsStrongest(t *testing.T) {
+	judge := &rankJudge{rank: map[string]int{
+		"ollama/gemma2:27b": 1, "google": 2, "systran": 3, "ollama/qwen3:14b": 4, "mymemory": 5,
+	}}
+	a := NewTournamentArbiter(judge)
+
+	res, err := a.Evaluate(context.Background(), "Hello", "en", "uk", tournamentCandidates())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService != "ollama/gemma2:27b" {
+		t.Errorf("expected strongest candidate, got %q", res.SelectedService)
+	}
+	// 5 candidates: 10 pairs, each judged in both orders.
+	if got := judge.calls.Load(); got != 20 {
+		t.Errorf("expected 20 judge calls, got %d", got)
+	}
+	if !strings.Contains(res.Reasoning, "Bracket:") || !strings.Contains(res.Reasoning, "google vs systran") {
+		t.Errorf("expected full bracket in reasoning, got:\n%s", res.Reasoning)
+	}
+}
+
+func TestTournamentArbiter_PositionBiasCancelsOut(t *testing.T) {
+	a := NewTournamentArbiter(&rankJudge{biased: true})
+
+	results := tournamentCandidates()[:3]
+	res, err := a.Evaluate(context.Background(), "Hello", "en", "uk", results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Every match is a draw, so the deterministic tie-break decides.
+	if res.SelectedService != "google" {
+		t.Errorf("expected name tie-break to pick 'google', got %q", res.SelectedService)
+	}
+	if strings.Count(res.Reasoning, ": draw") != 3 {
+		t.Errorf("expected every order-swapped match to be a draw, got:\n%s", res.Reasoning)
+	}
+}
+
+func TestTournamentArbiter_AllComparisonsFail(t *testing.T) {
+	a := NewTournamentArbiter(&rankJudge{fail: true})
+
+	if _, err := a.Evaluate(context.Background(), "Hello", "en", "uk", tournamentCandidates()[:2]); err == nil {
+		t.Error("expected error when no comparison succeeds")
+	}
+}
+
+func TestTournamentArbiter_SingleResult(t *testing.T) {
+	judge := &rankJudge{}
+	a := NewTournamentArbiter(judge)
+
+	res, err := a.Evaluate(context.Background(), "Hello", "en", "uk", tournamentCandidates()[:1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SelectedService != "google" || judge.calls.Load() != 0 {
+		t.Errorf("expected single candidate without judging, got %q after %d calls", res.SelectedService, judge.calls.Load())
+	}
+}
+
+func TestBradleyTerry_OrdersByWins(t *testing.T) {
+	// 0 beats 1 and 2; 1 beats 2.
+	wins := [][]float64{
+		{0, 2, 2},
+		{0, 0, 2},
+		{0, 0, 0},
+	}
+	p := bradleyTerry(wins)
+	if !(p[0] > p[1] && p[1] > p[2]) {
+		t.Errorf("expected strengths ordered 0 > 1 > 2, got %v", p)
+	}
+	sum := p[0] + p[1] + p[2]
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("expected normalised strengths, sum=%v", sum)
+	}
+}
+
+func TestParsePairwiseResponse(t *testing.T) {
+	v, err := parsePairwiseResponse(` {"winner": "b", "reasoning": "more fluent"} `)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Winner != "B" {
+		t.Errorf("expected normalised winner 'B', got %q", v.Winner)
+	}
+
+	if _, err := parsePairwiseResponse(`{"winner": "C"}`); err == nil {
+		t.Error("expected error for invalid winner")
+	}
+	if _, err := parsePairwiseResponse("not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestPairwiseJudgeInterface(t *testing.T) {
+	var _ PairwiseJudge = (*OllamaArbiter)(nil)
+	var _ PairwiseJudge = (*OpenAIArbiter)(nil)
+	var _ Arbiter = (*TournamentArbiter)(nil)
+}
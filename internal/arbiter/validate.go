@@ -0,0 +1,133 @@
+package arbiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/style"
+	"github.com/valpere/peretran/internal/translator"
+)
+
+// maxRepairAttempts is the number of repair prompts sent after an unusable
+// verdict before falling back to deterministic ranking.
+const maxRepairAttempts = 1
+
+// completeFunc sends a prompt to the arbiter model and returns its raw reply.
+type completeFunc func(ctx context.Context, prompt string) (string, error)
+
+// evaluateWithRepair runs the single-prompt protocol shared by the LLM
+// arbiters. The verdict is parsed and validated against the candidates; an
+// unusable verdict gets a repair prompt naming the problem, and when repair
+// also fails the candidates are ranked by ConsensusArbiter instead, so a
+// misbehaving model never blocks the pipeline. Transport errors on the first
+// call are returned as-is.
+func evaluateWithRepair(ctx context.Context, complete completeFunc, prompt, source, targetLang string, results []translator.ServiceResult, v LanguageValidator, sp *style.Profile) (*EvaluationResult, error) {
+	response, err := complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var invalid error
+	for attempt := 0; ; attempt++ {
+		eval, err := parseArbiterResponse(response)
+		if err == nil {
+			err = validateEvaluation(eval, source, targetLang, results, v, sp)
+		}
+		if err == nil {
+			return eval, nil
+		}
+		invalid = err
+		if attempt == maxRepairAttempts {
+			break
+		}
+
+		response, err = complete(ctx, buildRepairPrompt(prompt, response, invalid))
+		if err != nil {
+			invalid = fmt.Errorf("%v; repair request failed: %w", invalid, err)
+			break
+		}
+	}
+
+	fallback, err := NewConsensusArbiter(nil, v).Evaluate(ctx, source, "", targetLang, results)
+	if err != nil {
+		return nil, err
+	}
+	fallback.Reasoning = fmt.Sprintf("Arbiter response rejected (%v); fell back to consensus ranking.\n%s", invalid, fallback.Reasoning)
+	return fallback, nil
+}
+
+// validateEvaluation checks a parsed verdict against the candidates and
+// normalises it in place:
+//   - selected_service must be a candidate or "composite"
+//   - a selected candidate's text is taken from the candidate itself, not
+//     from the model's echo of it
+//   - a composite must be non-empty, keep every [PHn] marker of the source,
+//     pass the target-language validator (when v is set) and avoid the
+//     style profile's forbidden words (when sp is set)
+//   - scores for unknown services are dropped
+func validateEvaluation(eval *EvaluationResult, source, targetLang string, results []translator.ServiceResult, v LanguageValidator, sp *style.Profile) error {
+	byName := make(map[string]translator.ServiceResult, len(results))
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		byName[r.ServiceName] = r
+		names = append(names, r.ServiceName)
+	}
+
+	if eval.IsComposite {
+		if strings.TrimSpace(eval.CompositeText) == "" {
+			return fmt.Errorf("composite final_text is empty")
+		}
+		if missing := placeholder.MissingFrom(source, eval.CompositeText); len(missing) > 0 {
+			return fmt.Errorf("composite final_text drops placeholder markers %s", strings.Join(missing, ", "))
+		}
+		if v != nil {
+			if ok, err := v.IsValid(eval.CompositeText, targetLang); !ok {
+				return fmt.Errorf("composite final_text is not in %s: %v", targetLang, err)
+			}
+		}
+		if forbidden := sp.ForbiddenIn(eval.CompositeText); len(forbidden) > 0 {
+			return fmt.Errorf("composite final_text uses forbidden words %s", strings.Join(forbidden, ", "))
+		}
+	} else {
+		r, ok := byName[eval.SelectedService]
+		if !ok {
+			return fmt.Errorf("selected_service %q is not one of the candidates (%s) or \"composite\"",
+				eval.SelectedService, strings.Join(names, ", "))
+		}
+		eval.CompositeText = r.TranslatedText
+	}
+
+	for name := range eval.Scores {
+		if _, ok := byName[name]; !ok {
+			delete(eval.Scores, name)
+		}
+	}
+	if len(eval.Scores) == 0 {
+		eval.Scores = nil
+	}
+	return nil
+}
+
+func buildRepairPrompt(prompt, response string, problem error) string {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString("\nYour previous response was:\n")
+	sb.WriteString(response)
+	sb.WriteString(fmt.Sprintf("\n\nIt was rejected: %v.\n", problem))
+	sb.WriteString("Respond again with ONLY the corrected JSON object, following the format above exactly.\n")
+	return sb.String()
+}
+
+// extractJSON strips Markdown code fences and any prose around the outermost
+// JSON object, a common deviation even with JSON mode requested.
+func extractJSON(response string) string {
+	response = strings.TrimSpace(response)
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return response
+	}
+	return response[start : end+1]
+}
@@ -0,0 +1,135 @@
+package arbiter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/valpere/peretran/internal/style"
+	"github.com/valpere/peretran/internal/translator"
+)
+
+func validationCandidates() []translator.ServiceResult {
+	return []translator.ServiceResult{
+		{ServiceName: "google", TranslatedText: "Натисніть [PH0] щоб продовжити"},
+		{ServiceName: "systran", TranslatedText: "Клацніть [PH0] для продовження"},
+	}
+}
+
+func TestValidateEvaluation(t *testing.T) {
+	tests := []struct {
+		name    string
+		eval    EvaluationResult
+		v       LanguageValidator
+		wantErr string
+	}{
+		{"unknown service", EvaluationResult{SelectedService: "deepl"}, nil, "not one of the candidates"},
+		{"empty composite", EvaluationResult{SelectedService: "composite", IsComposite: true, CompositeText: "  "}, nil, "empty"},
+		{"dropped marker", EvaluationResult{SelectedService: "composite", IsComposite: true, CompositeText: "Натисніть щоб продовжити"}, nil, "[PH0]"},
+		{"wrong language", EvaluationResult{SelectedService: "composite", IsComposite: true, CompositeText: "Click [PH0] to continue"}, stubValidator{}, "not in uk"},
+		{"valid composite", EvaluationResult{SelectedService: "composite", IsComposite: true, CompositeText: "Натисніть [PH0], щоб продовжити"}, stubValidator{valid: map[string]bool{"Натисніть [PH0], щоб продовжити": true}}, ""},
+		{"valid selection", EvaluationResult{SelectedService: "systran"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := tt.eval
+			err := validateEvaluation(&eval, "Click [PH0] to continue", "uk", validationCandidates(), tt.v, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateEvaluation_UsesCandidateText(t *testing.T) {
+	eval := EvaluationResult{SelectedService: "systran", CompositeText: "paraphrased echo"}
+	if err := validateEvaluation(&eval, "Click [PH0] to continue", "uk", validationCandidates(), nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eval.CompositeText != "Клацніть [PH0] для продовження" {
+		t.Errorf("expected candidate text, got %q", eval.CompositeText)
+	}
+}
+
+func TestValidateEvaluation_ForbiddenWords(t *testing.T) {
+	sp := &style.Profile{ForbiddenWords: []string{"клацніть"}}
+
+	eval := EvaluationResult{SelectedService: "composite", IsComposite: true, CompositeText: "Клацніть [PH0], щоб продовжити"}
+	err := validateEvaluation(&eval, "Click [PH0] to continue", "uk", validationCandidates(), nil, sp)
+	if err == nil || !strings.Contains(err.Error(), "forbidden words клацніть") {
+		t.Errorf("expected forbidden-word error, got %v", err)
+	}
+
+	eval = EvaluationResult{SelectedService: "composite", IsComposite: true, CompositeText: "Натисніть [PH0], щоб продовжити"}
+	if err := validateEvaluation(&eval, "Click [PH0] to continue", "uk", validationCandidates(), nil, sp); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	got := extractJSON("Sure! Here it is:\n```json\n{\"winner\": \"A\"}\n```")
+	if got != `{"winner": "A"}` {
+		t.Errorf("unexpected extraction %q", got)
+	}
+}
+
+// ollamaReplies serves the given responses in order, repeating the last one.
+func ollamaReplies(calls *atomic.Int32, replies ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n >= len(replies) {
+			n = len(replies) - 1
+		}
+		json.NewEncoder(w).Encode(OllamaResponse{Response: replies[n]})
+	}))
+}
+
+func TestOllamaArbiter_Evaluate_RepairsInvalidVerdict(t *testing.T) {
+	var calls atomic.Int32
+	server := ollamaReplies(&calls,
+		`not json at all`,
+		`{"selected_service": "systran", "final_text": "", "reasoning": "fixed"}`)
+	defer server.Close()
+
+	res, err := NewOllamaArbiter("llama3.2", server.URL).Evaluate(context.Background(), "Click [PH0] to continue", "en", "uk", validationCandidates())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected one repair call, got %d calls", calls.Load())
+	}
+	if res.SelectedService != "systran" || res.Reasoning != "fixed" {
+		t.Errorf("expected repaired verdict, got %+v", res)
+	}
+}
+
+func TestOllamaArbiter_Evaluate_FallsBackAfterFailedRepair(t *testing.T) {
+	var calls atomic.Int32
+	server := ollamaReplies(&calls, `{"selected_service": "deepl", "final_text": "x", "reasoning": "?"}`)
+	defer server.Close()
+
+	res, err := NewOllamaArbiter("llama3.2", server.URL).Evaluate(context.Background(), "Click [PH0] to continue", "en", "uk", validationCandidates())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1+maxRepairAttempts {
+		t.Errorf("expected %d calls, got %d", 1+maxRepairAttempts, calls.Load())
+	}
+	if res.SelectedService != "google" && res.SelectedService != "systran" {
+		t.Errorf("expected fallback to pick a candidate, got %q", res.SelectedService)
+	}
+	if !strings.Contains(res.Reasoning, "fell back to consensus") {
+		t.Errorf("expected fallback noted in reasoning, got %q", res.Reasoning)
+	}
+}
@@ -7,6 +7,8 @@ package chunker
 import (
 	"strings"
 	"unicode"
+
+	"github.com/valpere/peretran/internal/segmenter"
 )
 
 const (
@@ -15,16 +17,36 @@ const (
 	DefaultContextWords = 25
 )
 
+// Chunker splits text at the sentence boundaries of one language.
+type Chunker struct {
+	seg *segmenter.Segmenter
+}
+
+// New returns a chunker that finds sentence ends with the rules of lang
+// (see segmenter.New); an empty or unknown lang uses the generic rules.
+func New(lang string) *Chunker {
+	return &Chunker{seg: segmenter.New(lang)}
+}
+
+// defaultChunker backs the package-level functions.
+var defaultChunker = New("")
+
+// Chunk splits text with the generic sentence rules; see Chunker.Chunk.
+func Chunk(text string, maxChars int) []string {
+	return defaultChunker.Chunk(text, maxChars)
+}
+
 // Chunk splits text into pieces each no longer than maxChars unicode
 // code points. Splits are attempted (in order of preference) at:
 //  1. Paragraph boundaries (\n\n or \r\n\r\n)
-//  2. Sentence-ending punctuation (. ! ?)
+//  2. Sentence ends, as found by the chunker's segmenter
 //  3. Whitespace (word boundary)
 //  4. Hard cut at maxChars if no suitable boundary is found
 //
-// If text fits entirely within maxChars, a single-element slice is returned.
+// If text fits entirely within maxChars, a single-element slice is returned;
+// so is a single empty chunk for longer text that is all whitespace.
 // If maxChars ≤ 0 it is treated as unlimited (returns the whole text).
-func Chunk(text string, maxChars int) []string {
+func (c *Chunker) Chunk(text string, maxChars int) []string {
 	if maxChars <= 0 || len([]rune(text)) <= maxChars {
 		return []string{text}
 	}
@@ -33,7 +55,7 @@ func Chunk(text string, maxChars int) []string {
 	remaining := text
 
 	for len([]rune(remaining)) > maxChars {
-		split := findSplit(remaining, maxChars)
+		split := c.findSplit(remaining, maxChars)
 		chunk := strings.TrimSpace(remaining[:split])
 		if chunk != "" {
 			chunks = append(chunks, chunk)
@@ -44,6 +66,9 @@ func Chunk(text string, maxChars int) []string {
 	if strings.TrimSpace(remaining) != "" {
 		chunks = append(chunks, strings.TrimSpace(remaining))
 	}
+	if len(chunks) == 0 {
+		return []string{""}
+	}
 
 	return chunks
 }
@@ -51,7 +76,7 @@ func Chunk(text string, maxChars int) []string {
 // findSplit returns the byte index within text at which to split, aiming for
 // at most maxChars runes. It searches backwards from maxChars for the best
 // split boundary.
-func findSplit(text string, maxChars int) int {
+func (c *Chunker) findSplit(text string, maxChars int) int {
 	runes := []rune(text)
 	if len(runes) <= maxChars {
 		return len(text)
@@ -69,15 +94,13 @@ func findSplit(text string, maxChars int) int {
 		return idx + 4
 	}
 
-	// 2. Sentence-ending punctuation followed by a space.
-	for i := len([]rune(candidate)) - 1; i > 0; i-- {
-		r := []rune(candidate)[i]
-		if (r == '.' || r == '!' || r == '?') && i+1 < len([]rune(candidate)) {
-			next := []rune(candidate)[i+1]
-			if unicode.IsSpace(next) {
-				byteOffset := len(string([]rune(candidate)[:i+1]))
-				return byteOffset
-			}
+	// 2. Sentence end. The segmenter looks a little past the candidate to
+	// see how the next sentence starts.
+	lookahead := string(runes[:min(len(runes), maxChars+sentenceLookahead)])
+	bounds := c.seg.Boundaries(lookahead)
+	for i := len(bounds) - 1; i >= 0; i-- {
+		if bounds[i] > 0 && bounds[i] <= len(candidate) {
+			return bounds[i]
 		}
 	}
 
@@ -93,6 +116,10 @@ func findSplit(text string, maxChars int) int {
 	return len(candidate)
 }
 
+// sentenceLookahead is how many runes past a split candidate the segmenter
+// sees, enough to tell "etc. and" from "etc. The".
+const sentenceLookahead = 16
+
 // lastIndex returns the last byte index of substr within s, or -1 if not found.
 func lastIndex(s, substr string) int {
 	idx := -1
@@ -123,3 +150,17 @@ func ExtractContext(text string, wordCount int) string {
 	}
 	return strings.Join(words[len(words)-wordCount:], " ")
 }
+
+// ExtractLeadingContext is the counterpart of ExtractContext for the text
+// that follows a chunk: it returns the first wordCount words of text, joined
+// by a single space. If wordCount ≤ 0, DefaultContextWords is used.
+func ExtractLeadingContext(text string, wordCount int) string {
+	if wordCount <= 0 {
+		wordCount = DefaultContextWords
+	}
+	words := strings.Fields(text)
+	if len(words) <= wordCount {
+		return strings.TrimSpace(text)
+	}
+	return strings.Join(words[:wordCount], " ")
+}
@@ -106,6 +106,13 @@ func TestChunk_EmptyText(t *testing.T) {
 	}
 }
 
+func TestChunk_WhitespaceOnlyText(t *testing.T) {
+	chunks := chunker.Chunk(strings.Repeat(" \n", 100), 10)
+	if len(chunks) != 1 || chunks[0] != "" {
+		t.Errorf("expected a single empty chunk, got %q", chunks)
+	}
+}
+
 // --- ExtractContext tests ---
 
 func TestExtractContext_FewerWordsThanLimit(t *testing.T) {
@@ -162,3 +169,44 @@ func TestExtractContext_LastWordsCorrect(t *testing.T) {
 		t.Errorf("expected last 3 words, got %q", ctx)
 	}
 }
+
+func TestExtractLeadingContext_FirstWordsCorrect(t *testing.T) {
+	text := "alpha beta\ngamma delta epsilon"
+	ctx := chunker.ExtractLeadingContext(text, 3)
+	if ctx != "alpha beta gamma" {
+		t.Errorf("expected first 3 words, got %q", ctx)
+	}
+	if got := chunker.ExtractLeadingContext("short text", 25); got != "short text" {
+		t.Errorf("expected whole text back, got %q", got)
+	}
+}
+
+// --- Language-aware splitting ---
+
+func TestChunker_DoesNotSplitAfterAbbreviation(t *testing.T) {
+	text := "We met Dr. Smith at noon. He was late."
+	// The budget ends just after "Dr. Smith"; the only sentence end before
+	// it is the abbreviation, which must not be used.
+	chunks := chunker.New("en").Chunk(text, 20)
+	for _, c := range chunks {
+		if strings.HasSuffix(c, "Dr.") {
+			t.Errorf("chunk split after abbreviation: %q", chunks)
+		}
+	}
+}
+
+func TestChunker_SplitsAtCJKSentenceEnd(t *testing.T) {
+	text := "今天天气很好。我们去公园散步吧。"
+	chunks := chunker.New("zh").Chunk(text, 10)
+	if len(chunks) != 2 || chunks[0] != "今天天气很好。" {
+		t.Errorf("expected split after the first 。, got %q", chunks)
+	}
+}
+
+func TestChunker_SplitsAtDanda(t *testing.T) {
+	text := "मैं घर जा रहा हूँ। तुम कहाँ हो?"
+	chunks := chunker.New("hi").Chunk(text, 25)
+	if len(chunks) != 2 || !strings.HasSuffix(chunks[0], "।") {
+		t.Errorf("expected split after the danda, got %q", chunks)
+	}
+}
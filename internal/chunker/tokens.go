@@ -0,0 +1,261 @@
+package chunker
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Token budget defaults.
+const (
+	// DefaultContextTokens is the context window assumed for unknown models
+	// and for Ollama, whose default num_ctx is small regardless of the model.
+	DefaultContextTokens = 2048
+	// DefaultOutputRatio is the expected number of output tokens per input
+	// token. Translations into Cyrillic or CJK scripts often need more tokens
+	// than the English source, so it is above 1.
+	DefaultOutputRatio = 1.5
+	// DefaultShrinkFactor is the share of a chunk kept when its translation
+	// was truncated: the chunk is reduced by 60% and retried.
+	DefaultShrinkFactor = 0.4
+	// DefaultShrinkAttempts is how many times a chunk is shrunk before its
+	// truncated translation is accepted.
+	DefaultShrinkAttempts = 3
+)
+
+// Tokenizer counts the tokens a model needs for a text.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// Estimator approximates a model's tokenizer from the average number of
+// characters per token for each script. It needs no vocabulary files and
+// errs on the side of overestimating.
+type Estimator struct {
+	Latin    float64 // Latin letters, digits, punctuation and spaces
+	Cyrillic float64 // Cyrillic and Greek
+	CJK      float64 // Han, Hiragana, Katakana and Hangul
+	Other    float64 // everything else (Arabic, Devanagari, ...)
+}
+
+// CountTokens returns the estimated token count of text.
+func (e Estimator) CountTokens(text string) int {
+	var n float64
+	for _, r := range text {
+		switch {
+		case r <= unicode.MaxASCII || unicode.Is(unicode.Latin, r):
+			n += 1 / e.Latin
+		case unicode.In(r, unicode.Cyrillic, unicode.Greek):
+			n += 1 / e.Cyrillic
+		case isCJK(r):
+			n += 1 / e.CJK
+		default:
+			n += 1 / e.Other
+		}
+	}
+	return int(math.Ceil(n))
+}
+
+// ModelProfile describes a model family: its context window and an estimate
+// of its tokenizer.
+type ModelProfile struct {
+	Family        string
+	ContextTokens int
+	Tokenizer     Estimator
+}
+
+// modelProfiles is matched against the model name in order; the first family
+// found in the name wins. Context windows are the models' native ones; the
+// estimates favour larger vocabularies (gemma, gemini, gpt-4o) over smaller
+// ones (mistral) for non-Latin scripts.
+var modelProfiles = []ModelProfile{
+	{"gemini", 1048576, Estimator{Latin: 4, Cyrillic: 3, CJK: 1.3, Other: 2.5}},
+	{"gemma3", 131072, Estimator{Latin: 4, Cyrillic: 3.2, CJK: 1.5, Other: 2.5}},
+	{"gemma", 8192, Estimator{Latin: 4, Cyrillic: 3.2, CJK: 1.5, Other: 2.5}},
+	{"llama", 131072, Estimator{Latin: 4, Cyrillic: 2.8, CJK: 1.1, Other: 2}},
+	{"qwen", 32768, Estimator{Latin: 3.8, Cyrillic: 2.5, CJK: 1.4, Other: 1.8}},
+	{"mixtral", 32768, Estimator{Latin: 3.5, Cyrillic: 2, CJK: 0.9, Other: 1.3}},
+	{"mistral", 32768, Estimator{Latin: 3.5, Cyrillic: 2, CJK: 0.9, Other: 1.3}},
+	{"phi", 16384, Estimator{Latin: 3.8, Cyrillic: 2.2, CJK: 1, Other: 1.5}},
+	{"aya", 8192, Estimator{Latin: 4, Cyrillic: 3, CJK: 1.3, Other: 2.5}},
+	{"gpt", 128000, Estimator{Latin: 4, Cyrillic: 3, CJK: 1.2, Other: 2.2}},
+}
+
+// defaultProfile is used for models not in modelProfiles.
+var defaultProfile = ModelProfile{"default", DefaultContextTokens, Estimator{Latin: 3.5, Cyrillic: 2, CJK: 0.9, Other: 1.3}}
+
+// ProfileFor returns the profile of the family model belongs to, matched
+// case-insensitively by name ("gemma2:27b", "google/gemini-2.5-flash").
+func ProfileFor(model string) ModelProfile {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, p := range modelProfiles {
+		if strings.Contains(name, p.Family) {
+			return p
+		}
+	}
+	return defaultProfile
+}
+
+// TokenBudget derives the largest chunk a model can translate in one call.
+type TokenBudget struct {
+	// ContextTokens is the model's context window.
+	ContextTokens int
+	// OverheadTokens is what every request spends besides the chunk: the
+	// prompt template, glossary, style guide and previous-chunk context.
+	OverheadTokens int
+	// OutputRatio is the expected output tokens per input token; zero means
+	// DefaultOutputRatio.
+	OutputRatio float64
+	// MaxOutputTokens caps the reply length when the backend limits it
+	// (e.g. max_tokens); zero means only the context window applies.
+	MaxOutputTokens int
+}
+
+// MaxChunkTokens returns the chunk size in tokens that leaves room in the
+// context window for the overhead and the translation, or 0 when the
+// overhead alone fills the window.
+func (b TokenBudget) MaxChunkTokens() int {
+	ratio := b.OutputRatio
+	if ratio <= 0 {
+		ratio = DefaultOutputRatio
+	}
+	free := b.ContextTokens - b.OverheadTokens
+	if free <= 0 {
+		return 0
+	}
+	n := int(float64(free) / (1 + ratio))
+	if b.MaxOutputTokens > 0 {
+		if out := int(float64(b.MaxOutputTokens) / ratio); out < n {
+			n = out
+		}
+	}
+	return n
+}
+
+// ChunkTokens splits text with the generic sentence rules; see
+// Chunker.ChunkTokens.
+func ChunkTokens(text string, maxTokens int, tok Tokenizer) []string {
+	return defaultChunker.ChunkTokens(text, maxTokens, tok)
+}
+
+// SplitTokens splits text with the generic sentence rules; see
+// Chunker.SplitTokens.
+func SplitTokens(text string, maxTokens int, tok Tokenizer) (pieces, seps []string) {
+	return defaultChunker.SplitTokens(text, maxTokens, tok)
+}
+
+// Shrink splits text with the generic sentence rules; see Chunker.Shrink.
+func Shrink(text string, factor float64, tok Tokenizer) (pieces, seps []string) {
+	return defaultChunker.Shrink(text, factor, tok)
+}
+
+// ChunkTokens splits text like Chunk, but into pieces of at most maxTokens
+// as counted by tok. If maxTokens ≤ 0 the whole text is returned.
+func (c *Chunker) ChunkTokens(text string, maxTokens int, tok Tokenizer) []string {
+	pieces, _ := c.SplitTokens(text, maxTokens, tok)
+	return pieces
+}
+
+// SplitTokens splits text into pieces of at most maxTokens and also returns
+// the separator that followed each piece in text: "\n\n" after a paragraph,
+// "\n" after a line and " " otherwise; the last separator is "". Joining
+// pieces with their separators restores the text's layout.
+func (c *Chunker) SplitTokens(text string, maxTokens int, tok Tokenizer) (pieces, seps []string) {
+	if maxTokens <= 0 || tok.CountTokens(text) <= maxTokens {
+		return []string{text}, []string{""}
+	}
+
+	remaining := text
+	for tok.CountTokens(remaining) > maxTokens {
+		split := c.findSplit(remaining, runeBudget(remaining, maxTokens, tok))
+		if piece := strings.TrimSpace(remaining[:split]); piece != "" {
+			pieces = append(pieces, piece)
+			seps = append(seps, separator(remaining[:split], remaining[split:]))
+		}
+		remaining = strings.TrimSpace(remaining[split:])
+	}
+	if remaining != "" {
+		pieces = append(pieces, remaining)
+		seps = append(seps, "")
+	}
+	if len(pieces) == 0 {
+		return []string{""}, []string{""}
+	}
+	seps[len(seps)-1] = ""
+	return pieces, seps
+}
+
+// Shrink re-splits a chunk whose translation came back truncated into
+// pieces of at most factor times its token count, with their separators as
+// in SplitTokens. A single piece means the chunk cannot be split further.
+func (c *Chunker) Shrink(text string, factor float64, tok Tokenizer) (pieces, seps []string) {
+	if factor <= 0 || factor >= 1 {
+		factor = DefaultShrinkFactor
+	}
+	maxTokens := int(float64(tok.CountTokens(text)) * factor)
+	if maxTokens < 1 {
+		maxTokens = 1
+	}
+	return c.SplitTokens(text, maxTokens, tok)
+}
+
+// LooksTruncated reports whether translation is suspiciously short for
+// source, as happens when a model runs out of context or output tokens.
+// CJK characters count three times, since they carry about as much as a
+// short Latin word. Sources under 200 characters are never flagged.
+func LooksTruncated(source, translation string) bool {
+	src := weightedLen(source)
+	if src < 200 {
+		return false
+	}
+	return float64(weightedLen(translation)) < 0.4*float64(src)
+}
+
+// runeBudget returns the longest rune prefix of text within maxTokens, and
+// at least one rune.
+func runeBudget(text string, maxTokens int, tok Tokenizer) int {
+	runes := []rune(text)
+	lo, hi := 1, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if tok.CountTokens(string(runes[:mid])) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// separator classifies the whitespace between two consecutive pieces.
+func separator(before, after string) string {
+	gap := before[len(strings.TrimRightFunc(before, unicode.IsSpace)):] +
+		after[:len(after)-len(strings.TrimLeftFunc(after, unicode.IsSpace))]
+	switch {
+	case strings.Count(gap, "\n") >= 2:
+		return "\n\n"
+	case strings.Contains(gap, "\n"):
+		return "\n"
+	default:
+		return " "
+	}
+}
+
+func weightedLen(s string) int {
+	n := 0
+	for _, r := range s {
+		if isCJK(r) {
+			n += 3
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
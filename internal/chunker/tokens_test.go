@@ -0,0 +1,144 @@
+package chunker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/chunker"
+)
+
+// charTokenizer counts one token per rune.
+type charTokenizer struct{}
+
+func (charTokenizer) CountTokens(text string) int { return len([]rune(text)) }
+
+func TestEstimator_CountTokens(t *testing.T) {
+	e := chunker.Estimator{Latin: 4, Cyrillic: 2, CJK: 1, Other: 1}
+
+	if got := e.CountTokens("abcdefgh"); got != 2 {
+		t.Errorf("Latin: expected 2 tokens, got %d", got)
+	}
+	if got := e.CountTokens("абвгде"); got != 3 {
+		t.Errorf("Cyrillic: expected 3 tokens, got %d", got)
+	}
+	if got := e.CountTokens("翻译文本"); got != 4 {
+		t.Errorf("CJK: expected 4 tokens, got %d", got)
+	}
+	if got := e.CountTokens(""); got != 0 {
+		t.Errorf("empty: expected 0 tokens, got %d", got)
+	}
+}
+
+func TestProfileFor(t *testing.T) {
+	tests := []struct {
+		model, family string
+	}{
+		{"gemma2:27b", "gemma"},
+		{"gemma3:12b-it-qat", "gemma3"},
+		{"google/gemini-2.5-flash-preview:free", "gemini"},
+		{"meta-llama/llama-3.1-8b-instruct:free", "llama"},
+		{"Qwen3:14B", "qwen"},
+		{"mixtral:8x7b", "mixtral"},
+		{"unknown-model", "default"},
+	}
+	for _, tt := range tests {
+		if got := chunker.ProfileFor(tt.model).Family; got != tt.family {
+			t.Errorf("ProfileFor(%q) = %q, want %q", tt.model, got, tt.family)
+		}
+	}
+	if got := chunker.ProfileFor("unknown-model").ContextTokens; got != chunker.DefaultContextTokens {
+		t.Errorf("expected default context %d, got %d", chunker.DefaultContextTokens, got)
+	}
+}
+
+func TestTokenBudget_MaxChunkTokens(t *testing.T) {
+	b := chunker.TokenBudget{ContextTokens: 2048, OverheadTokens: 298, OutputRatio: 1.5}
+	if got := b.MaxChunkTokens(); got != 700 {
+		t.Errorf("expected 700 tokens, got %d", got)
+	}
+
+	b.MaxOutputTokens = 600
+	if got := b.MaxChunkTokens(); got != 400 {
+		t.Errorf("expected output cap to limit chunk to 400 tokens, got %d", got)
+	}
+
+	b = chunker.TokenBudget{ContextTokens: 100, OverheadTokens: 200}
+	if got := b.MaxChunkTokens(); got != 0 {
+		t.Errorf("expected 0 when overhead fills the window, got %d", got)
+	}
+}
+
+func TestChunkTokens_RespectsBudget(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)
+	chunks := chunker.ChunkTokens(text, 100, charTokenizer{})
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := len([]rune(c)); n > 100 {
+			t.Errorf("chunk %d has %d tokens, budget is 100", i, n)
+		}
+		if !strings.HasSuffix(c, ".") {
+			t.Errorf("chunk %d should end at a sentence boundary: %q", i, c)
+		}
+	}
+}
+
+func TestSplitTokens_Separators(t *testing.T) {
+	text := "First paragraph is here.\n\nSecond paragraph, first sentence. Second sentence is longer."
+	pieces, seps := chunker.SplitTokens(text, 40, charTokenizer{})
+	if len(pieces) != len(seps) {
+		t.Fatalf("got %d pieces and %d separators", len(pieces), len(seps))
+	}
+	if len(pieces) < 3 {
+		t.Fatalf("expected at least 3 pieces, got %v", pieces)
+	}
+	if seps[0] != "\n\n" {
+		t.Errorf("expected paragraph separator after first piece, got %q", seps[0])
+	}
+	if seps[1] != " " {
+		t.Errorf("expected space separator between sentences, got %q", seps[1])
+	}
+	if seps[len(seps)-1] != "" {
+		t.Errorf("expected empty final separator, got %q", seps[len(seps)-1])
+	}
+
+	var b strings.Builder
+	for i := range pieces {
+		b.WriteString(pieces[i] + seps[i])
+	}
+	if b.String() != text {
+		t.Errorf("joining pieces with separators should restore the text, got %q", b.String())
+	}
+}
+
+func TestShrink(t *testing.T) {
+	text := "One sentence here. Another sentence there. A third one follows. And a fourth."
+	pieces, _ := chunker.Shrink(text, chunker.DefaultShrinkFactor, charTokenizer{})
+	if len(pieces) < 3 {
+		t.Fatalf("expected the chunk to be reduced by 60%%, got %d pieces: %v", len(pieces), pieces)
+	}
+	limit := int(float64(len([]rune(text))) * chunker.DefaultShrinkFactor)
+	for i, p := range pieces {
+		if n := len([]rune(p)); n > limit {
+			t.Errorf("piece %d has %d tokens, limit is %d", i, n, limit)
+		}
+	}
+}
+
+func TestLooksTruncated(t *testing.T) {
+	source := strings.Repeat("This sentence is part of a long source text. ", 10)
+
+	if chunker.LooksTruncated("Short source.", "Коротко") {
+		t.Error("short sources should never be flagged")
+	}
+	if !chunker.LooksTruncated(source, "Це речення є частиною") {
+		t.Error("expected a much shorter translation to be flagged")
+	}
+	if chunker.LooksTruncated(source, strings.Repeat("Це речення є частиною довгого тексту. ", 10)) {
+		t.Error("a full-length translation should not be flagged")
+	}
+	if chunker.LooksTruncated(source, strings.Repeat("这句话是长篇源文本的一部分。", 10)) {
+		t.Error("a complete CJK translation should not be flagged")
+	}
+}
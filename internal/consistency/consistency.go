@@ -0,0 +1,384 @@
+// Package consistency harmonizes the terminology of a document translated in
+// chunks. Chunks translated independently drift: a name or term can get
+// several renderings across a book. The pass finds the recurring source
+// terms, asks an LLM how each chunk rendered them, picks the glossary or
+// majority rendering, and has the LLM revise the chunks that deviate.
+package consistency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/valpere/peretran/internal/postprocess"
+	"github.com/valpere/peretran/internal/prompts"
+)
+
+// Model sends a prompt to an LLM and returns its raw reply; jsonMode
+// requests a JSON object. refiner.LLMRefiner implements it.
+type Model interface {
+	Complete(ctx context.Context, prompt string, jsonMode bool) (string, error)
+}
+
+// Guard rejects a revised chunk that changed more than its terms: its
+// length, numbers, URLs or placeholders. refiner.Guard implements it.
+type Guard interface {
+	Check(ctx context.Context, sourceLang, targetLang, sourceText, draft, refined string) error
+}
+
+// Checker runs the consistency pass with one model.
+type Checker struct {
+	model        Model
+	prompts      *prompts.Set
+	glossary     map[string]string
+	instructions string
+	guard        Guard
+}
+
+// New returns a checker that queries model.
+func New(model Model) *Checker {
+	return &Checker{model: model}
+}
+
+// SetPrompts sets the prompt templates; nil means the embedded defaults.
+func (c *Checker) SetPrompts(p *prompts.Set) {
+	c.prompts = p
+}
+
+// SetGlossary sets the required terminology. Glossary terms are checked in
+// every chunk they occur in, even just one, and their glossary rendering
+// wins over the majority.
+func (c *Checker) SetGlossary(terms map[string]string) {
+	c.glossary = terms
+}
+
+// SetInstructions sets extra instructions for the harmonization prompt,
+// e.g. the placeholder-preservation hint.
+func (c *Checker) SetInstructions(instructions string) {
+	c.instructions = instructions
+}
+
+// SetGuard sets the checks a revised chunk must pass; nil accepts any
+// revision that uses the required renderings.
+func (c *Checker) SetGuard(g Guard) {
+	c.guard = g
+}
+
+// Variant is one rendering of a term and the chunks (0-based) using it.
+type Variant struct {
+	Rendering string
+	Chunks    []int
+}
+
+// TermReport describes how a term was rendered across the document.
+type TermReport struct {
+	Term string
+	// Chosen is the rendering the document is harmonized to.
+	Chosen string
+	// FromGlossary is true when Chosen comes from the glossary rather than
+	// the majority of chunks.
+	FromGlossary bool
+	// Variants lists every rendering found, the most used first.
+	Variants []Variant
+}
+
+// Consistent reports whether every chunk already uses the chosen rendering.
+func (t TermReport) Consistent() bool {
+	for _, v := range t.Variants {
+		if !strings.EqualFold(v.Rendering, t.Chosen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Change records one term rendering replaced in a chunk.
+type Change struct {
+	Chunk int
+	Term  string
+	From  string
+	To    string
+}
+
+// Report is the outcome of the consistency pass.
+type Report struct {
+	// Terms lists every checked term that was found in the translation.
+	Terms []TermReport
+	// Changes lists the renderings replaced, by chunk.
+	Changes []Change
+	// Warnings explains chunks that could not be checked or revised.
+	Warnings []string
+}
+
+// Harmonize checks the recurring terms of sources (the source chunks) in
+// translations and returns the translations with deviating chunks revised.
+// A revision is kept only if it passes the guard and uses every required
+// rendering. A chunk that cannot be checked or revised keeps its
+// translation and the failure is reported as a warning; only a cancelled
+// context aborts the pass.
+func (c *Checker) Harmonize(ctx context.Context, sourceLang, targetLang string, sources, translations []string) ([]string, *Report, error) {
+	if len(sources) != len(translations) {
+		return nil, nil, fmt.Errorf("got %d source chunks but %d translations", len(sources), len(translations))
+	}
+	report := &Report{}
+	out := append([]string(nil), translations...)
+
+	terms := ExtractTerms(sourceLang, sources, c.glossary)
+	if len(terms) == 0 || len(sources) < 2 {
+		return out, report, nil
+	}
+
+	// renderings[term][chunk] is the chunk's rendering of the term.
+	renderings := map[string]map[int]string{}
+	for i, source := range sources {
+		chunkTerms := termsIn(source, terms)
+		if len(chunkTerms) == 0 {
+			continue
+		}
+		found, err := c.renderings(ctx, sourceLang, targetLang, source, translations[i], chunkTerms)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, err
+			}
+			report.Warnings = append(report.Warnings, fmt.Sprintf("chunk %d: %v", i+1, err))
+			continue
+		}
+		for term, rendering := range found {
+			if renderings[term] == nil {
+				renderings[term] = map[int]string{}
+			}
+			renderings[term][i] = rendering
+		}
+	}
+
+	// fixes[chunk] lists the terms the chunk must change.
+	fixes := map[int][]prompts.Term{}
+	for _, term := range terms {
+		if len(renderings[term]) == 0 {
+			continue
+		}
+		tr := c.choose(term, renderings[term])
+		report.Terms = append(report.Terms, tr)
+		for _, v := range tr.Variants {
+			if strings.EqualFold(v.Rendering, tr.Chosen) {
+				continue
+			}
+			for _, chunk := range v.Chunks {
+				fixes[chunk] = append(fixes[chunk], prompts.Term{Source: term, Found: v.Rendering, Required: tr.Chosen})
+			}
+		}
+	}
+
+	for i := range sources {
+		if len(fixes[i]) == 0 {
+			continue
+		}
+		revised, err := c.revise(ctx, sourceLang, targetLang, sources[i], translations[i], fixes[i])
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, err
+			}
+			report.Warnings = append(report.Warnings, fmt.Sprintf("chunk %d: revision failed: %v", i+1, err))
+			continue
+		}
+		if c.guard != nil {
+			if err := c.guard.Check(ctx, sourceLang, targetLang, sources[i], translations[i], revised); err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				report.Warnings = append(report.Warnings, fmt.Sprintf("chunk %d: revision rejected: %v", i+1, err))
+				continue
+			}
+		}
+		if missing := missingRenderings(revised, fixes[i]); len(missing) > 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("chunk %d: revision rejected: it does not use %s", i+1, strings.Join(missing, ", ")))
+			continue
+		}
+		out[i] = revised
+		for _, f := range fixes[i] {
+			report.Changes = append(report.Changes, Change{Chunk: i, Term: f.Source, From: f.Found, To: f.Required})
+		}
+	}
+	return out, report, nil
+}
+
+// choose picks the rendering of term: the glossary one if the term is in
+// the glossary, otherwise the one used by most chunks, ties going to the
+// earliest chunk.
+func (c *Checker) choose(term string, byChunk map[int]string) TermReport {
+	tr := TermReport{Term: term}
+	chunks := make([]int, 0, len(byChunk))
+	for chunk := range byChunk {
+		chunks = append(chunks, chunk)
+	}
+	sort.Ints(chunks)
+
+	index := map[string]int{}
+	for _, chunk := range chunks {
+		rendering := byChunk[chunk]
+		key := strings.ToLower(rendering)
+		if j, ok := index[key]; ok {
+			tr.Variants[j].Chunks = append(tr.Variants[j].Chunks, chunk)
+			continue
+		}
+		index[key] = len(tr.Variants)
+		tr.Variants = append(tr.Variants, Variant{Rendering: rendering, Chunks: []int{chunk}})
+	}
+	sort.SliceStable(tr.Variants, func(a, b int) bool {
+		return len(tr.Variants[a].Chunks) > len(tr.Variants[b].Chunks)
+	})
+
+	if required, ok := lookupFold(c.glossary, term); ok {
+		tr.Chosen = required
+		tr.FromGlossary = true
+	} else if len(tr.Variants) > 0 {
+		tr.Chosen = tr.Variants[0].Rendering
+	}
+	return tr
+}
+
+// renderings asks the model how translation renders each of terms. Terms
+// it reports as absent are left out.
+func (c *Checker) renderings(ctx context.Context, sourceLang, targetLang, source, translation string, terms []string) (map[string]string, error) {
+	data := prompts.Data{
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+		Source:     source,
+		Draft:      translation,
+	}
+	for _, t := range terms {
+		data.Terms = append(data.Terms, prompts.Term{Source: t})
+	}
+	prompt, err := c.prompts.Render(prompts.TermRenderings, data)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.model.Complete(ctx, prompt, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseRenderings(response, terms)
+}
+
+// revise asks the model to apply fixes to translation. An empty or
+// unchanged reply is an error, so the chunk is not reported as changed.
+func (c *Checker) revise(ctx context.Context, sourceLang, targetLang, source, translation string, fixes []prompts.Term) (string, error) {
+	prompt, err := c.prompts.Render(prompts.Harmonize, prompts.Data{
+		SourceLang:   sourceLang,
+		TargetLang:   targetLang,
+		Source:       source,
+		Draft:        translation,
+		Terms:        fixes,
+		Instructions: c.instructions,
+	})
+	if err != nil {
+		return "", err
+	}
+	response, err := c.model.Complete(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	revised := postprocess.Clean(response)
+	if revised == "" || revised == translation {
+		return "", fmt.Errorf("model made no changes")
+	}
+	return revised, nil
+}
+
+// missingRenderings returns the required renderings of fixes, quoted, that
+// text does not use. Each word of a rendering must begin a word of text
+// with its stem, the word without its last two letters but at least three
+// long: the model is asked for the dictionary form, while the text inflects
+// it as the sentence needs.
+func missingRenderings(text string, fixes []prompts.Term) []string {
+	words := lowerWords(text)
+	uses := func(stem string) bool {
+		for _, w := range words {
+			if strings.HasPrefix(w, stem) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var missing []string
+	for _, f := range fixes {
+		for _, word := range lowerWords(f.Required) {
+			stem := []rune(word)
+			stem = stem[:max(min(len(stem), 3), len(stem)-2)]
+			if !uses(string(stem)) {
+				missing = append(missing, fmt.Sprintf("%q", f.Required))
+				break
+			}
+		}
+	}
+	return missing
+}
+
+// lowerWords splits text into lower-case words of letters and digits.
+func lowerWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func parseRenderings(response string, terms []string) (map[string]string, error) {
+	response = strings.TrimSpace(response)
+	if start, end := strings.Index(response, "{"), strings.LastIndex(response, "}"); start >= 0 && end > start {
+		response = response[start : end+1]
+	}
+
+	var parsed struct {
+		Renderings []struct {
+			Term      string `json:"term"`
+			Rendering string `json:"rendering"`
+		} `json:"renderings"`
+	}
+	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse term renderings: %w", err)
+	}
+
+	found := map[string]string{}
+	for _, r := range parsed.Renderings {
+		rendering := strings.Trim(strings.TrimSpace(r.Rendering), `"'«»“”`)
+		if rendering == "" {
+			continue
+		}
+		// Map the model's spelling of the term back to ours.
+		for _, t := range terms {
+			if strings.EqualFold(strings.TrimSpace(r.Term), t) {
+				found[t] = rendering
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// termsIn returns the terms that occur in source, case-insensitively.
+func termsIn(source string, terms []string) []string {
+	lower := strings.ToLower(source)
+	var in []string
+	for _, t := range terms {
+		if strings.Contains(lower, strings.ToLower(t)) {
+			in = append(in, t)
+		}
+	}
+	return in
+}
+
+// lookupFold returns the glossary entry for term, matched case-insensitively.
+func lookupFold(glossary map[string]string, term string) (string, bool) {
+	if v, ok := glossary[term]; ok {
+		return v, true
+	}
+	for k, v := range glossary {
+		if strings.EqualFold(k, term) {
+			return v, true
+		}
+	}
+	return "", false
+}
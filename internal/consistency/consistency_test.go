@@ -0,0 +1,174 @@
+package consistency_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/consistency"
+)
+
+// fakeModel answers rendering queries from renderings (by translation) and
+// harmonization requests with a fixed replacement.
+type fakeModel struct {
+	renderings map[string]string
+	revisions  []string
+	// revision is the harmonized text; "Ганна пішла додому." if empty.
+	revision string
+}
+
+func (m *fakeModel) Complete(_ context.Context, prompt string, jsonMode bool) (string, error) {
+	if jsonMode {
+		for translation, rendering := range m.renderings {
+			if strings.Contains(prompt, translation) {
+				return fmt.Sprintf(`{"renderings": [{"term": "anna", "rendering": %q}]}`, rendering), nil
+			}
+		}
+		return `{"renderings": []}`, nil
+	}
+	m.revisions = append(m.revisions, prompt)
+	if m.revision != "" {
+		return m.revision, nil
+	}
+	return "Ганна пішла додому.", nil
+}
+
+func TestHarmonize_MajorityRendering(t *testing.T) {
+	sources := []string{"Then Anna came.", "Then Anna smiled.", "Then Anna went home."}
+	translations := []string{"Потім прийшла Ганна.", "Потім усміхнулася Ганна.", "Потім Анна пішла додому."}
+	model := &fakeModel{renderings: map[string]string{
+		translations[0]: "Ганна",
+		translations[1]: "Ганна",
+		translations[2]: "Анна",
+	}}
+
+	out, report, err := consistency.New(model).Harmonize(context.Background(), "en", "uk", sources, translations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out[2] != "Ганна пішла додому." || out[0] != translations[0] {
+		t.Errorf("expected only chunk 3 revised, got %q", out)
+	}
+	if len(model.revisions) != 1 || !strings.Contains(model.revisions[0], `Anna → Ганна (this passage uses "Анна")`) {
+		t.Errorf("unexpected harmonization prompts: %q", model.revisions)
+	}
+	if len(report.Changes) != 1 || report.Changes[0] != (consistency.Change{Chunk: 2, Term: "Anna", From: "Анна", To: "Ганна"}) {
+		t.Errorf("unexpected changes: %+v", report.Changes)
+	}
+	if len(report.Terms) != 1 || report.Terms[0].Consistent() || report.Terms[0].FromGlossary {
+		t.Errorf("unexpected term report: %+v", report.Terms)
+	}
+}
+
+func TestHarmonize_GlossaryWins(t *testing.T) {
+	sources := []string{"Then Anna came.", "Then Anna smiled."}
+	translations := []string{"Потім прийшла Ганна.", "Потім усміхнулася Ганна."}
+	model := &fakeModel{renderings: map[string]string{
+		translations[0]: "Ганна",
+		translations[1]: "Ганна",
+	}, revision: "Потім Анну побачили."}
+	c := consistency.New(model)
+	c.SetGlossary(map[string]string{"anna": "Анна"})
+
+	_, report, err := c.Harmonize(context.Background(), "en", "uk", sources, translations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Changes) != 2 || !report.Terms[0].FromGlossary || report.Terms[0].Chosen != "Анна" {
+		t.Errorf("expected both chunks moved to the glossary rendering, got %+v", report)
+	}
+}
+
+func TestHarmonize_AlreadyConsistent(t *testing.T) {
+	sources := []string{"Then Anna came.", "Then Anna smiled."}
+	translations := []string{"Потім прийшла Ганна.", "Потім усміхнулася ганна."}
+	model := &fakeModel{renderings: map[string]string{
+		translations[0]: "Ганна",
+		translations[1]: "ганна",
+	}}
+
+	out, report, err := consistency.New(model).Harmonize(context.Background(), "en", "uk", sources, translations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(model.revisions) != 0 || len(report.Changes) != 0 || out[1] != translations[1] {
+		t.Errorf("expected no revisions, got %+v", report)
+	}
+	if !report.Terms[0].Consistent() {
+		t.Errorf("expected a consistent term, got %+v", report.Terms[0])
+	}
+}
+
+func TestHarmonize_UnparsableRenderings(t *testing.T) {
+	sources := []string{"Then Anna came.", "Then Anna smiled."}
+	translations := []string{"a", "b"}
+	model := modelFunc(func(string, bool) (string, error) { return "not json", nil })
+
+	out, report, err := consistency.New(model).Harmonize(context.Background(), "en", "uk", sources, translations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Warnings) != 2 || out[0] != "a" {
+		t.Errorf("expected a warning per chunk and unchanged text, got %+v", report)
+	}
+}
+
+func TestHarmonize_RejectsRevisionWithoutRendering(t *testing.T) {
+	sources := []string{"Then Anna came.", "Then Anna smiled."}
+	translations := []string{"Потім прийшла Ганна.", "Потім усміхнулася Ганна."}
+	model := &fakeModel{renderings: map[string]string{
+		translations[0]: "Ганна",
+		translations[1]: "Ганна",
+	}}
+	c := consistency.New(model)
+	c.SetGlossary(map[string]string{"anna": "Анна"})
+
+	out, report, err := c.Harmonize(context.Background(), "en", "uk", sources, translations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Changes) != 0 || len(report.Warnings) != 2 || out[0] != translations[0] {
+		t.Errorf("expected both revisions rejected, got %+v", report)
+	}
+}
+
+func TestHarmonize_Guard(t *testing.T) {
+	sources := []string{"Then Anna came.", "Then Anna smiled."}
+	translations := []string{"Потім прийшла Ганна.", "Потім усміхнулася Ганна."}
+	model := &fakeModel{renderings: map[string]string{
+		translations[0]: "Ганна",
+		translations[1]: "Ганна",
+	}, revision: "Потім Анну побачили."}
+	c := consistency.New(model)
+	c.SetGlossary(map[string]string{"anna": "Анна"})
+	c.SetGuard(guardFunc(func(source, draft, refined string) error {
+		if source == sources[1] {
+			return fmt.Errorf("length ratio out of range")
+		}
+		return nil
+	}))
+
+	out, report, err := c.Harmonize(context.Background(), "en", "uk", sources, translations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].Chunk != 0 || out[1] != translations[1] {
+		t.Errorf("expected only chunk 1 revised, got %q, %+v", out, report)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "length ratio") {
+		t.Errorf("unexpected warnings: %q", report.Warnings)
+	}
+}
+
+type guardFunc func(source, draft, refined string) error
+
+func (f guardFunc) Check(_ context.Context, _, _, source, draft, refined string) error {
+	return f(source, draft, refined)
+}
+
+type modelFunc func(prompt string, jsonMode bool) (string, error)
+
+func (f modelFunc) Complete(_ context.Context, prompt string, jsonMode bool) (string, error) {
+	return f(prompt, jsonMode)
+}
@@ -0,0 +1,65 @@
+package consistency
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/valpere/peretran/internal/terms"
+)
+
+// MaxTerms caps the number of terms checked per document, most widespread
+// first, to keep the rendering prompts short.
+const MaxTerms = 40
+
+// ExtractTerms returns the source terms worth keeping consistent across
+// chunks: names and other capitalized phrases (see terms.Names) that occur
+// in at least two chunks, plus glossary terms that occur in any chunk, since
+// a single deviating rendering of those still breaks the glossary. Scripts
+// without capitals (CJK, Arabic, ...) only yield glossary terms. Terms are
+// ordered by the number of chunks they occur in, then by first occurrence.
+func ExtractTerms(lang string, chunks []string, glossary map[string]string) []string {
+	seen := map[string]map[int]bool{}
+	var order []string
+	add := func(term string, chunk int) {
+		if seen[term] == nil {
+			seen[term] = map[int]bool{}
+			order = append(order, term)
+		}
+		seen[term][chunk] = true
+	}
+
+	for i, names := range terms.Names(lang, chunks) {
+		for _, name := range names {
+			add(name, i)
+		}
+	}
+	glossaryTerms := make([]string, 0, len(glossary))
+	for term := range glossary {
+		glossaryTerms = append(glossaryTerms, term)
+	}
+	sort.Strings(glossaryTerms)
+	inGlossary := make(map[string]bool, len(glossaryTerms))
+	for _, term := range glossaryTerms {
+		inGlossary[term] = true
+		lower := strings.ToLower(term)
+		for i, chunk := range chunks {
+			if strings.Contains(strings.ToLower(chunk), lower) {
+				add(term, i)
+			}
+		}
+	}
+
+	var found []string
+	for _, term := range order {
+		if len(seen[term]) >= 2 || inGlossary[term] {
+			found = append(found, term)
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool {
+		return len(seen[found[a]]) > len(seen[found[b]])
+	})
+	if len(found) > MaxTerms {
+		found = found[:MaxTerms]
+	}
+	return found
+}
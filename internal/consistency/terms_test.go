@@ -0,0 +1,42 @@
+package consistency_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/valpere/peretran/internal/consistency"
+)
+
+func TestExtractTerms(t *testing.T) {
+	chunks := []string{
+		"The captain met Anna Karenina at the station. Anna's brother waved.",
+		"When the train left, Vronsky looked at Anna Karenina again. The Station Master nodded.",
+		"Later Vronsky spoke to the Station Master. It was cold.",
+	}
+	got := consistency.ExtractTerms("en", chunks, nil)
+	want := []string{"Anna Karenina", "Vronsky", "Station Master"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtractTerms_SentenceStartNeedsInnerCapital(t *testing.T) {
+	chunks := []string{
+		"Moscow was cold. Winter came early.",
+		"Moscow slept. Winter stayed. They lived in Moscow then.",
+	}
+	got := consistency.ExtractTerms("en", chunks, nil)
+	want := []string{"Moscow"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtractTerms_Glossary(t *testing.T) {
+	chunks := []string{"我们去长城。", "长城很长。", "再见。"}
+	got := consistency.ExtractTerms("zh", chunks, map[string]string{"长城": "Велика китайська стіна", "再见": "До побачення"})
+	want := []string{"长城", "再见"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,522 @@
+// Package htmldoc splits an HTML document into translatable segments and
+// writes the translations back into its DOM. A segment is a run of inline
+// content — text and phrasing elements such as <a>, <em> or <code> — inside
+// a block, sent to translation as one piece with the tags replaced by
+// [PHn] markers, or the value of a translatable attribute (alt, title,
+// placeholder, aria-label, and the content of descriptive <meta> tags).
+package htmldoc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+// translatableAttrs are the attributes whose values are translated on any
+// element.
+var translatableAttrs = map[string]bool{
+	"alt":         true,
+	"title":       true,
+	"placeholder": true,
+	"aria-label":  true,
+}
+
+// metaNames are the <meta> names (or Open Graph properties) whose content
+// is translated.
+var metaNames = map[string]bool{
+	"description":         true,
+	"keywords":            true,
+	"og:title":            true,
+	"og:description":      true,
+	"twitter:title":       true,
+	"twitter:description": true,
+}
+
+// inlineTags are the phrasing elements grouped with the text around them.
+var inlineTags = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.Acronym: true, atom.B: true, atom.Bdi: true,
+	atom.Bdo: true, atom.Big: true, atom.Br: true, atom.Button: true, atom.Cite: true,
+	atom.Code: true, atom.Data: true, atom.Del: true, atom.Dfn: true, atom.Em: true,
+	atom.Font: true, atom.I: true, atom.Img: true, atom.Input: true, atom.Ins: true,
+	atom.Kbd: true, atom.Label: true, atom.Mark: true, atom.Meter: true, atom.Output: true,
+	atom.Progress: true, atom.Q: true, atom.Ruby: true, atom.Rp: true, atom.Rt: true,
+	atom.S: true, atom.Samp: true, atom.Select: true, atom.Small: true, atom.Span: true,
+	atom.Strike: true, atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.Textarea: true,
+	atom.Time: true, atom.Tt: true, atom.U: true, atom.Var: true, atom.Wbr: true,
+	atom.Svg: true, atom.Math: true,
+}
+
+// opaqueTags are inline elements kept whole: their content is code, media
+// or form data rather than prose. Inside a run each becomes one marker.
+var opaqueTags = map[atom.Atom]bool{
+	atom.Code: true, atom.Kbd: true, atom.Samp: true, atom.Var: true, atom.Img: true,
+	atom.Br: true, atom.Wbr: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Svg: true, atom.Math: true, atom.Meter: true, atom.Progress: true,
+}
+
+// skippedTags are elements whose content is never translated.
+var skippedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Pre: true, atom.Code: true, atom.Textarea: true, atom.Svg: true, atom.Math: true,
+	atom.Iframe: true, atom.Object: true,
+}
+
+var reMarker = regexp.MustCompile(`\[PH(\d+)\]`)
+
+// Document is a parsed HTML document or fragment.
+type Document struct {
+	root *html.Node
+	// fragment is true when the source had no <html>, <head> or <body>
+	// and is rendered without them.
+	fragment bool
+	// Segments lists the translatable text in document order.
+	Segments []Segment
+}
+
+// Segment is one piece of translatable text.
+type Segment struct {
+	// Text is the text to translate, whitespace collapsed. In element
+	// content, each inline tag is replaced by a [PHn] marker; an opaque
+	// element (code, image, line break, untranslatable span) takes a
+	// single marker.
+	Text string
+	// Attr is the attribute the text comes from, or "" for element
+	// content.
+	Attr string
+	// Lang is the language declared by the nearest lang attribute, or ""
+	// when none is.
+	Lang string
+
+	// node is the element owning the attribute, or the parent of the run.
+	node *html.Node
+	// first and last are the first and last nodes of the run.
+	first, last *html.Node
+	// lead and trail are the whitespace around the run, kept as is.
+	lead, trail string
+	parts       []part
+}
+
+// part is what a marker stands for: the start or end of an element, or a
+// whole opaque node.
+type part struct {
+	node *html.Node
+	kind partKind
+}
+
+type partKind int
+
+const (
+	partOpen partKind = iota
+	partClose
+	partOpaque
+)
+
+// PlainText returns Text without the markers, e.g. to show the segment to
+// a model as context.
+func (s Segment) PlainText() string {
+	return strings.Join(strings.Fields(reMarker.ReplaceAllString(s.Text, " ")), " ")
+}
+
+// Parse parses src. A source without <html>, <head> or <body> tags is
+// parsed as a fragment of a body and rendered the same way.
+func Parse(src string) (*Document, error) {
+	d := &Document{}
+	lower := strings.ToLower(src)
+	if strings.Contains(lower, "<html") || strings.Contains(lower, "<head") ||
+		strings.Contains(lower, "<body") || strings.Contains(lower, "<!doctype") {
+		root, err := html.Parse(strings.NewReader(src))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		d.root = root
+	} else {
+		body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		nodes, err := html.ParseFragment(strings.NewReader(src), body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		d.fragment = true
+		d.root = &html.Node{Type: html.DocumentNode}
+		for _, n := range nodes {
+			d.root.AppendChild(n)
+		}
+	}
+	d.container(d.root, true, "")
+	return d, nil
+}
+
+// Lang returns the lang attribute of the <html> element, or "".
+func (d *Document) Lang() string {
+	if h := findElement(d.root, atom.Html); h != nil {
+		return attr(h, "lang")
+	}
+	return ""
+}
+
+// container collects the segments of the children of n: runs of inline
+// content between block elements, and the attributes of every element.
+// translate is false inside translate="no"; lang is the inherited
+// language.
+func (d *Document) container(n *html.Node, translate bool, lang string) {
+	var run []*html.Node
+	flush := func() {
+		if len(run) > 0 {
+			d.addRun(n, run, lang)
+		}
+		run = nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case !translate:
+			if c.Type == html.ElementNode {
+				d.element(c, translate, lang)
+			}
+		case c.Type == html.TextNode, c.Type == html.CommentNode:
+			run = append(run, c)
+		case c.Type == html.ElementNode && inlineTags[c.DataAtom] && !hasBlock(c):
+			run = append(run, c)
+			d.inlineAttrs(c, lang)
+		case c.Type == html.ElementNode:
+			flush()
+			d.element(c, translate, lang)
+		}
+	}
+	flush()
+}
+
+// element collects the segments of a block element and its content.
+func (d *Document) element(e *html.Node, translate bool, lang string) {
+	if l := attr(e, "lang"); l != "" {
+		lang = l
+	}
+	translate = translatable(e, translate)
+	if translate {
+		d.attrs(e, lang)
+	}
+	if skippedTags[e.DataAtom] {
+		return
+	}
+	d.container(e, translate, lang)
+}
+
+// inlineAttrs collects the attributes of an inline element in a run and of
+// the elements inside it.
+func (d *Document) inlineAttrs(e *html.Node, lang string) {
+	if l := attr(e, "lang"); l != "" {
+		lang = l
+	}
+	if !translatable(e, true) {
+		return
+	}
+	d.attrs(e, lang)
+	for c := e.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			d.inlineAttrs(c, lang)
+		}
+	}
+}
+
+// attrs adds a segment for each translatable attribute of e.
+func (d *Document) attrs(e *html.Node, lang string) {
+	for _, a := range e.Attr {
+		if a.Namespace != "" {
+			continue
+		}
+		ok := translatableAttrs[a.Key]
+		if e.DataAtom == atom.Meta && a.Key == "content" {
+			name := attr(e, "name")
+			if name == "" {
+				name = attr(e, "property")
+			}
+			ok = metaNames[strings.ToLower(name)]
+		}
+		if e.DataAtom == atom.Meta && a.Key != "content" {
+			ok = false
+		}
+		text := strings.Join(strings.Fields(a.Val), " ")
+		if ok && hasLetters(text) {
+			d.Segments = append(d.Segments, Segment{Text: text, Attr: a.Key, Lang: lang, node: e})
+		}
+	}
+}
+
+// addRun adds the segment for a run of inline nodes, the children of
+// parent from run[0] to the last one, if it has any text.
+func (d *Document) addRun(parent *html.Node, run []*html.Node, lang string) {
+	seg := Segment{Lang: lang, node: parent, first: run[0], last: run[len(run)-1]}
+	var b strings.Builder
+	var walk func(n *html.Node)
+	marker := func(n *html.Node, kind partKind) {
+		fmt.Fprintf(&b, "[PH%d]", len(seg.parts))
+		seg.parts = append(seg.parts, part{node: n, kind: kind})
+	}
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.CommentNode:
+			marker(n, partOpaque)
+		case html.ElementNode:
+			if l := attr(n, "lang"); opaqueTags[n.DataAtom] || !translatable(n, true) || (l != "" && !SameLanguage(l, lang)) {
+				marker(n, partOpaque)
+				return
+			}
+			marker(n, partOpen)
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			marker(n, partClose)
+		}
+	}
+	for _, n := range run {
+		walk(n)
+	}
+
+	if seg.first.Type == html.TextNode {
+		seg.lead = seg.first.Data[:len(seg.first.Data)-len(strings.TrimLeftFunc(seg.first.Data, unicode.IsSpace))]
+	}
+	if seg.last.Type == html.TextNode {
+		seg.trail = seg.last.Data[len(strings.TrimRightFunc(seg.last.Data, unicode.IsSpace)):]
+	}
+	seg.Text = strings.Join(strings.Fields(b.String()), " ")
+	if !hasLetters(reMarker.ReplaceAllString(seg.Text, " ")) {
+		return
+	}
+	d.Segments = append(d.Segments, seg)
+}
+
+// Apply replaces segment i with its translation. The markers of an element
+// segment become the original tags again; a translation that lost a marker
+// is rejected and the segment keeps its source.
+func (d *Document) Apply(i int, translation string) error {
+	seg := &d.Segments[i]
+	text := strings.Join(strings.Fields(translation), " ")
+	if text == "" {
+		return fmt.Errorf("empty translation")
+	}
+	if seg.Attr != "" {
+		for j := range seg.node.Attr {
+			if seg.node.Attr[j].Key == seg.Attr && seg.node.Attr[j].Namespace == "" {
+				seg.node.Attr[j].Val = text
+			}
+		}
+		return nil
+	}
+	if missing := placeholder.Validate(text, make([]string, len(seg.parts))); len(missing) > 0 {
+		return fmt.Errorf("translation lost %d of %d tag placeholder(s)", len(missing), len(seg.parts))
+	}
+
+	// Take the run out of the tree, then build its replacement from the
+	// translation, reusing the original element nodes.
+	anchor := seg.last.NextSibling
+	for n := seg.first; n != nil; {
+		next := n.NextSibling
+		seg.node.RemoveChild(n)
+		if n == seg.last {
+			break
+		}
+		n = next
+	}
+
+	out := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	stack := []*html.Node{out}
+	used := map[*html.Node]bool{}
+	appendText := func(s string) {
+		if s != "" {
+			stack[len(stack)-1].AppendChild(&html.Node{Type: html.TextNode, Data: s})
+		}
+	}
+	appendText(seg.lead)
+	pos := 0
+	for _, m := range reMarker.FindAllStringSubmatchIndex(text, -1) {
+		k, _ := strconv.Atoi(text[m[2]:m[3]])
+		if k >= len(seg.parts) {
+			continue
+		}
+		appendText(text[pos:m[0]])
+		pos = m[1]
+		p := seg.parts[k]
+		top := stack[len(stack)-1]
+		switch p.kind {
+		case partOpaque:
+			n := p.node
+			if used[n] {
+				n = cloneTree(n)
+			}
+			detach(n)
+			used[n] = true
+			top.AppendChild(n)
+		case partOpen:
+			n := p.node
+			if used[n] {
+				n = &html.Node{Type: n.Type, Data: n.Data, DataAtom: n.DataAtom, Namespace: n.Namespace, Attr: append([]html.Attribute(nil), n.Attr...)}
+			} else {
+				detach(n)
+				for c := n.FirstChild; c != nil; c = n.FirstChild {
+					n.RemoveChild(c)
+				}
+			}
+			used[n] = true
+			top.AppendChild(n)
+			stack = append(stack, n)
+		case partClose:
+			for j := len(stack) - 1; j > 0; j-- {
+				if stack[j] == p.node {
+					stack = stack[:j]
+					break
+				}
+			}
+		}
+	}
+	appendText(text[pos:])
+	stack = stack[:1]
+	appendText(seg.trail)
+
+	for c := out.FirstChild; c != nil; c = out.FirstChild {
+		out.RemoveChild(c)
+		seg.node.InsertBefore(c, anchor)
+	}
+	return nil
+}
+
+// SetLang sets the lang attribute of the <html> element to lang, adding it
+// if missing, and changes every other lang (or xml:lang) attribute equal
+// to from, by primary language, to lang. A fragment has no <html> element;
+// only its matching lang attributes change.
+func (d *Document) SetLang(from, lang string) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			found := false
+			for i, a := range n.Attr {
+				if a.Key != "lang" && a.Key != "xml:lang" {
+					continue
+				}
+				found = found || a.Key == "lang"
+				if n.DataAtom == atom.Html || (from != "" && SameLanguage(a.Val, from)) {
+					n.Attr[i].Val = lang
+				}
+			}
+			if n.DataAtom == atom.Html && !found {
+				n.Attr = append(n.Attr, html.Attribute{Key: "lang", Val: lang})
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(d.root)
+}
+
+// Render writes the document back as HTML.
+func (d *Document) Render() (string, error) {
+	var b bytes.Buffer
+	if d.fragment {
+		for c := d.root.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(&b, c); err != nil {
+				return "", err
+			}
+		}
+		return b.String(), nil
+	}
+	if err := html.Render(&b, d.root); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// SameLanguage reports whether two language tags share their primary
+// language: "en-US" and "en" do.
+func SameLanguage(a, b string) bool {
+	primary := func(tag string) string {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		return tag
+	}
+	return primary(a) == primary(b)
+}
+
+// translatable applies the translate attribute of e (and the common
+// "notranslate" class) to the inherited setting.
+func translatable(e *html.Node, inherited bool) bool {
+	switch strings.ToLower(attr(e, "translate")) {
+	case "no":
+		return false
+	case "yes":
+		return true
+	}
+	if hasClass(e, "notranslate") {
+		return false
+	}
+	return inherited
+}
+
+// hasBlock reports whether an inline element contains a non-inline
+// element, as in <a><div>...</div></a>; such an element is treated as a
+// block.
+func hasBlock(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (!inlineTags[c.DataAtom] || hasBlock(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key && a.Namespace == "" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLetters(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func detach(n *html.Node) {
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+func cloneTree(n *html.Node) *html.Node {
+	c := &html.Node{Type: n.Type, Data: n.Data, DataAtom: n.DataAtom, Namespace: n.Namespace, Attr: append([]html.Attribute(nil), n.Attr...)}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.AppendChild(cloneTree(child))
+	}
+	return c
+}
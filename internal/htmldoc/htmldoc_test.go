@@ -0,0 +1,147 @@
+package htmldoc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/htmldoc"
+)
+
+const samplePage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>My  Page</title>
+  <meta name="description" content="A page about things">
+  <meta property="og:image" content="cat.png">
+  <style>p { color: red }</style>
+</head>
+<body>
+  <h1>Welcome to <em>our</em> site</h1>
+  <p>Read the <a href="/docs" title="Documentation">docs</a> and run <code>make</code>.</p>
+  <img src="cat.png" alt="A cat">
+  <p translate="no">Keep this</p>
+  <p>The <span class="notranslate">AcmeCloud</span> team says <i lang="fr">bonjour</i>.</p>
+  <div>Loose text<div>Nested block</div></div>
+  <input placeholder="Search here">
+  <pre>code block</pre>
+  <blockquote lang="de"><p>Guten Tag</p></blockquote>
+  <script>var x = "text";</script>
+</body>
+</html>
+`
+
+func parse(t *testing.T, src string) *htmldoc.Document {
+	t.Helper()
+	doc, err := htmldoc.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParse_Segments(t *testing.T) {
+	doc := parse(t, samplePage)
+	want := []struct{ text, attr, lang string }{
+		{"My Page", "", "en"},
+		{"A page about things", "content", "en"},
+		{"Welcome to [PH0]our[PH1] site", "", "en"},
+		{"Documentation", "title", "en"},
+		{"Read the [PH0]docs[PH1] and run [PH2].", "", "en"},
+		{"A cat", "alt", "en"},
+		{"The [PH0] team says [PH1].", "", "en"},
+		{"Loose text", "", "en"},
+		{"Nested block", "", "en"},
+		{"Search here", "placeholder", "en"},
+		{"Guten Tag", "", "de"},
+	}
+	if len(doc.Segments) != len(want) {
+		for _, s := range doc.Segments {
+			t.Logf("%q %q %q", s.Text, s.Attr, s.Lang)
+		}
+		t.Fatalf("got %d segments, want %d", len(doc.Segments), len(want))
+	}
+	for i, w := range want {
+		s := doc.Segments[i]
+		if s.Text != w.text || s.Attr != w.attr || s.Lang != w.lang {
+			t.Errorf("segment %d = (%q, %q, %q), want (%q, %q, %q)", i, s.Text, s.Attr, s.Lang, w.text, w.attr, w.lang)
+		}
+	}
+	if doc.Lang() != "en" {
+		t.Errorf("Lang() = %q", doc.Lang())
+	}
+}
+
+func TestApply_RendersTranslation(t *testing.T) {
+	doc := parse(t, samplePage)
+	for i, s := range doc.Segments {
+		if s.Lang == "de" {
+			continue
+		}
+		if err := doc.Apply(i, strings.ToUpper(s.Text)); err != nil {
+			t.Fatalf("segment %d: %v", i, err)
+		}
+	}
+	doc.SetLang("en", "uk")
+	out, err := doc.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<html lang="uk">`,
+		`<title>MY PAGE</title>`,
+		`content="A PAGE ABOUT THINGS"`,
+		`content="cat.png"`,
+		`<h1>WELCOME TO <em>OUR</em> SITE</h1>`,
+		`<p>READ THE <a href="/docs" title="DOCUMENTATION">DOCS</a> AND RUN <code>make</code>.</p>`,
+		`alt="A CAT"`,
+		`<p translate="no">Keep this</p>`,
+		`<p>THE <span class="notranslate">AcmeCloud</span> TEAM SAYS <i lang="fr">bonjour</i>.</p>`,
+		`<div>LOOSE TEXT<div>NESTED BLOCK</div></div>`,
+		`placeholder="SEARCH HERE"`,
+		`<pre>code block</pre>`,
+		`<blockquote lang="de"><p>Guten Tag</p></blockquote>`,
+		`var x = "text";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s\n%s", want, out)
+		}
+	}
+}
+
+func TestApply_ReorderedAndLostMarkers(t *testing.T) {
+	doc := parse(t, `<p>Click <b>here</b> to <a href="/x">continue</a>.</p>`)
+	seg := doc.Segments[0]
+	if seg.Text != "Click [PH0]here[PH1] to [PH2]continue[PH3]." {
+		t.Fatalf("Text = %q", seg.Text)
+	}
+	if err := doc.Apply(0, "Щоб [PH2]продовжити[PH3], натисніть [PH0]тут[PH1]."); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := doc.Render()
+	if want := `<p>Щоб <a href="/x">продовжити</a>, натисніть <b>тут</b>.</p>`; out != want {
+		t.Errorf("Render = %s, want %s", out, want)
+	}
+
+	doc = parse(t, `<p>Say <em>hi</em> &amp; bye</p>`)
+	if err := doc.Apply(0, "Скажи привіт[PH1] і бувай"); err == nil {
+		t.Error("expected an error for a lost marker")
+	}
+	out, _ = doc.Render()
+	if want := `<p>Say <em>hi</em> &amp; bye</p>`; out != want {
+		t.Errorf("a rejected translation changed the document: %s", out)
+	}
+}
+
+func TestSetLang(t *testing.T) {
+	doc := parse(t, `<html><body><p lang="en-GB">Hi</p><p lang="fr">Salut</p></body></html>`)
+	doc.SetLang("en", "uk")
+	out, _ := doc.Render()
+	for _, want := range []string{`<html lang="uk">`, `<p lang="uk">Hi</p>`, `<p lang="fr">Salut</p>`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s: %s", want, out)
+		}
+	}
+	if !htmldoc.SameLanguage("en-US", "EN") || htmldoc.SameLanguage("en", "uk") {
+		t.Error("SameLanguage compares primary subtags")
+	}
+}
@@ -0,0 +1,337 @@
+// Package i18n reads and writes nested JSON and YAML locale files, as
+// used by i18next, vue-i18n and Rails. Only leaf string values are
+// translated; keys, order and structure are kept. A JSON file is written
+// back byte for byte except for the values that change; a YAML file is
+// re-encoded from its node tree, which keeps key order and comments.
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the syntax of a locale file.
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+// reLocale matches a language code used as the single top-level key of a
+// locale file, e.g. Rails' "en:".
+var reLocale = regexp.MustCompile(`^[a-z]{2,3}(?:[-_][A-Za-z]{2,4})?$`)
+
+// DetectFormat returns the format of a file from its extension, or from
+// its first character when the extension is unknown.
+func DetectFormat(name, src string) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return JSON
+	case ".yml", ".yaml":
+		return YAML
+	}
+	if s := strings.TrimSpace(src); strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") {
+		return JSON
+	}
+	return YAML
+}
+
+// File is a parsed locale file.
+type File struct {
+	format Format
+	src    string
+	root   yaml.Node
+	// Entries lists the leaf strings in document order.
+	Entries []*Entry
+	// rootKey is the single top-level key, when there is one.
+	rootKey *Entry
+	values  map[string]string
+}
+
+// Entry is one leaf string.
+type Entry struct {
+	// Path is the keys leading to the value; array items use their index.
+	Path []string
+	// Text is the value.
+	Text string
+
+	node       *yaml.Node
+	start, end int
+	changed    bool
+}
+
+// Key returns the path of e joined with dots, e.g. "menu.file.open".
+func (e *Entry) Key() string {
+	return strings.Join(e.Path, ".")
+}
+
+// Translatable reports whether the value has text to translate, not only
+// placeholders.
+func (e *Entry) Translatable() bool {
+	return strings.ContainsFunc(reMarker.ReplaceAllString(Protect(e.Text).Text, ""), unicode.IsLetter)
+}
+
+// Parse parses a locale file.
+func Parse(src string, format Format) (*File, error) {
+	f := &File{format: format, src: src}
+	if format == JSON {
+		if err := f.parseJSON(); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return f, nil
+	}
+	if err := yaml.Unmarshal([]byte(src), &f.root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(f.root.Content) > 0 {
+		doc := f.root.Content[0]
+		if doc.Kind == yaml.MappingNode && len(doc.Content) == 2 {
+			f.rootKey = &Entry{Text: doc.Content[0].Value, node: doc.Content[0]}
+		}
+		f.walkYAML(doc, nil)
+	}
+	return f, nil
+}
+
+func (f *File) walkYAML(n *yaml.Node, path []string) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "<<" {
+				continue
+			}
+			f.walkYAML(n.Content[i+1], appendPath(path, n.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			f.walkYAML(c, appendPath(path, strconv.Itoa(i)))
+		}
+	case yaml.ScalarNode:
+		if n.Tag == "!!str" {
+			f.Entries = append(f.Entries, &Entry{Path: path, Text: n.Value, node: n})
+		}
+	}
+}
+
+func appendPath(path []string, key string) []string {
+	return append(append([]string(nil), path...), key)
+}
+
+// parseJSON records the span of every leaf string of the JSON source.
+func (f *File) parseJSON() error {
+	dec := json.NewDecoder(strings.NewReader(f.src))
+	dec.UseNumber()
+
+	type frame struct {
+		object bool
+		key    string
+		index  int
+		// wantKey is true in an object when the next token is a key.
+		wantKey bool
+	}
+	var stack []*frame
+	path := func() []string {
+		var p []string
+		for _, fr := range stack {
+			if fr.object {
+				p = append(p, fr.key)
+			} else {
+				p = append(p, strconv.Itoa(fr.index))
+			}
+		}
+		return p
+	}
+	// value is called after each value, to move its container on.
+	value := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.wantKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		end := int(dec.InputOffset())
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				stack = append(stack, &frame{object: t == '{', wantKey: true})
+			default:
+				stack = stack[:len(stack)-1]
+				value()
+			}
+		case string:
+			start := prev + strings.IndexByte(f.src[prev:end], '"')
+			if n := len(stack); n > 0 && stack[n-1].object && stack[n-1].wantKey {
+				top := stack[n-1]
+				top.key, top.wantKey = t, false
+				if n == 1 && f.rootKey == nil {
+					f.rootKey = &Entry{Text: t, start: start, end: end}
+				} else if n == 1 {
+					// More than one top-level key.
+					f.rootKey.end = -1
+				}
+				continue
+			}
+			f.Entries = append(f.Entries, &Entry{Path: path(), Text: t, start: start, end: end})
+			value()
+		default:
+			value()
+		}
+	}
+	if f.rootKey != nil && f.rootKey.end < 0 {
+		f.rootKey = nil
+	}
+	return nil
+}
+
+// LocaleRoot returns the single top-level key when it is a language code,
+// as in Rails locale files ("en:"), or "".
+func (f *File) LocaleRoot() string {
+	if f.rootKey == nil || !reLocale.MatchString(f.rootKey.Text) {
+		return ""
+	}
+	for _, e := range f.Entries {
+		if len(e.Path) < 2 {
+			return ""
+		}
+	}
+	return f.rootKey.Text
+}
+
+// SetLocaleRoot renames the top-level language key returned by
+// LocaleRoot.
+func (f *File) SetLocaleRoot(lang string) {
+	if f.LocaleRoot() == "" {
+		return
+	}
+	f.rootKey.Text, f.rootKey.changed = lang, true
+	if f.rootKey.node != nil {
+		f.rootKey.node.Value = lang
+	}
+	for _, e := range f.Entries {
+		e.Path[0] = lang
+	}
+	f.values = nil
+}
+
+// Value returns the leaf string at path.
+func (f *File) Value(path []string) (string, bool) {
+	if f.values == nil {
+		f.values = make(map[string]string, len(f.Entries))
+		for _, e := range f.Entries {
+			f.values[strings.Join(e.Path, "\x00")] = e.Text
+		}
+	}
+	v, ok := f.values[strings.Join(path, "\x00")]
+	return v, ok
+}
+
+// Reuse sets entry i to its value in prev, an earlier translation of the
+// file, and reports whether there was one to reuse. A value still equal to
+// the source text is not reused: a string that failed to translate keeps
+// its source text and should be retried.
+func (f *File) Reuse(i int, prev *File) bool {
+	e := f.Entries[i]
+	v, ok := prev.Value(e.Path)
+	if !ok || (v == e.Text && e.Translatable()) {
+		return false
+	}
+	f.Set(i, v)
+	return true
+}
+
+// Set sets the value of entry i.
+func (f *File) Set(i int, value string) {
+	e := f.Entries[i]
+	e.Text, e.changed = value, true
+	if e.node != nil {
+		e.node.Value = value
+	}
+	f.values = nil
+}
+
+// Render returns the file with the changed values.
+func (f *File) Render() (string, error) {
+	if f.format == JSON {
+		return f.renderJSON()
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(f.src))
+	if err := enc.Encode(&f.root); err != nil {
+		return "", fmt.Errorf("failed to write YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to write YAML: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func (f *File) renderJSON() (string, error) {
+	edits := f.Entries
+	if f.rootKey != nil && f.rootKey.changed {
+		edits = append([]*Entry{f.rootKey}, edits...)
+	}
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		if !e.changed {
+			continue
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(e.Text); err != nil {
+			return "", err
+		}
+		b.WriteString(f.src[last:e.start])
+		b.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+		last = e.end
+	}
+	b.WriteString(f.src[last:])
+	return b.String(), nil
+}
+
+// yamlIndent returns the indentation step of a YAML source: the smallest
+// indentation of a line, 2 when there is none.
+func yamlIndent(src string) int {
+	indent := 0
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent == 0 {
+		return 2
+	}
+	return indent
+}
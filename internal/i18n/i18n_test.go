@@ -0,0 +1,184 @@
+package i18n_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/i18n"
+)
+
+const sampleJSON = `{
+  "menu": {
+    "open": "Open",
+    "items": ["First", "Second"],
+    "count": 3
+  },
+  "greeting": "Hello, {{name}} & <b>friends</b>"
+}
+`
+
+func TestParseJSON(t *testing.T) {
+	f, err := i18n.Parse(sampleJSON, i18n.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range f.Entries {
+		got = append(got, e.Key()+"="+e.Text)
+	}
+	want := "menu.open=Open|menu.items.0=First|menu.items.1=Second|greeting=Hello, {{name}} & <b>friends</b>"
+	if strings.Join(got, "|") != want {
+		t.Errorf("entries = %q", got)
+	}
+	if f.LocaleRoot() != "" {
+		t.Errorf("LocaleRoot = %q", f.LocaleRoot())
+	}
+	if v, ok := f.Value([]string{"menu", "items", "1"}); !ok || v != "Second" {
+		t.Errorf("Value = %q, %v", v, ok)
+	}
+
+	out, _ := f.Render()
+	if out != sampleJSON {
+		t.Errorf("Render without changes altered the file:\n%s", out)
+	}
+	f.Set(0, `Відкрити "файл"`)
+	f.Set(3, "Привіт, {{name}} & <b>друзі</b>")
+	out, err = f.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = strings.Replace(sampleJSON, `"Open"`, `"Відкрити \"файл\""`, 1)
+	want = strings.Replace(want, `"Hello, {{name}} & <b>friends</b>"`, `"Привіт, {{name}} & <b>друзі</b>"`, 1)
+	if out != want {
+		t.Errorf("Render =\n%s\nwant\n%s", out, want)
+	}
+}
+
+const sampleYAML = `# Rails locale
+en:
+  greeting: Hello, %{name}!
+  messages:
+    # Inbox
+    one: "You have one message"
+    count: 5
+    enabled: true
+  list:
+    - Apple
+    - Pear
+`
+
+func TestParseYAML_LocaleRoot(t *testing.T) {
+	f, err := i18n.Parse(sampleYAML, i18n.YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range f.Entries {
+		keys = append(keys, e.Key())
+	}
+	if want := "en.greeting en.messages.one en.list.0 en.list.1"; strings.Join(keys, " ") != want {
+		t.Errorf("keys = %q", keys)
+	}
+	if f.LocaleRoot() != "en" {
+		t.Fatalf("LocaleRoot = %q", f.LocaleRoot())
+	}
+	f.SetLocaleRoot("uk")
+	f.Set(0, "Привіт, %{name}!")
+	f.Set(1, "У вас одне повідомлення")
+	out, err := f.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Rails locale
+uk:
+  greeting: Привіт, %{name}!
+  messages:
+    # Inbox
+    one: "У вас одне повідомлення"
+    count: 5
+    enabled: true
+  list:
+    - Apple
+    - Pear
+`
+	if out != want {
+		t.Errorf("Render =\n%s\nwant\n%s", out, want)
+	}
+	if _, ok := f.Value([]string{"uk", "list", "0"}); !ok {
+		t.Error("paths follow the renamed root")
+	}
+}
+
+func TestProtect(t *testing.T) {
+	cases := []struct{ in, text string }{
+		{"Hello, {{name}}!", "Hello, [PH0]!"},
+		{"Hi %{user}, see $t(common.more) or @:common.link", "Hi [PH0], see [PH1] or [PH2]"},
+		{"{count} files of {total, number}", "[PH0] files of [PH1]"},
+		{"You have {count, plural, =0 {no messages} one {# message} other {# messages}}.",
+			"You have [PH0]no messages[PH1][PH2] message[PH3][PH4] messages[PH5]."},
+		{"{n, plural, one {{n, number} day} other {{n, number} days}}", "[PH0][PH1] day[PH2][PH3] days[PH4]"},
+		{"{gender, select, male {He} other {They}} replied", "[PH0]He[PH1]They[PH2] replied"},
+		{"Click <a href=\"/x\">here</a>", "Click [PH0]here[PH1]"},
+	}
+	for _, c := range cases {
+		p := i18n.Protect(c.in)
+		if p.Text != c.text {
+			t.Errorf("Protect(%q).Text = %q, want %q", c.in, p.Text, c.text)
+			continue
+		}
+		if out, err := p.Restore(p.Text); err != nil || out != c.in {
+			t.Errorf("Restore(%q) = %q, %v", c.in, out, err)
+		}
+	}
+
+	p := i18n.Protect("{count, plural, one {# file} other {# files}}")
+	out, err := p.Restore("[PH0][PH1] файл[PH2][PH3] файлів[PH4]")
+	if err != nil || out != "{count, plural, one {# файл} other {# файлів}}" {
+		t.Errorf("Restore = %q, %v", out, err)
+	}
+	if _, err := p.Restore("[PH2][PH3] файлів[PH0][PH1] файл[PH4]"); err == nil {
+		t.Error("reordered ICU syntax should be rejected")
+	}
+	if _, err := p.Restore("[PH0][PH1] файл[PH2] файлів[PH4]"); err == nil {
+		t.Error("a lost marker should be rejected")
+	}
+}
+
+func TestMissingPluralForms(t *testing.T) {
+	p := i18n.Protect("{count, plural, =0 {none} one {# file} other {# files}} in {gender, select, male {his} other {their}} folder")
+	if got := strings.Join(p.MissingPluralForms("uk"), " "); got != "few many" {
+		t.Errorf("uk: missing %q", got)
+	}
+	if got := p.MissingPluralForms("de-AT"); len(got) != 0 {
+		t.Errorf("de: missing %q", got)
+	}
+	if got := p.MissingPluralForms("xx"); len(got) != 0 {
+		t.Errorf("unknown language: missing %q", got)
+	}
+	p = i18n.Protect("{n, plural, one {# день} few {# дні} many {# днів} other {# дня}}")
+	if got := p.MissingPluralForms("ru"); len(got) != 0 {
+		t.Errorf("ru: missing %q", got)
+	}
+}
+
+func TestTranslatable(t *testing.T) {
+	f, _ := i18n.Parse(`{"a": "{{count}}", "b": "%{n} / {m}", "c": "Save"}`, i18n.JSON)
+	for i, want := range []bool{false, false, true} {
+		if got := f.Entries[i].Translatable(); got != want {
+			t.Errorf("entry %d Translatable = %v", i, got)
+		}
+	}
+}
+
+func TestReuse(t *testing.T) {
+	f, _ := i18n.Parse(`{"a": "Save", "b": "Open", "c": "{{count}}", "d": "Close"}`, i18n.JSON)
+	prev, _ := i18n.Parse(`{"a": "Зберегти", "b": "Open", "c": "{{count}}"}`, i18n.JSON)
+	for i, want := range []bool{true, false, true, false} {
+		if got := f.Reuse(i, prev); got != want {
+			t.Errorf("entry %d Reuse = %v", i, got)
+		}
+	}
+	if f.Entries[0].Text != "Зберегти" || f.Entries[1].Text != "Open" {
+		t.Errorf("entries = %q, %q", f.Entries[0].Text, f.Entries[1].Text)
+	}
+}
@@ -0,0 +1,36 @@
+package i18n
+
+import "strings"
+
+// pluralCategories are the CLDR cardinal plural categories each language
+// needs for whole numbers, "other" aside. Languages with only "one" and
+// "other", by far the most common case, are listed under oneOther.
+var pluralCategories = map[string][]string{
+	"ja": {}, "zh": {}, "ko": {}, "vi": {}, "th": {}, "id": {}, "ms": {}, "lo": {}, "my": {},
+	"uk": {"one", "few", "many"}, "ru": {"one", "few", "many"}, "be": {"one", "few", "many"},
+	"pl": {"one", "few", "many"}, "lt": {"one", "few", "many"}, "cs": {"one", "few", "many"},
+	"sk": {"one", "few", "many"},
+	"hr": {"one", "few"}, "sr": {"one", "few"}, "bs": {"one", "few"}, "ro": {"one", "few"},
+	"sl": {"one", "two", "few"}, "he": {"one", "two"}, "lv": {"zero", "one"},
+	"ga": {"one", "two", "few", "many"},
+	"ar": {"zero", "one", "two", "few", "many"}, "cy": {"zero", "one", "two", "few", "many"},
+}
+
+var oneOther = []string{"en", "de", "nl", "sv", "da", "no", "nb", "nn", "fi", "et", "hu", "el",
+	"it", "es", "ca", "pt", "fr", "bg", "tr", "eo", "af", "sq", "hi", "bn", "ka", "az", "kk", "hy"}
+
+// PluralCategories returns the CLDR plural categories lang uses for whole
+// numbers besides "other", e.g. one, few and many for "uk" or "pt-BR".
+// ok is false when the language's rules are unknown.
+func PluralCategories(lang string) (categories []string, ok bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(lang, "_", "-")), "-")
+	if c, ok := pluralCategories[primary]; ok {
+		return c, true
+	}
+	for _, l := range oneOther {
+		if l == primary {
+			return []string{"one"}, true
+		}
+	}
+	return nil, false
+}
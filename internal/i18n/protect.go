@@ -0,0 +1,189 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+var (
+	reMarker = regexp.MustCompile(`\[PH(\d+)\]`)
+
+	// reVariable matches the interpolations that are not ICU arguments:
+	// i18next {{name}} and $t(key), Rails %{name}, printf %s and %1$d,
+	// and vue-i18n linked messages @:key and @.lower:key.
+	reVariable = regexp.MustCompile(`\{\{[^{}]*\}\}|\$t\([^()]*\)|%\{[^{}]*\}|%(?:\d+\$)?[-+ #0]*\d*(?:\.\d+)?[sdifuxX]|@(?:\.[a-z]+)?:(?:\([^()]*\)|[\w.-]+)`)
+)
+
+// Protected is a value with its placeholders, markup and ICU syntax
+// replaced by [PHn] markers.
+type Protected struct {
+	// Text is the value to translate.
+	Text    string
+	markers []string
+	// icu marks the markers that are ICU plural or select syntax; they
+	// must stay in order for the message to stay valid.
+	icu []bool
+	// plurals lists the branch selectors of every ICU plural message.
+	plurals [][]string
+}
+
+// Protect replaces the parts of a locale value that must not be
+// translated with [PHn] markers: HTML tags, interpolation variables,
+// ICU arguments such as {name} or {n, number}, and the syntax of ICU
+// plural and select messages, whose branch texts stay translatable:
+//
+//	{count, plural, one {# file} other {# files}}
+//	→ [PH0][PH1] file[PH2][PH3] files[PH4]
+func Protect(text string) *Protected {
+	text, markers := placeholder.Protect(text)
+	p := &Protected{markers: markers, icu: make([]bool, len(markers))}
+	var b strings.Builder
+	p.message(&b, text, false)
+	p.Text = b.String()
+	return p
+}
+
+// add records raw as a marker and writes the marker to b.
+func (p *Protected) add(b *strings.Builder, raw string, icu bool) {
+	// Adjacent syntax pieces share one marker.
+	if icu && len(p.markers) > 0 && p.icu[len(p.markers)-1] && strings.HasSuffix(b.String(), fmt.Sprintf("[PH%d]", len(p.markers)-1)) {
+		p.markers[len(p.markers)-1] += raw
+		return
+	}
+	p.markers = append(p.markers, raw)
+	p.icu = append(p.icu, icu)
+	fmt.Fprintf(b, "[PH%d]", len(p.markers)-1)
+}
+
+// message protects an ICU message; inPlural is true inside a plural
+// branch, where # stands for the count.
+func (p *Protected) message(b *strings.Builder, s string, inPlural bool) {
+	for i := 0; i < len(s); {
+		if loc := reVariable.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			p.add(b, s[i:i+loc[1]], false)
+			i += loc[1]
+			continue
+		}
+		switch {
+		case s[i] == '#' && inPlural:
+			// The count may move within its branch.
+			p.add(b, "#", false)
+			i++
+		case s[i] == '{':
+			end := matchBrace(s, i)
+			if end < 0 {
+				b.WriteByte(s[i])
+				i++
+				continue
+			}
+			p.argument(b, s[i:end+1], inPlural)
+			i = end + 1
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+}
+
+// argument protects an ICU argument {…}: a plural or select message has
+// its branch texts kept translatable, anything else is one marker.
+func (p *Protected) argument(b *strings.Builder, arg string, inPlural bool) {
+	parts := strings.SplitN(arg[1:len(arg)-1], ",", 3)
+	kind := ""
+	if len(parts) == 3 {
+		kind = strings.TrimSpace(parts[1])
+	}
+	if kind != "plural" && kind != "select" && kind != "selectordinal" {
+		p.add(b, arg, false)
+		return
+	}
+	// The syntax runs from the start, or the end of the previous branch,
+	// to the brace opening the next branch; it ends with the selector.
+	var selectors []string
+	syntax := 0
+	for i := len(parts[0]) + len(parts[1]) + 3; ; {
+		open := strings.IndexByte(arg[i:], '{')
+		if open < 0 {
+			break
+		}
+		open += i
+		close := matchBrace(arg, open)
+		if close < 0 {
+			break
+		}
+		if f := strings.Fields(strings.Trim(arg[syntax:open], "{},")); len(f) > 0 {
+			selectors = append(selectors, strings.Trim(f[len(f)-1], ","))
+		}
+		p.add(b, arg[syntax:open+1], true)
+		p.message(b, arg[open+1:close], inPlural || kind != "select")
+		syntax, i = close, close+1
+	}
+	p.add(b, arg[syntax:], true)
+	if kind == "plural" {
+		p.plurals = append(p.plurals, selectors)
+	}
+}
+
+// MissingPluralForms returns the plural categories of lang that an ICU
+// plural message of the value has no branch for, e.g. few and many when
+// an English message is translated to Ukrainian. The ICU syntax is kept
+// as in the source, so such a translation uses its "other" branch where
+// the language needs another form. Unknown languages report nothing.
+func (p *Protected) MissingPluralForms(lang string) []string {
+	needed, ok := PluralCategories(lang)
+	if !ok {
+		return nil
+	}
+	var missing []string
+	for _, selectors := range p.plurals {
+		for _, c := range needed {
+			if !slices.Contains(selectors, c) && !slices.Contains(missing, c) {
+				missing = append(missing, c)
+			}
+		}
+	}
+	return missing
+}
+
+// matchBrace returns the index of the brace closing the one at open, or
+// -1.
+func matchBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Restore puts the protected parts back into a translation of Text. It
+// fails when a marker is lost or the ICU syntax markers are out of
+// order.
+func (p *Protected) Restore(translation string) (string, error) {
+	if missing := placeholder.Validate(translation, p.markers); len(missing) > 0 {
+		return "", fmt.Errorf("translation lost %d of %d placeholder(s)", len(missing), len(p.markers))
+	}
+	last := -1
+	for _, m := range reMarker.FindAllStringSubmatch(translation, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if n >= len(p.icu) || !p.icu[n] {
+			continue
+		}
+		if n <= last {
+			return "", fmt.Errorf("translation reordered the ICU message syntax")
+		}
+		last = n
+	}
+	return placeholder.Restore(translation, p.markers), nil
+}
@@ -0,0 +1,754 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+// extensions are the gomarkdown extensions documents are parsed with.
+const extensions = parser.CommonExtensions | parser.Attributes | parser.Footnotes
+
+var (
+	reFence       = regexp.MustCompile("^(`{3,}|~{3,})")
+	reATXHeading  = regexp.MustCompile(`^#{1,6}([ \t]+|$)`)
+	reATXClosing  = regexp.MustCompile(`([ \t]+#+)?[ \t]*$`)
+	reHeadingAttr = regexp.MustCompile(`[ \t]*\{[^{}]*\}[ \t]*$`)
+	reListMarker  = regexp.MustCompile(`^([-*+]|\d{1,9}[.)])([ \t]+|$)`)
+	reTaskBox     = regexp.MustCompile(`^\[[ xX]\][ \t]+`)
+	reFootnoteDef = regexp.MustCompile(`^\[\^[^\]]+\]:[ \t]*`)
+	reRefDef      = regexp.MustCompile(`^\[[^\]]+\]:`)
+	reAttrLine    = regexp.MustCompile(`^\{[^{}]*\}[ \t]*$`)
+	reDefinition  = regexp.MustCompile(`^:[ \t]+`)
+	reDelimRow    = regexp.MustCompile(`^\|?([ \t]*:?-+:?[ \t]*\|)*[ \t]*:?-+:?[ \t]*\|?[ \t]*$`)
+	reSingleTag   = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(\s[^>]*)?/?>[ \t]*$`)
+	reRawHTML     = regexp.MustCompile(`^<(?i:(script|pre|style|textarea))(\s|>|$)`)
+	reBlockTag    = regexp.MustCompile(`^</?([A-Za-z][A-Za-z0-9]*)(\s|/?>|$)`)
+	reEntityTail  = regexp.MustCompile(`^#?[0-9A-Za-z]+;$`)
+	reMarker      = regexp.MustCompile(`\[PH\d+\]`)
+)
+
+// blockTags are the HTML tags that start an HTML block even in the middle
+// of other text, as in CommonMark.
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "base": true, "basefont": true,
+	"blockquote": true, "body": true, "caption": true, "center": true, "col": true,
+	"colgroup": true, "dd": true, "details": true, "dialog": true, "dir": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "frame": true, "frameset": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"head": true, "header": true, "hr": true, "html": true, "iframe": true,
+	"legend": true, "li": true, "link": true, "main": true, "menu": true,
+	"menuitem": true, "nav": true, "noframes": true, "ol": true, "optgroup": true,
+	"option": true, "p": true, "param": true, "search": true, "section": true,
+	"summary": true, "table": true, "tbody": true, "td": true, "tfoot": true,
+	"th": true, "thead": true, "title": true, "tr": true, "track": true, "ul": true,
+}
+
+// Document is a Markdown source split into the spans a translator may
+// replace: the inline content of headings, paragraphs, list items and
+// table cells. Front matter, code blocks, HTML blocks, reference
+// definitions and all block syntax stay outside the segments, so Render
+// reproduces the source byte for byte around the translated spans.
+type Document struct {
+	src string
+	// Segments lists the translatable spans in document order.
+	Segments []Segment
+}
+
+// Segment is the inline content of one block.
+type Segment struct {
+	// Text is the content to translate. Inline markup — emphasis markers,
+	// code spans, link and image targets, inline HTML, autolinks, hard
+	// line breaks — is replaced by [PHn] markers; soft line breaks become
+	// spaces.
+	Text string
+	// Markup holds the originals of the markers, by marker number.
+	Markup []string
+	// InTable is true for a table cell, whose translation must not contain
+	// an unescaped "|".
+	InTable bool
+
+	start, end int
+}
+
+// PlainText returns Text without the markers, e.g. to show the segment to
+// a model as context.
+func (s Segment) PlainText() string {
+	return strings.Join(strings.Fields(reMarker.ReplaceAllString(s.Text, " ")), " ")
+}
+
+// Restore turns the translation of Text back into Markdown: it puts the
+// markup back in place of the markers and joins the lines, since a
+// segment ends at the next line break. It fails when the translation lost
+// a marker, as the markup around it would then be unbalanced.
+func (s Segment) Restore(translation string) (string, error) {
+	text := strings.Join(strings.Fields(translation), " ")
+	if missing := placeholder.Validate(text, s.Markup); len(missing) > 0 {
+		return "", fmt.Errorf("translation lost %d of %d markup placeholder(s)", len(missing), len(s.Markup))
+	}
+	if s.InTable {
+		text = escapePipes(text)
+	}
+	return placeholder.Restore(text, s.Markup), nil
+}
+
+// Parse splits src into segments. gomarkdown parses the inline content of
+// each block, with the reference and footnote definitions of the whole
+// document, to tell the text from the markup.
+func Parse(src string) *Document {
+	p := parser.NewWithExtensions(extensions)
+	p.Parse([]byte(src))
+	s := &scanner{src: src, doc: &Document{src: src}, p: p, listIndent: -1}
+	s.run()
+	return s.doc
+}
+
+// Render returns the source with each segment replaced by the Markdown at
+// the same index of translated. An empty string keeps the segment's
+// source.
+func (d *Document) Render(translated []string) string {
+	var b strings.Builder
+	last := 0
+	for i, seg := range d.Segments {
+		if i >= len(translated) || translated[i] == "" {
+			continue
+		}
+		b.WriteString(d.src[last:seg.start])
+		b.WriteString(translated[i])
+		last = seg.end
+	}
+	b.WriteString(d.src[last:])
+	return b.String()
+}
+
+// span is a byte range of the source.
+type span struct{ start, end int }
+
+// scanner walks the source line by line, tracking just enough block
+// structure to know which lines hold translatable inline content.
+type scanner struct {
+	src string
+	doc *Document
+	p   *parser.Parser
+
+	// para holds the content of each line of the open paragraph.
+	para      []span
+	paraDepth int
+
+	fenceChar byte
+	fenceLen  int
+	// htmlEnd ends the open HTML block: "" at a blank line, otherwise a
+	// closing string such as "-->".
+	htmlEnd string
+	inHTML  bool
+	inTable bool
+	inFence bool
+	blank   bool
+	// listIndent is the content column of the innermost list item, -1
+	// outside lists.
+	listIndent int
+}
+
+func (s *scanner) run() {
+	start := frontMatterEnd(s.src)
+	for start < len(s.src) {
+		end := strings.IndexByte(s.src[start:], '\n')
+		next := len(s.src)
+		if end < 0 {
+			end = len(s.src)
+		} else {
+			end += start
+			next = end + 1
+		}
+		lineEnd := end
+		if lineEnd > start && s.src[lineEnd-1] == '\r' {
+			lineEnd--
+		}
+		s.line(start, lineEnd)
+		start = next
+	}
+	s.flush()
+}
+
+// frontMatterEnd returns the offset after the YAML ("---") or TOML ("+++")
+// front matter at the start of src, or 0 when there is none.
+func frontMatterEnd(src string) int {
+	var open string
+	switch {
+	case strings.HasPrefix(src, "---\n"), strings.HasPrefix(src, "---\r\n"):
+		open = "---"
+	case strings.HasPrefix(src, "+++\n"), strings.HasPrefix(src, "+++\r\n"):
+		open = "+++"
+	default:
+		return 0
+	}
+	pos := strings.IndexByte(src, '\n') + 1
+	for pos < len(src) {
+		end := strings.IndexByte(src[pos:], '\n')
+		next := len(src)
+		if end >= 0 {
+			next = pos + end + 1
+		} else {
+			end = len(src) - pos
+		}
+		line := strings.TrimRight(src[pos:pos+end], " \t\r")
+		if line == open || (open == "---" && line == "...") {
+			return next
+		}
+		pos = next
+	}
+	return 0
+}
+
+// line processes the source line src[start:end].
+func (s *scanner) line(start, end int) {
+	text := s.src[start:end]
+	if s.inFence {
+		rest := strings.TrimLeft(stripQuotes(text), " \t")
+		if strings.HasPrefix(rest, strings.Repeat(string(s.fenceChar), s.fenceLen)) && strings.Trim(rest, string(s.fenceChar)+" \t") == "" {
+			s.inFence = false
+		}
+		return
+	}
+	if s.inHTML {
+		switch {
+		case s.htmlEnd == "" && strings.TrimSpace(text) == "":
+			s.inHTML = false
+			s.blank = true
+		case s.htmlEnd != "" && strings.Contains(strings.ToLower(text), s.htmlEnd):
+			s.inHTML = false
+		}
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		s.flush()
+		s.inTable = false
+		s.blank = true
+		return
+	}
+
+	// Container prefixes: block quote markers, then list item markers.
+	pos := start
+	depth := 0
+	for {
+		i := pos
+		for n := 0; n < 3 && i < end && s.src[i] == ' '; n++ {
+			i++
+		}
+		if i >= end || s.src[i] != '>' {
+			break
+		}
+		depth++
+		pos = i + 1
+		if pos < end && (s.src[pos] == ' ' || s.src[pos] == '\t') {
+			pos++
+		}
+	}
+	indent, width := indentation(s.src[pos:end])
+	pos += width
+	rest := s.src[pos:end]
+
+	if s.open() && depth > s.paraDepth {
+		s.flush()
+	}
+	if s.blank && s.listIndent >= 0 && indent < s.listIndent && !reListMarker.MatchString(rest) {
+		s.listIndent = -1
+	}
+	wasBlank := s.blank
+	s.blank = false
+
+	// Indented code: only where a paragraph cannot continue.
+	codeIndent := 4
+	if s.listIndent >= 0 && indent >= s.listIndent {
+		codeIndent = s.listIndent + 4
+	}
+	if !s.open() && !s.inTable && indent >= codeIndent && (wasBlank || s.listIndent < 0) {
+		return
+	}
+
+	if s.inTable {
+		if strings.Contains(rest, "|") {
+			s.tableRow(pos, end)
+			return
+		}
+		s.inTable = false
+	}
+
+	// Table: a one-line paragraph with pipes followed by a delimiter row.
+	if len(s.para) == 1 && strings.Contains(rest, "|") && reDelimRow.MatchString(rest) &&
+		strings.Contains(s.src[s.para[0].start:s.para[0].end], "|") {
+		header := s.para[0]
+		s.para = nil
+		s.tableRow(header.start, header.end)
+		s.inTable = true
+		return
+	}
+	if s.open() && isSetextUnderline(rest) {
+		s.flush()
+		return
+	}
+	if isThematicBreak(rest) {
+		s.flush()
+		return
+	}
+
+prefixes:
+	for {
+		switch m := reListMarker.FindString(rest); {
+		case m != "":
+			s.flush()
+			markerWidth := len(strings.TrimRight(m, " \t"))
+			spaces := len(m) - markerWidth
+			if spaces > 4 {
+				spaces = 1
+			}
+			s.listIndent = indent + markerWidth + spaces
+			pos += len(m)
+			if box := reTaskBox.FindString(s.src[pos:end]); box != "" {
+				pos += len(box)
+			}
+		case !s.open() && reFootnoteDef.MatchString(rest):
+			pos += len(reFootnoteDef.FindString(rest))
+		case reDefinition.MatchString(rest):
+			s.flush()
+			pos += len(reDefinition.FindString(rest))
+		default:
+			break prefixes
+		}
+		_, w := indentation(s.src[pos:end])
+		pos += w
+		rest = s.src[pos:end]
+		if rest == "" || isThematicBreak(rest) {
+			return
+		}
+	}
+
+	switch {
+	case reFence.MatchString(rest):
+		fence := reFence.FindString(rest)
+		if fence[0] == '`' && strings.Contains(rest[len(fence):], "`") {
+			break
+		}
+		s.flush()
+		s.inFence = true
+		s.fenceChar = fence[0]
+		s.fenceLen = len(fence)
+		return
+	case reATXHeading.MatchString(rest):
+		s.flush()
+		content := strings.TrimLeft(rest, "#")
+		content = reHeadingAttr.ReplaceAllString(content, "")
+		content = reATXClosing.ReplaceAllString(content, "")
+		lead := len(rest) - len(strings.TrimLeft(rest, "#"))
+		lead += len(content) - len(strings.TrimLeft(content, " \t"))
+		content = strings.TrimLeft(content, " \t")
+		if content != "" {
+			s.segment([]span{{pos + lead, pos + lead + len(content)}}, false)
+		}
+		return
+	case strings.HasPrefix(rest, "<") && s.htmlBlock(rest):
+		s.flush()
+		return
+	case !s.open() && (reRefDef.MatchString(rest) || reAttrLine.MatchString(rest)):
+		return
+	}
+
+	if !s.open() {
+		s.paraDepth = depth
+	}
+	s.para = append(s.para, span{pos, end})
+}
+
+// htmlBlock reports whether rest starts an HTML block and, if so, opens
+// it. A block that closes on its first line is not left open.
+func (s *scanner) htmlBlock(rest string) bool {
+	lower := strings.ToLower(rest)
+	var endMark string
+	switch {
+	case strings.HasPrefix(rest, "<!--"):
+		endMark = "-->"
+	case strings.HasPrefix(rest, "<?"):
+		endMark = "?>"
+	case strings.HasPrefix(rest, "<![CDATA["):
+		endMark = "]]>"
+	case strings.HasPrefix(rest, "<!") && len(rest) > 2 && unicode.IsLetter(rune(rest[2])):
+		endMark = ">"
+	case reRawHTML.MatchString(rest):
+		endMark = "</" + strings.ToLower(reRawHTML.FindStringSubmatch(rest)[1]) + ">"
+	default:
+		m := reBlockTag.FindStringSubmatch(rest)
+		if m != nil && blockTags[strings.ToLower(m[1])] {
+			break
+		}
+		if s.open() || !reSingleTag.MatchString(rest) {
+			return false
+		}
+	}
+	s.inHTML = true
+	s.htmlEnd = endMark
+	if endMark != "" && strings.Contains(lower[1:], endMark) {
+		s.inHTML = false
+	}
+	return true
+}
+
+// tableRow adds a segment for each cell of the table row src[start:end].
+func (s *scanner) tableRow(start, end int) {
+	row := s.src[start:end]
+	i := len(row) - len(strings.TrimLeft(row, " \t"))
+	j := len(strings.TrimRight(row, " \t"))
+	if i < j && row[i] == '|' {
+		i++
+	}
+	if j > i && row[j-1] == '|' && (j < 2 || row[j-2] != '\\') {
+		j--
+	}
+	cellStart := i
+	inCode := false
+	for k := i; k <= j; k++ {
+		if k < j {
+			switch {
+			case row[k] == '\\':
+				k++
+				continue
+			case row[k] == '`':
+				inCode = !inCode
+				continue
+			case row[k] != '|' || inCode:
+				continue
+			}
+		}
+		cell := row[cellStart:k]
+		lead := len(cell) - len(strings.TrimLeft(cell, " \t"))
+		cell = strings.TrimSpace(cell)
+		if cell != "" {
+			s.segment([]span{{start + cellStart + lead, start + cellStart + lead + len(cell)}}, true)
+		}
+		cellStart = k + 1
+	}
+}
+
+func (s *scanner) open() bool {
+	return len(s.para) > 0
+}
+
+func (s *scanner) flush() {
+	if len(s.para) > 0 {
+		s.segment(s.para, false)
+	}
+	s.para = nil
+}
+
+// segment parses the inline content of a block, given as the content span
+// of each of its lines, and adds it as a segment if it has any text.
+func (s *scanner) segment(lines []span, inTable bool) {
+	var b strings.Builder
+	offsets := make([]int, len(lines))
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		offsets[i] = b.Len()
+		b.WriteString(s.src[l.start:l.end])
+	}
+	content := b.String()
+	// toSource maps an offset of content to the source.
+	toSource := func(off int) int {
+		i := len(offsets) - 1
+		for i > 0 && offsets[i] > off {
+			i--
+		}
+		return lines[i].start + off - offsets[i]
+	}
+
+	first := len(content) - len(strings.TrimLeft(content, " \t\n"))
+	last := len(strings.TrimRight(content, " \t\n"))
+	if first >= last {
+		return
+	}
+
+	para := &ast.Paragraph{}
+	s.p.Inline(para, []byte(content))
+	w := &inlineWalker{content: content, ok: true}
+	w.walk(para)
+	runs := mergeRuns(content, w.runs)
+	if !w.ok {
+		// The text could not be matched to the source: send the content
+		// as is rather than lose the block.
+		runs = []span{{first, last}}
+	}
+
+	seg := Segment{InTable: inTable, start: toSource(first), end: toSource(last)}
+	var text strings.Builder
+	hasLetters := false
+	pos := first
+	addMarkup := func(from, to int) {
+		if from < to {
+			text.WriteString(fmt.Sprintf("[PH%d]", len(seg.Markup)))
+			seg.Markup = append(seg.Markup, s.src[toSource(from):toSource(to)])
+		}
+	}
+	for _, r := range runs {
+		r.start = max(r.start, pos)
+		r.end = min(r.end, last)
+		if r.start >= r.end {
+			continue
+		}
+		addMarkup(pos, r.start)
+		run := softBreaks(content[r.start:r.end])
+		text.WriteString(run)
+		hasLetters = hasLetters || strings.IndexFunc(run, unicode.IsLetter) >= 0
+		pos = r.end
+	}
+	addMarkup(pos, last)
+	if !hasLetters {
+		return
+	}
+	seg.Text = text.String()
+	s.doc.Segments = append(s.doc.Segments, seg)
+}
+
+// inlineWalker finds the text of an inline AST in the content it was
+// parsed from. gomarkdown keeps no source offsets, so each node is looked
+// up from the end of the previous one.
+type inlineWalker struct {
+	content string
+	cur     int
+	runs    []span
+	ok      bool
+}
+
+func (w *inlineWalker) walk(node ast.Node) {
+	if !w.ok {
+		return
+	}
+	switch n := node.(type) {
+	case *ast.Text:
+		if len(n.Literal) == 0 {
+			return
+		}
+		start := w.skip(string(n.Literal))
+		if start >= 0 {
+			w.runs = append(w.runs, span{start, w.cur})
+		}
+		return
+	case *ast.Code:
+		w.skip(string(n.Literal))
+		return
+	case *ast.HTMLSpan:
+		w.skip(string(n.Literal))
+		return
+	case *ast.Math:
+		w.skip(string(n.Literal))
+		return
+	case *ast.Link:
+		if n.NoteID > 0 {
+			w.skip("[^")
+			w.linkTail()
+			return
+		}
+		if isAutolink(n) {
+			w.skip(string(n.Children[0].AsLeaf().Literal))
+			if w.cur < len(w.content) && w.content[w.cur] == '>' {
+				w.cur++
+			}
+			return
+		}
+		for _, child := range n.Children {
+			w.walk(child)
+		}
+		w.linkTail()
+		return
+	case *ast.Image:
+		for _, child := range n.Children {
+			w.walk(child)
+		}
+		w.linkTail()
+		return
+	}
+	for _, child := range node.GetChildren() {
+		w.walk(child)
+	}
+}
+
+// skip moves past the next occurrence of s and returns where it starts,
+// or -1 when it is not found.
+func (w *inlineWalker) skip(s string) int {
+	i := strings.Index(w.content[w.cur:], s)
+	if i < 0 {
+		w.ok = false
+		return -1
+	}
+	start := w.cur + i
+	w.cur = start + len(s)
+	return start
+}
+
+// linkTail moves past the "]" closing a link's text and the destination
+// or reference label after it: "](url "title")", "][label]" or "[]".
+func (w *inlineWalker) linkTail() {
+	c := w.content
+	i := w.cur
+	for i < len(c) && c[i] != ']' {
+		if c[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	if i >= len(c) {
+		w.ok = false
+		return
+	}
+	i++
+	switch {
+	case i < len(c) && c[i] == '(':
+		depth, quote := 0, byte(0)
+		for ; i < len(c); i++ {
+			switch ch := c[i]; {
+			case ch == '\\':
+				i++
+			case quote != 0:
+				if ch == quote {
+					quote = 0
+				}
+			case (ch == '"' || ch == '\'') && (c[i-1] == ' ' || c[i-1] == '\t'):
+				quote = ch
+			case ch == '(':
+				depth++
+			case ch == ')':
+				depth--
+			}
+			if depth == 0 {
+				i++
+				break
+			}
+		}
+	case i < len(c) && c[i] == '[':
+		if j := strings.IndexByte(c[i:], ']'); j >= 0 {
+			i += j + 1
+		}
+	}
+	w.cur = i
+}
+
+// isAutolink reports a link whose text is its URL: <https://...>, a bare
+// URL or an e-mail address. Its text is not translated.
+func isAutolink(n *ast.Link) bool {
+	if len(n.Children) != 1 {
+		return false
+	}
+	text, ok := n.Children[0].(*ast.Text)
+	if !ok {
+		return false
+	}
+	lit := string(text.Literal)
+	dest := string(n.Destination)
+	return lit == dest || "mailto:"+lit == dest
+}
+
+// mergeRuns joins text runs separated only by what gomarkdown decodes
+// into the text — a backslash escape or the tail of an entity — so the
+// model sees "a \* b" and "&amp;" as written.
+func mergeRuns(content string, runs []span) []span {
+	var out []span
+	for _, r := range runs {
+		if n := len(out); n > 0 {
+			prev := &out[n-1]
+			gap := content[prev.end:r.start]
+			if gap == "" || gap == "\\" || (strings.HasSuffix(content[prev.start:prev.end], "&") && reEntityTail.MatchString(gap)) {
+				prev.end = r.end
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// softBreaks turns the line breaks of a text run, with the indentation of
+// the next line, into single spaces.
+func softBreaks(s string) string {
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		if i > 0 {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+		if i < len(lines)-1 {
+			lines[i] = strings.TrimRight(lines[i], " \t")
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// indentation returns the column width of the leading whitespace of s,
+// with tabs stopping every 4 columns, and its length in bytes.
+func indentation(s string) (columns, width int) {
+	for width < len(s) {
+		switch s[width] {
+		case ' ':
+			columns++
+		case '\t':
+			columns += 4 - columns%4
+		default:
+			return columns, width
+		}
+		width++
+	}
+	return columns, width
+}
+
+// stripQuotes removes the block quote markers at the start of a line.
+func stripQuotes(line string) string {
+	for {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, ">") {
+			return line
+		}
+		line = strings.TrimPrefix(trimmed[1:], " ")
+	}
+}
+
+func isThematicBreak(s string) bool {
+	s = strings.TrimRight(s, " \t")
+	if s == "" || !strings.ContainsAny(s[:1], "-*_") {
+		return false
+	}
+	n := 0
+	for _, r := range s {
+		switch {
+		case r == rune(s[0]):
+			n++
+		case r != ' ' && r != '\t':
+			return false
+		}
+	}
+	return n >= 3
+}
+
+func isSetextUnderline(s string) bool {
+	s = strings.TrimRight(s, " \t")
+	return s != "" && (strings.Trim(s, "=") == "" || strings.Trim(s, "-") == "")
+}
+
+// escapePipes escapes the "|" characters of a table cell translation that
+// are not escaped already.
+func escapePipes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '|' && (i == 0 || s[i-1] != '\\') {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
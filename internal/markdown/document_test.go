@@ -0,0 +1,169 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/markdown"
+)
+
+const sampleDoc = `---
+title: Hello world
+---
+
+# Getting *started* {#start}
+
+Read the [guide](http://x.com/a_(b) "Guide") and run ` + "`make`" + `.
+See ![the logo](logo.png) and [the API][api].
+
+- First item
+  continued
+- Item **two**
+
+> Quoted text
+
+| Name | Value |
+|------|-------|
+| Size | ` + "`42`" + ` |
+
+` + "```go\nfmt.Println(\"keep\")\n```" + `
+
+    indented code
+
+<div>
+HTML block
+</div>
+
+[api]: https://example.com/api "API"
+`
+
+func texts(doc *markdown.Document) []string {
+	var out []string
+	for _, s := range doc.Segments {
+		out = append(out, s.Text)
+	}
+	return out
+}
+
+func TestParse_Segments(t *testing.T) {
+	doc := markdown.Parse(sampleDoc)
+	want := []string{
+		"Getting [PH0]started[PH1]",
+		"Read the [PH0]guide[PH1] and run [PH2]. See [PH3]the logo[PH4] and [PH5]the API[PH6].",
+		"First item continued",
+		"Item [PH0]two[PH1]",
+		"Quoted text",
+		"Name",
+		"Value",
+		"Size",
+	}
+	got := texts(doc)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("segments:\n%q\nwant:\n%q", got, want)
+	}
+
+	markup := doc.Segments[1].Markup
+	wantMarkup := []string{"[", `](http://x.com/a_(b) "Guide")`, "`make`", "![", "](logo.png)", "[", "][api]"}
+	if strings.Join(markup, "|") != strings.Join(wantMarkup, "|") {
+		t.Errorf("markup = %q, want %q", markup, wantMarkup)
+	}
+}
+
+func TestParse_InlineEdgeCases(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"escapes and entities", `Use \*stars\* &amp; more`, []string{`Use \*stars\* &amp; more`}},
+		{"autolink", "Visit <https://example.com> now", []string{"Visit [PH0] now"}},
+		{"hard break", "one  \ntwo", []string{"one[PH0]two"}},
+		{"closing hashes", "## Title ##", []string{"Title"}},
+		{"setext heading", "Title\n=====\n\nBody", []string{"Title", "Body"}},
+		{"only code", "`code` 42", nil},
+		{"task list", "- [x] Done task", []string{"Done task"}},
+		{"quoted lines", "> one\n> two", []string{"one two"}},
+		{"front matter only", "---\ntitle: x\n---\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := texts(markdown.Parse(tt.src))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("segments = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_KeepsSourceWithoutTranslations(t *testing.T) {
+	for _, src := range []string{sampleDoc, strings.ReplaceAll(sampleDoc, "\n", "\r\n"), "no trailing newline"} {
+		if got := markdown.Parse(src).Render(nil); got != src {
+			t.Errorf("Render(nil) changed the document:\n%q", got)
+		}
+	}
+}
+
+func TestRender_ReplacesOnlySegments(t *testing.T) {
+	doc := markdown.Parse(sampleDoc)
+	translated := make([]string, len(doc.Segments))
+	for i, s := range doc.Segments {
+		out, err := s.Restore(strings.ToUpper(s.Text))
+		if err != nil {
+			t.Fatalf("segment %d: %v", i, err)
+		}
+		translated[i] = out
+	}
+
+	want := `---
+title: Hello world
+---
+
+# GETTING *STARTED* {#start}
+
+READ THE [GUIDE](http://x.com/a_(b) "Guide") AND RUN ` + "`make`" + `. SEE ![THE LOGO](logo.png) AND [THE API][api].
+
+- FIRST ITEM CONTINUED
+- ITEM **TWO**
+
+> QUOTED TEXT
+
+| NAME | VALUE |
+|------|-------|
+| SIZE | ` + "`42`" + ` |
+
+` + "```go\nfmt.Println(\"keep\")\n```" + `
+
+    indented code
+
+<div>
+HTML block
+</div>
+
+[api]: https://example.com/api "API"
+`
+	if got := doc.Render(translated); got != want {
+		t.Errorf("Render:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSegment_Restore(t *testing.T) {
+	seg := markdown.Parse("Say *hi*").Segments[0]
+	if _, err := seg.Restore("Скажи привіт[PH1]"); err == nil {
+		t.Error("expected an error for a lost marker")
+	}
+	got, err := seg.Restore("Скажи\n[PH0]привіт[PH1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Скажи *привіт*" {
+		t.Errorf("Restore = %q", got)
+	}
+
+	cell := markdown.Parse("| a |\n|---|\n| either or |").Segments[1]
+	if got, _ := cell.Restore("this | that"); got != `this \| that` {
+		t.Errorf("table cell Restore = %q", got)
+	}
+	if got := markdown.Parse("See *this* `x` page").Segments[0].PlainText(); got != "See this page" {
+		t.Errorf("PlainText = %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+// Package metrics implements reference-based string similarity metrics used
+// to compare translations with each other: chrF (character n-gram F-score)
+// and sentence-level BLEU. Scores are in [0, 1], where 1 means identical.
+package metrics
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// chrFOrder is the maximum character n-gram order (chrF standard: 6).
+	chrFOrder = 6
+	// chrFBeta weights recall beta times as much as precision (chrF standard: 2).
+	chrFBeta = 2.0
+	// bleuOrder is the maximum word n-gram order for BLEU.
+	bleuOrder = 4
+)
+
+// ChrF returns the chrF score of hyp against ref. Whitespace is ignored, as
+// in the reference implementation, so the metric works for languages without
+// word delimiters and is robust to morphology.
+func ChrF(hyp, ref string) float64 {
+	h := []rune(stripSpace(hyp))
+	r := []rune(stripSpace(ref))
+	if len(h) == 0 && len(r) == 0 {
+		return 1
+	}
+	if len(h) == 0 || len(r) == 0 {
+		return 0
+	}
+
+	var precSum, recSum float64
+	orders := 0
+	for n := 1; n <= chrFOrder; n++ {
+		hc := runeNgrams(h, n)
+		rc := runeNgrams(r, n)
+		if len(hc) == 0 || len(rc) == 0 {
+			break
+		}
+		match := overlap(hc, rc)
+		precSum += float64(match) / float64(total(hc))
+		recSum += float64(match) / float64(total(rc))
+		orders++
+	}
+	if orders == 0 {
+		return 0
+	}
+
+	prec := precSum / float64(orders)
+	rec := recSum / float64(orders)
+	if prec == 0 && rec == 0 {
+		return 0
+	}
+	b2 := chrFBeta * chrFBeta
+	return (1 + b2) * prec * rec / (b2*prec + rec)
+}
+
+// BLEU returns sentence-level BLEU of hyp against ref with add-one smoothing
+// for higher-order n-grams, so short segments do not collapse to zero.
+func BLEU(hyp, ref string) float64 {
+	h := Tokenize(hyp)
+	r := Tokenize(ref)
+	if len(h) == 0 && len(r) == 0 {
+		return 1
+	}
+	if len(h) == 0 || len(r) == 0 {
+		return 0
+	}
+
+	logSum := 0.0
+	for n := 1; n <= bleuOrder; n++ {
+		hc := wordNgrams(h, n)
+		rc := wordNgrams(r, n)
+		match := float64(overlap(hc, rc))
+		count := float64(total(hc))
+		if n > 1 {
+			match++
+			count++
+		}
+		if match == 0 || count == 0 {
+			return 0
+		}
+		logSum += math.Log(match / count)
+	}
+
+	bp := 1.0
+	if len(h) < len(r) {
+		bp = math.Exp(1 - float64(len(r))/float64(len(h)))
+	}
+	return bp * math.Exp(logSum/bleuOrder)
+}
+
+// Tokenize lowercases text and splits it into words and individual
+// punctuation marks.
+func Tokenize(text string) []string {
+	var tokens []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’':
+			cur.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens = append(tokens, string(r))
+		}
+	}
+	flush()
+	return tokens
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func runeNgrams(runes []rune, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(runes); i++ {
+		counts[string(runes[i:i+n])]++
+	}
+	return counts
+}
+
+func wordNgrams(words []string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(words); i++ {
+		counts[strings.Join(words[i:i+n], "\x00")]++
+	}
+	return counts
+}
+
+func overlap(a, b map[string]int) int {
+	m := 0
+	for k, ca := range a {
+		if cb, ok := b[k]; ok {
+			if ca < cb {
+				m += ca
+			} else {
+				m += cb
+			}
+		}
+	}
+	return m
+}
+
+func total(counts map[string]int) int {
+	t := 0
+	for _, c := range counts {
+		t += c
+	}
+	return t
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestChrF_Identical(t *testing.T) {
+	if got := ChrF("Привіт, світе!", "Привіт, світе!"); got != 1 {
+		t.Errorf("expected 1 for identical strings, got %v", got)
+	}
+}
+
+func TestChrF_Disjoint(t *testing.T) {
+	if got := ChrF("abc", "xyz"); got != 0 {
+		t.Errorf("expected 0 for disjoint strings, got %v", got)
+	}
+}
+
+func TestChrF_Empty(t *testing.T) {
+	if got := ChrF("", ""); got != 1 {
+		t.Errorf("expected 1 for two empty strings, got %v", got)
+	}
+	if got := ChrF("текст", ""); got != 0 {
+		t.Errorf("expected 0 against empty reference, got %v", got)
+	}
+}
+
+func TestChrF_IgnoresWhitespace(t *testing.T) {
+	if got := ChrF("добрий  день", "добрий день"); got != 1 {
+		t.Errorf("expected whitespace differences to be ignored, got %v", got)
+	}
+}
+
+func TestChrF_CloserIsHigher(t *testing.T) {
+	ref := "Кіт сидить на килимку"
+	near := ChrF("Кіт сидів на килимку", ref)
+	far := ChrF("Собака бігає в парку", ref)
+	if near <= far {
+		t.Errorf("expected near variant (%v) to score above unrelated text (%v)", near, far)
+	}
+}
+
+func TestBLEU_Identical(t *testing.T) {
+	if got := BLEU("the cat sat on the mat", "the cat sat on the mat"); got < 0.999 {
+		t.Errorf("expected ~1 for identical strings, got %v", got)
+	}
+}
+
+func TestBLEU_BrevityPenalty(t *testing.T) {
+	ref := "the cat sat on the mat today"
+	full := BLEU("the cat sat on the mat today", ref)
+	short := BLEU("the cat sat", ref)
+	if short >= full {
+		t.Errorf("expected short hypothesis (%v) to be penalised below full (%v)", short, full)
+	}
+}
+
+func TestBLEU_Empty(t *testing.T) {
+	if got := BLEU("", "something"); got != 0 {
+		t.Errorf("expected 0 for empty hypothesis, got %v", got)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Hello, World! It's 2025.")
+	want := []string{"hello", ",", "world", "!", "it's", "2025", "."}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
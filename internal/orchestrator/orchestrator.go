@@ -29,6 +29,13 @@ type OrchestratorConfig struct {
 
 	// SkipValidation disables target-language checking of translation results.
 	SkipValidation bool
+
+	// HedgeAfter maps a service name to the latency (typically its learned p95)
+	// after which a duplicate request is fired with a different model from the
+	// service's rotation; whichever returns first wins. Only services that
+	// implement translator.ModelRotator with two or more models are hedged.
+	// A missing or zero entry disables hedging for that service.
+	HedgeAfter map[string]time.Duration
 }
 
 // OrchestratorResult holds the aggregated output of a parallel translation run.
@@ -134,9 +141,15 @@ func (o *Orchestrator) translateWithRetry(
 			delay *= 2
 		}
 
+		attemptCfg, release, err := o.acquireSlot(ctx, cfg, svc)
+		if err != nil {
+			return nil, err
+		}
+
 		callCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
-		res, err := svc.Translate(callCtx, cfg, req)
+		res, err := o.translateHedged(callCtx, attemptCfg, req, svc)
 		cancel()
+		release()
 
 		if err != nil {
 			lastErr = err
@@ -183,6 +196,132 @@ func (o *Orchestrator) translateWithRetry(
 	return nil, lastErr
 }
 
+// acquireSlot waits for a concurrency slot when svc limits in-flight requests.
+// For model-rotating services the model is chosen here and pinned in the
+// returned config, so the slot and the call refer to the same model.
+func (o *Orchestrator) acquireSlot(
+	ctx context.Context,
+	cfg translator.ServiceConfig,
+	svc translator.TranslationService,
+) (translator.ServiceConfig, func(), error) {
+	acq, ok := svc.(translator.SlotAcquirer)
+	if !ok {
+		return cfg, func() {}, nil
+	}
+	if cfg.Model == "" {
+		if rot, ok := svc.(translator.ModelRotator); ok {
+			cfg.Model = rot.NextModel()
+		}
+	}
+	release, err := acq.AcquireSlot(ctx, cfg.Model)
+	if err != nil {
+		return cfg, nil, err
+	}
+	return cfg, release, nil
+}
+
+// translateHedged performs a single call to svc. When a hedge delay is
+// configured for the service and the call has not completed within it, a
+// duplicate request is fired with a different model and the first successful
+// response wins; the slower call is cancelled. The hedge needs a free
+// concurrency slot when it fires; if the limits are reached, it is skipped
+// rather than queued behind the call it was meant to overtake. A failure that arrives before
+// the hedge fires is returned immediately so the retry loop can handle it.
+func (o *Orchestrator) translateHedged(
+	ctx context.Context,
+	cfg translator.ServiceConfig,
+	req translator.TranslateRequest,
+	svc translator.TranslationService,
+) (*translator.ServiceResult, error) {
+	delay := o.config.HedgeAfter[svc.Name()]
+	rot, ok := svc.(translator.ModelRotator)
+	if delay <= 0 || !ok || len(rot.GetModels()) < 2 {
+		return svc.Translate(ctx, cfg, req)
+	}
+
+	primaryCfg := cfg
+	if primaryCfg.Model == "" {
+		primaryCfg.Model = rot.NextModel()
+	}
+	hedgeCfg := cfg
+	hedgeCfg.Model = alternateModel(rot.GetModels(), primaryCfg.Model)
+	if hedgeCfg.Model == "" {
+		return svc.Translate(ctx, primaryCfg, req)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		res    *translator.ServiceResult
+		err    error
+		hedged bool
+	}
+	ch := make(chan outcome, 2)
+	call := func(c translator.ServiceConfig, hedged bool, release func()) {
+		defer release()
+		res, err := svc.Translate(ctx, c, req)
+		ch <- outcome{res: res, err: err, hedged: hedged}
+	}
+
+	go call(primaryCfg, false, func() {})
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	fired := false
+	var failed outcome
+	for {
+		select {
+		case <-timer.C:
+			release := func() {}
+			if acq, ok := svc.(translator.SlotAcquirer); ok {
+				if release, ok = acq.TryAcquireSlot(hedgeCfg.Model); !ok {
+					continue
+				}
+			}
+			fired = true
+			pending++
+			fmt.Fprintf(os.Stderr, "[%s] no response from %s after %v, hedging with %s\n",
+				svc.Name(), primaryCfg.Model, delay.Round(time.Millisecond), hedgeCfg.Model)
+			go call(hedgeCfg, true, release)
+		case oc := <-ch:
+			pending--
+			if oc.err == nil && oc.res != nil && oc.res.Error == "" {
+				if oc.hedged {
+					if oc.res.Metadata == nil {
+						oc.res.Metadata = make(map[string]string)
+					}
+					oc.res.Metadata["hedged"] = "true"
+				}
+				return oc.res, nil
+			}
+			failed = oc
+			if !fired || pending == 0 {
+				return failed.res, failed.err
+			}
+		}
+	}
+}
+
+// alternateModel returns the model following current in models, wrapping
+// around, or "" when models holds no model other than current.
+func alternateModel(models []string, current string) string {
+	start := 0
+	for i, m := range models {
+		if m == current {
+			start = i + 1
+			break
+		}
+	}
+	for i := 0; i < len(models); i++ {
+		if m := models[(start+i)%len(models)]; m != current {
+			return m
+		}
+	}
+	return ""
+}
+
 // ExecuteWithFallback is a convenience wrapper that returns the first successful result.
 func (o *Orchestrator) ExecuteWithFallback(ctx context.Context, cfg translator.ServiceConfig, req translator.TranslateRequest) *translator.ServiceResult {
 	result := o.Execute(ctx, cfg, req)
@@ -354,3 +354,191 @@ func TestOrchestrator_Execute_ValidationFailure(t *testing.T) {
 		t.Errorf("expected 1 succeeded (validation failure on final attempt still returns result), got %d", result.Succeeded)
 	}
 }
+
+// rotatingMockService is a mockService that also implements translator.ModelRotator.
+type rotatingMockService struct {
+	mockService
+	models []string
+}
+
+func (m *rotatingMockService) GetModels() []string { return m.models }
+func (m *rotatingMockService) NextModel() string   { return m.models[0] }
+
+func TestOrchestrator_Execute_HedgeWinsOnSlowModel(t *testing.T) {
+	svc := &rotatingMockService{models: []string{"slow", "fast"}}
+	svc.nameVal = "llm"
+	svc.translateFunc = func(ctx context.Context, cfg translator.ServiceConfig, req translator.TranslateRequest) (*translator.ServiceResult, error) {
+		if cfg.Model == "slow" {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+		}
+		return &translator.ServiceResult{
+			ServiceName:    "llm",
+			TranslatedText: "from " + cfg.Model,
+			Metadata:       map[string]string{"model": cfg.Model},
+		}, nil
+	}
+
+	o := New([]translator.TranslationService{svc}, OrchestratorConfig{
+		Timeout:        5 * time.Second,
+		MaxAttempts:    1,
+		SkipValidation: true,
+		HedgeAfter:     map[string]time.Duration{"llm": 20 * time.Millisecond},
+	})
+
+	start := time.Now()
+	result := o.Execute(context.Background(), translator.ServiceConfig{}, translator.TranslateRequest{Text: "Hello", TargetLang: "uk"})
+
+	if result.Succeeded != 1 {
+		t.Fatalf("expected 1 succeeded, got %d", result.Succeeded)
+	}
+	if got := result.Results[0].TranslatedText; got != "from fast" {
+		t.Errorf("expected hedged result 'from fast', got %q", got)
+	}
+	if result.Results[0].Metadata["hedged"] != "true" {
+		t.Error("expected hedged=true metadata on the winning result")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected hedge to short-circuit the slow call, took %v", elapsed)
+	}
+	if svc.callCount.Load() != 2 {
+		t.Errorf("expected 2 calls (primary + hedge), got %d", svc.callCount.Load())
+	}
+}
+
+func TestOrchestrator_Execute_NoHedgeWhenFast(t *testing.T) {
+	svc := &rotatingMockService{models: []string{"a", "b"}}
+	svc.nameVal = "llm"
+
+	o := New([]translator.TranslationService{svc}, OrchestratorConfig{
+		Timeout:        5 * time.Second,
+		MaxAttempts:    1,
+		SkipValidation: true,
+		HedgeAfter:     map[string]time.Duration{"llm": time.Second},
+	})
+
+	result := o.Execute(context.Background(), translator.ServiceConfig{}, translator.TranslateRequest{Text: "Hello", TargetLang: "uk"})
+
+	if result.Succeeded != 1 {
+		t.Fatalf("expected 1 succeeded, got %d", result.Succeeded)
+	}
+	if svc.callCount.Load() != 1 {
+		t.Errorf("expected no hedge for a fast call, got %d calls", svc.callCount.Load())
+	}
+}
+
+func TestAlternateModel(t *testing.T) {
+	tests := []struct {
+		models  []string
+		current string
+		want    string
+	}{
+		{[]string{"a", "b", "c"}, "a", "b"},
+		{[]string{"a", "b", "c"}, "c", "a"},
+		{[]string{"a", "b"}, "x", "a"},
+		{[]string{"a"}, "a", ""},
+	}
+	for _, tt := range tests {
+		if got := alternateModel(tt.models, tt.current); got != tt.want {
+			t.Errorf("alternateModel(%v, %q) = %q, want %q", tt.models, tt.current, got, tt.want)
+		}
+	}
+}
+
+// limitedMockService is a mockService that shares a ConcurrencyLimiter.
+type limitedMockService struct {
+	mockService
+	limiter *translator.ConcurrencyLimiter
+}
+
+func (m *limitedMockService) AcquireSlot(ctx context.Context, model string) (func(), error) {
+	return m.limiter.Acquire(ctx, model)
+}
+
+func (m *limitedMockService) TryAcquireSlot(model string) (func(), bool) {
+	return m.limiter.TryAcquire(model)
+}
+
+func TestOrchestrator_Execute_FanOutRespectsSharedLimit(t *testing.T) {
+	limiter := translator.NewConcurrencyLimiter(1, 0, nil)
+	var inFlight, peak atomic.Int32
+
+	var services []translator.TranslationService
+	for _, name := range []string{"ollama/a", "ollama/b", "ollama/c"} {
+		svc := &limitedMockService{limiter: limiter}
+		svc.nameVal = name
+		svc.translateFunc = func(ctx context.Context, cfg translator.ServiceConfig, req translator.TranslateRequest) (*translator.ServiceResult, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			if n > peak.Load() {
+				peak.Store(n)
+			}
+			time.Sleep(30 * time.Millisecond)
+			return &translator.ServiceResult{ServiceName: svc.nameVal, TranslatedText: "ok"}, nil
+		}
+		services = append(services, svc)
+	}
+
+	// Each call fits in the timeout, but all three queued together do not:
+	// queueing must not count against the per-attempt timeout.
+	o := New(services, OrchestratorConfig{
+		Timeout:        60 * time.Millisecond,
+		MaxAttempts:    1,
+		SkipValidation: true,
+	})
+
+	result := o.Execute(context.Background(), translator.ServiceConfig{}, translator.TranslateRequest{Text: "Hello", TargetLang: "uk"})
+
+	if result.Succeeded != 3 {
+		t.Errorf("expected 3 independent candidates, got %d (errors: %v)", result.Succeeded, result.Errors)
+	}
+	if peak.Load() != 1 {
+		t.Errorf("expected at most 1 call in flight, saw %d", peak.Load())
+	}
+}
+
+// limitedRotatingMockService is a rotatingMockService that shares a
+// ConcurrencyLimiter.
+type limitedRotatingMockService struct {
+	rotatingMockService
+	limiter *translator.ConcurrencyLimiter
+}
+
+func (m *limitedRotatingMockService) AcquireSlot(ctx context.Context, model string) (func(), error) {
+	return m.limiter.Acquire(ctx, model)
+}
+
+func (m *limitedRotatingMockService) TryAcquireSlot(model string) (func(), bool) {
+	return m.limiter.TryAcquire(model)
+}
+
+func TestOrchestrator_Execute_NoHedgeWithoutFreeSlot(t *testing.T) {
+	svc := &limitedRotatingMockService{limiter: translator.NewConcurrencyLimiter(1, 0, nil)}
+	svc.models = []string{"slow", "fast"}
+	svc.nameVal = "llm"
+	svc.translateFunc = func(ctx context.Context, cfg translator.ServiceConfig, req translator.TranslateRequest) (*translator.ServiceResult, error) {
+		if cfg.Model == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return &translator.ServiceResult{ServiceName: "llm", TranslatedText: "from " + cfg.Model}, nil
+	}
+
+	o := New([]translator.TranslationService{svc}, OrchestratorConfig{
+		Timeout:        5 * time.Second,
+		MaxAttempts:    1,
+		SkipValidation: true,
+		HedgeAfter:     map[string]time.Duration{"llm": 10 * time.Millisecond},
+	})
+
+	result := o.Execute(context.Background(), translator.ServiceConfig{}, translator.TranslateRequest{Text: "Hello", TargetLang: "uk"})
+
+	if result.Succeeded != 1 || result.Results[0].TranslatedText != "from slow" {
+		t.Fatalf("expected the primary result, got %+v (errors: %v)", result.Results, result.Errors)
+	}
+	if svc.callCount.Load() != 1 {
+		t.Errorf("expected no hedge while the only slot is taken, got %d calls", svc.callCount.Load())
+	}
+}
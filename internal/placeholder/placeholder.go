@@ -82,3 +82,21 @@ func Validate(text string, markers []string) []int {
 	}
 	return missing
 }
+
+// MissingFrom returns the [PHn] markers present in source that do not appear
+// in translated, in the order they occur in source. It needs no marker slice,
+// so callers that only see protected text (such as the arbiter) can use it.
+func MissingFrom(source, translated string) []string {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, m := range rePlaceholder.FindAllString(source, -1) {
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+		if !strings.Contains(translated, m) {
+			missing = append(missing, m)
+		}
+	}
+	return missing
+}
@@ -171,3 +171,14 @@ func removeSubstring(s, sub string) string {
 	}
 	return s[:idx] + s[idx+len(sub):]
 }
+
+func TestMissingFrom(t *testing.T) {
+	source := "Click [PH0] then [PH1], not [PH0] again"
+	if got := placeholder.MissingFrom(source, "Натисніть [PH0], потім [PH1]"); len(got) != 0 {
+		t.Errorf("expected no missing markers, got %v", got)
+	}
+	got := placeholder.MissingFrom(source, "Натисніть [PH1]")
+	if len(got) != 1 || got[0] != "[PH0]" {
+		t.Errorf("expected [PH0] missing, got %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package po
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+var (
+	// reDirective matches printf directives (%s, %1$d, %(name)s, %.2f,
+	// %%) and brace placeholders ({name}, {0}, {count:d}).
+	reDirective = regexp.MustCompile(`%(?:\([A-Za-z_][A-Za-z0-9_]*\))?(?:\d+\$)?[-+ #0']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspn%]|\{[A-Za-z0-9_]*(?::[^{}]*)?\}`)
+
+	// reIntDirective matches a printf directive for an integer, the
+	// count of a plural message.
+	reIntDirective = regexp.MustCompile(`%(?:\([A-Za-z_][A-Za-z0-9_]*\))?(?:\d+\$)?[-+ #0']*\d*(?:hh|h|ll|l|j|z|t)?[diu]`)
+)
+
+// Protect replaces the markup of a message (HTML tags and code, as
+// placeholder.Protect does) and its format directives with [PHn]
+// markers. placeholder.Restore puts them back.
+func Protect(text string) (string, []string) {
+	text, markers := placeholder.Protect(text)
+	text = reDirective.ReplaceAllStringFunc(text, func(m string) string {
+		markers = append(markers, m)
+		return fmt.Sprintf("[PH%d]", len(markers)-1)
+	})
+	return text, markers
+}
+
+// WithCount replaces the first integer directive of text with the count
+// n, so a plural form is translated as a real phrase ("5 files") and
+// gets the grammar of that count. The returned function puts the
+// directive back in the translation; it fails unless n appears there
+// exactly once. ok is false when text has no integer directive.
+func WithCount(text string, n int) (string, func(translation string) (string, bool), bool) {
+	loc := reIntDirective.FindStringIndex(text)
+	if loc == nil {
+		return text, nil, false
+	}
+	directive := text[loc[0]:loc[1]]
+	count := strconv.Itoa(n)
+	reCount := regexp.MustCompile(`\b` + count + `\b`)
+	undo := func(translation string) (string, bool) {
+		found := reCount.FindAllStringIndex(translation, -1)
+		if len(found) != 1 {
+			return translation, false
+		}
+		return translation[:found[0][0]] + directive + translation[found[0][1]:], true
+	}
+	return text[:loc[0]] + count + text[loc[1]:], undo, true
+}
+
+// SetLanguage prepares the header for a translation into lang: it sets
+// Language, replaces a CHARSET placeholder with UTF-8 and sets
+// Plural-Forms, keeping a valid one that was written for lang. It
+// returns the plural rule; known is false when the rule of lang is not
+// known and the English one was used.
+func (f *File) SetLanguage(lang string) (p *Plural, known bool, err error) {
+	current := f.HeaderField("Language")
+	if forms := f.HeaderField("Plural-Forms"); forms != "" && (current == "" || samePrimary(current, lang)) {
+		if p, err := ParsePluralForms(forms); err == nil {
+			f.SetHeaderField("Language", lang)
+			f.setCharset()
+			return p, true, nil
+		}
+	}
+	forms, known := PluralFormsFor(lang)
+	p, err = ParsePluralForms(forms)
+	if err != nil {
+		return nil, false, err
+	}
+	f.SetHeaderField("Language", lang)
+	f.SetHeaderField("Plural-Forms", forms)
+	f.setCharset()
+	return p, known, nil
+}
+
+func (f *File) setCharset() {
+	ct := f.HeaderField("Content-Type")
+	switch {
+	case ct == "":
+		f.SetHeaderField("Content-Type", "text/plain; charset=UTF-8")
+	case strings.Contains(ct, "charset=CHARSET"):
+		f.SetHeaderField("Content-Type", strings.Replace(ct, "charset=CHARSET", "charset=UTF-8", 1))
+	}
+}
+
+// samePrimary reports whether two language codes share their primary
+// subtag, e.g. "pt_BR" and "pt".
+func samePrimary(a, b string) bool {
+	primary := func(s string) string {
+		s, _, _ = strings.Cut(strings.ReplaceAll(s, "-", "_"), "_")
+		return strings.ToLower(s)
+	}
+	return primary(a) == primary(b)
+}
@@ -0,0 +1,316 @@
+package po
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pluralForms are the Plural-Forms headers of the languages gettext
+// knows, by lower-case language code. Languages not listed use the
+// English rule.
+var pluralForms = map[string]string{
+	"ja":    "nplurals=1; plural=0;",
+	"ko":    "nplurals=1; plural=0;",
+	"zh":    "nplurals=1; plural=0;",
+	"vi":    "nplurals=1; plural=0;",
+	"th":    "nplurals=1; plural=0;",
+	"id":    "nplurals=1; plural=0;",
+	"ms":    "nplurals=1; plural=0;",
+	"fr":    "nplurals=2; plural=(n > 1);",
+	"pt_br": "nplurals=2; plural=(n > 1);",
+	"uk":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"ru":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"be":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"sr":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"hr":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"bs":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"pl":    "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"cs":    "nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;",
+	"sk":    "nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;",
+	"lt":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"lv":    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+	"ro":    "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+	"sl":    "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+	"ga":    "nplurals=5; plural=(n==1 ? 0 : n==2 ? 1 : n<7 ? 2 : n<11 ? 3 : 4);",
+	"ar":    "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+}
+
+// englishPluralForms is the rule of English and most Germanic and Romance
+// languages.
+const englishPluralForms = "nplurals=2; plural=(n != 1);"
+
+// PluralFormsFor returns the Plural-Forms header for lang, e.g. "uk" or
+// "pt_BR". ok is false when the language is unknown and the English rule
+// is returned.
+func PluralFormsFor(lang string) (forms string, ok bool) {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "-", "_"))
+	if forms, ok := pluralForms[lang]; ok {
+		return forms, true
+	}
+	primary, _, _ := strings.Cut(lang, "_")
+	if forms, ok := pluralForms[primary]; ok {
+		return forms, true
+	}
+	switch primary {
+	case "en", "de", "nl", "sv", "da", "no", "nb", "nn", "fi", "et", "hu", "el", "he",
+		"it", "es", "ca", "pt", "bg", "tr", "eo", "af", "sq", "hi", "bn":
+		return englishPluralForms, true
+	}
+	return englishPluralForms, false
+}
+
+// Plural is a parsed Plural-Forms header.
+type Plural struct {
+	// N is the number of plural forms.
+	N    int
+	eval func(n int) int
+}
+
+// ParsePluralForms parses a Plural-Forms header value such as
+// "nplurals=2; plural=(n != 1);".
+func ParsePluralForms(s string) (*Plural, error) {
+	var nplurals, expr string
+	for _, field := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(k) {
+		case "nplurals":
+			nplurals = strings.TrimSpace(v)
+		case "plural":
+			expr = strings.TrimSpace(v)
+		}
+	}
+	n, err := strconv.Atoi(nplurals)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad nplurals in %q", s)
+	}
+	p := &exprParser{src: expr}
+	eval, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("bad plural expression in %q: %w", s, err)
+	}
+	return &Plural{N: n, eval: eval}, nil
+}
+
+// Form returns the plural form used for the count n.
+func (p *Plural) Form(n int) int {
+	form := p.eval(n)
+	if form < 0 || form >= p.N {
+		return 0
+	}
+	return form
+}
+
+// Sample returns a count that uses form, preferring the smallest positive
+// one, and whether form is used for the count 1 alone. ok is false when
+// no count below 1000 uses form.
+func (p *Plural) Sample(form int) (n int, onlyOne bool, ok bool) {
+	n = -1
+	count := 0
+	for i := 1; i < 1000; i++ {
+		if p.Form(i) == form {
+			if n < 0 {
+				n = i
+			}
+			count++
+		}
+	}
+	if p.Form(0) == form {
+		if n < 0 {
+			n = 0
+		}
+		count++
+	}
+	return n, n == 1 && count == 1, n >= 0
+}
+
+// exprParser parses the C expression of a Plural-Forms header into a
+// function of n.
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) parse() (func(int) int, error) {
+	f, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	return f, nil
+}
+
+func (p *exprParser) space() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes op if it comes next. An operator that is the prefix of
+// a longer one ("<" of "<=", "!" of "!=") is not taken for it.
+func (p *exprParser) accept(op string) bool {
+	p.space()
+	if !strings.HasPrefix(p.src[p.pos:], op) {
+		return false
+	}
+	if len(op) == 1 && strings.Contains("<>!=", op) && strings.HasPrefix(p.src[p.pos+1:], "=") {
+		return false
+	}
+	p.pos += len(op)
+	return true
+}
+
+func (p *exprParser) ternary() (func(int) int, error) {
+	cond, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("missing ':' at %d", p.pos)
+	}
+	els, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return els(n)
+	}, nil
+}
+
+// levels are the binary operators from the lowest precedence up.
+var levels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) binary(level int) (func(int) int, error) {
+	if level == len(levels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, o := range levels[level] {
+			if p.accept(o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = combine(op, left, right)
+	}
+}
+
+func combine(op string, l, r func(int) int) func(int) int {
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	switch op {
+	case "||":
+		return func(n int) int { return b(l(n) != 0 || r(n) != 0) }
+	case "&&":
+		return func(n int) int { return b(l(n) != 0 && r(n) != 0) }
+	case "==":
+		return func(n int) int { return b(l(n) == r(n)) }
+	case "!=":
+		return func(n int) int { return b(l(n) != r(n)) }
+	case "<":
+		return func(n int) int { return b(l(n) < r(n)) }
+	case "<=":
+		return func(n int) int { return b(l(n) <= r(n)) }
+	case ">":
+		return func(n int) int { return b(l(n) > r(n)) }
+	case ">=":
+		return func(n int) int { return b(l(n) >= r(n)) }
+	case "+":
+		return func(n int) int { return l(n) + r(n) }
+	case "-":
+		return func(n int) int { return l(n) - r(n) }
+	case "*":
+		return func(n int) int { return l(n) * r(n) }
+	case "/":
+		return func(n int) int {
+			if d := r(n); d != 0 {
+				return l(n) / d
+			}
+			return 0
+		}
+	default:
+		return func(n int) int {
+			if d := r(n); d != 0 {
+				return l(n) % d
+			}
+			return 0
+		}
+	}
+}
+
+func (p *exprParser) unary() (func(int) int, error) {
+	if p.accept("!") {
+		f, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int {
+			if f(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	}
+	if p.accept("(") {
+		f, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')' at %d", p.pos)
+		}
+		return f, nil
+	}
+	if p.accept("n") {
+		return func(n int) int { return n }, nil
+	}
+	p.space()
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	v, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return nil, err
+	}
+	return func(int) int { return v }, nil
+}
@@ -0,0 +1,436 @@
+// Package po reads and writes gettext PO and POT files. Entries that are
+// not changed are written back exactly as they were read, so a file keeps
+// its comments, references, flags, obsolete entries and line wrapping.
+package po
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineWidth is the width gettext wraps msgstr lines at.
+const lineWidth = 79
+
+// File is a parsed PO or POT file.
+type File struct {
+	Entries []*Entry
+	// tail holds the lines after the last entry.
+	tail []string
+	crlf bool
+}
+
+// Entry is one message of a PO file.
+type Entry struct {
+	// Comments are the comment lines before the message, as written:
+	// translator comments (#), extracted comments (#.), references (#:),
+	// flags (#,) and previous strings (#|).
+	Comments []string
+	// Flags are the flags of the #, comment, e.g. "fuzzy" or "c-format".
+	Flags []string
+	// Context is the msgctxt; HasContext tells an empty one from none.
+	Context    string
+	HasContext bool
+	ID         string
+	// Plural is the msgid_plural, or "" for a message without plurals.
+	Plural string
+	// Str holds the msgstr, or msgstr[0], msgstr[1], ... of a plural
+	// message.
+	Str []string
+	// Obsolete is true for an entry commented out with #~.
+	Obsolete bool
+	// Previous is true when the entry has #| comments, the source text
+	// msgmerge matched an old translation from.
+	Previous bool
+
+	// sep holds the blank lines before the entry.
+	sep []string
+	// keys holds the msgctxt, msgid and msgid_plural lines.
+	keys []string
+	// raw holds every line of the entry, written while it is unchanged.
+	raw     []string
+	changed bool
+	// cur is the string continuation lines are appended to.
+	cur *string
+	// hasStr is true once a msgstr line has been read.
+	hasStr bool
+}
+
+// Parse parses the content of a PO or POT file.
+func Parse(src string) (*File, error) {
+	f := &File{crlf: strings.Contains(src, "\r\n")}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var sep []string
+	e := &Entry{}
+	finish := func() {
+		if e.hasStr || e.Obsolete {
+			e.sep = sep
+			f.Entries = append(f.Entries, e)
+			sep = nil
+		} else {
+			// A comment block without a message is kept as it is.
+			sep = append(sep, e.raw...)
+		}
+		e = &Entry{}
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			if len(e.raw) > 0 {
+				finish()
+			}
+			sep = append(sep, line)
+			continue
+		case strings.HasPrefix(trimmed, "#~"):
+			if e.hasStr {
+				finish()
+			}
+			e.Obsolete = true
+		case strings.HasPrefix(trimmed, "#"):
+			if e.hasStr {
+				finish()
+			}
+			e.Comments = append(e.Comments, line)
+			if strings.HasPrefix(trimmed, "#,") {
+				for _, flag := range strings.Split(trimmed[2:], ",") {
+					if flag = strings.TrimSpace(flag); flag != "" {
+						e.Flags = append(e.Flags, flag)
+					}
+				}
+			}
+			if strings.HasPrefix(trimmed, "#|") {
+				e.Previous = true
+			}
+		case strings.HasPrefix(trimmed, `"`):
+			if e.cur == nil {
+				return nil, fmt.Errorf("line %d: string without a keyword", i+1)
+			}
+			s, err := unquote(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			*e.cur += s
+			if !e.hasStr {
+				e.keys = append(e.keys, line)
+			}
+		default:
+			keyword, rest, _ := strings.Cut(trimmed, " ")
+			s, err := unquote(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			if (keyword == "msgctxt" || keyword == "msgid") && e.hasStr {
+				finish()
+			}
+			switch {
+			case keyword == "msgctxt":
+				e.Context, e.HasContext = s, true
+				e.cur = &e.Context
+			case keyword == "msgid":
+				e.ID = s
+				e.cur = &e.ID
+			case keyword == "msgid_plural":
+				e.Plural = s
+				e.cur = &e.Plural
+			case keyword == "msgstr":
+				e.Str = []string{s}
+				e.cur = &e.Str[0]
+				e.hasStr = true
+			case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+				n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("line %d: bad keyword %q", i+1, keyword)
+				}
+				for len(e.Str) <= n {
+					e.Str = append(e.Str, "")
+				}
+				e.Str[n] = s
+				e.cur = &e.Str[n]
+				e.hasStr = true
+			default:
+				return nil, fmt.Errorf("line %d: unexpected %q", i+1, keyword)
+			}
+			if !e.hasStr {
+				e.keys = append(e.keys, line)
+			}
+		}
+		e.raw = append(e.raw, line)
+	}
+	if len(e.raw) > 0 {
+		finish()
+	}
+	f.tail = sep
+	return f, nil
+}
+
+// IsHeader reports whether e is the header entry, the one with an empty
+// msgid.
+func (e *Entry) IsHeader() bool {
+	return e.ID == "" && !e.HasContext && !e.Obsolete
+}
+
+// Translated reports whether every msgstr of e is filled in. An entry
+// with #| comments counts as untranslated: msgmerge carried its msgstr
+// over from an older source text.
+func (e *Entry) Translated() bool {
+	if len(e.Str) == 0 || e.Previous {
+		return false
+	}
+	for _, s := range e.Str {
+		if s == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// HasFlag reports whether e has flag.
+func (e *Entry) HasFlag(flag string) bool {
+	for _, f := range e.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTranslation sets the msgstr of e, one string per plural form, and
+// marks it fuzzy so a translator reviews it. The #| comments are dropped:
+// they describe the translation being replaced.
+func (e *Entry) SetTranslation(strs []string) {
+	e.Str = strs
+	if !e.HasFlag("fuzzy") {
+		e.Flags = append(e.Flags, "fuzzy")
+	}
+	e.Previous = false
+	e.changed = true
+}
+
+// Header returns the header entry, or nil when the file has none.
+func (f *File) Header() *Entry {
+	for _, e := range f.Entries {
+		if e.IsHeader() {
+			return e
+		}
+	}
+	return nil
+}
+
+// HeaderField returns the value of a header field such as "Language", or
+// "" when it is not set.
+func (f *File) HeaderField(key string) string {
+	h := f.Header()
+	if h == nil || len(h.Str) == 0 {
+		return ""
+	}
+	for _, line := range strings.Split(h.Str[0], "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// SetHeaderField sets a header field, adding it at the end when missing.
+// A file without a header gets one.
+func (f *File) SetHeaderField(key, value string) {
+	h := f.Header()
+	if h == nil {
+		h = &Entry{keys: []string{`msgid ""`}, Str: []string{""}, hasStr: true}
+		f.Entries = append([]*Entry{h}, f.Entries...)
+		if len(f.Entries) > 1 && len(f.Entries[1].sep) == 0 {
+			f.Entries[1].sep = []string{""}
+		}
+	}
+	if len(h.Str) == 0 {
+		h.Str = []string{""}
+	}
+	lines := strings.Split(strings.TrimSuffix(h.Str[0], "\n"), "\n")
+	if h.Str[0] == "" {
+		lines = nil
+	}
+	found := false
+	for i, line := range lines {
+		if k, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			lines[i] = key + ": " + value
+			found = true
+		}
+	}
+	if !found {
+		lines = append(lines, key+": "+value)
+	}
+	h.Str[0] = strings.Join(lines, "\n") + "\n"
+	h.changed = true
+}
+
+// String returns the file in PO syntax.
+func (f *File) String() string {
+	var lines []string
+	for _, e := range f.Entries {
+		lines = append(lines, e.sep...)
+		lines = append(lines, e.lines()...)
+	}
+	lines = append(lines, f.tail...)
+	nl := "\n"
+	if f.crlf {
+		nl = "\r\n"
+	}
+	return strings.Join(lines, nl) + nl
+}
+
+// lines returns the lines of e: as read, unless e was changed.
+func (e *Entry) lines() []string {
+	if !e.changed {
+		return e.raw
+	}
+	var lines []string
+	flagsWritten := false
+	writeFlags := func() {
+		if !flagsWritten && len(e.Flags) > 0 {
+			lines = append(lines, "#, "+strings.Join(e.Flags, ", "))
+		}
+		flagsWritten = true
+	}
+	for _, c := range e.Comments {
+		c = strings.TrimSpace(c)
+		switch {
+		case strings.HasPrefix(c, "#,"):
+			writeFlags()
+		case strings.HasPrefix(c, "#|"):
+			writeFlags()
+			if e.Previous {
+				lines = append(lines, c)
+			}
+		default:
+			lines = append(lines, c)
+		}
+	}
+	writeFlags()
+	lines = append(lines, e.keys...)
+	if e.Plural == "" {
+		lines = append(lines, formatString("msgstr", e.Str[0])...)
+	} else {
+		for i, s := range e.Str {
+			lines = append(lines, formatString(fmt.Sprintf("msgstr[%d]", i), s)...)
+		}
+	}
+	return lines
+}
+
+// formatString writes keyword with s the way msgmerge does: on one line
+// when it fits, otherwise starting with "" and broken after each newline
+// and at spaces to stay within lineWidth.
+func formatString(keyword, s string) []string {
+	one := keyword + ` "` + escape(s) + `"`
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") && utf8.RuneCountInString(one) <= lineWidth {
+		return []string{one}
+	}
+	lines := []string{keyword + ` ""`}
+	for _, piece := range strings.SplitAfter(s, "\n") {
+		if piece == "" {
+			continue
+		}
+		for _, chunk := range wrap(escape(piece), lineWidth-2) {
+			lines = append(lines, `"`+chunk+`"`)
+		}
+	}
+	return lines
+}
+
+// wrap breaks s after spaces into chunks of at most width runes where it
+// can.
+func wrap(s string, width int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(s) > width {
+		cut := -1
+		n := 0
+		for i, r := range s {
+			if n >= width && cut >= 0 {
+				break
+			}
+			if r == ' ' {
+				cut = i + 1
+			}
+			n++
+		}
+		if cut <= 0 || cut >= len(s) {
+			break
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	return append(chunks, s)
+}
+
+// unquote decodes a C-style quoted PO string.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string, got %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i+1:j], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("bad escape in %q", s)
+			}
+			b.WriteByte(byte(v))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i:j], 8, 8)
+			b.WriteByte(byte(v))
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// escape encodes s for a quoted PO string.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+		"\a", `\a`, "\b", `\b`, "\f", `\f`, "\v", `\v`,
+	).Replace(s)
+}
@@ -0,0 +1,184 @@
+package po_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/placeholder"
+	"github.com/valpere/peretran/internal/po"
+)
+
+const samplePOT = `# SOME DESCRIPTIVE TITLE.
+#, fuzzy
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: \n"
+"Content-Type: text/plain; charset=CHARSET\n"
+"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"
+
+#. Shown on the toolbar
+#: src/main.c:10
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+#: src/main.c:20
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+#: src/main.c:30
+msgid ""
+"A long message that "
+"spans lines"
+msgstr "Довге повідомлення"
+
+#~ msgid "Old"
+#~ msgstr "Старе"
+`
+
+func TestParse_Entries(t *testing.T) {
+	f, err := po.Parse(samplePOT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(f.Entries))
+	}
+	if !f.Entries[0].IsHeader() || f.HeaderField("Project-Id-Version") != "demo" {
+		t.Errorf("header not parsed: %+v", f.Entries[0])
+	}
+	open := f.Entries[1]
+	if !open.HasContext || open.Context != "menu" || open.ID != "Open" || open.Translated() {
+		t.Errorf("entry 1 = %+v", open)
+	}
+	files := f.Entries[2]
+	if files.Plural != "%d files" || len(files.Str) != 2 || !files.HasFlag("c-format") {
+		t.Errorf("entry 2 = %+v", files)
+	}
+	long := f.Entries[3]
+	if long.ID != "A long message that spans lines" || !long.Translated() {
+		t.Errorf("entry 3 = %+v", long)
+	}
+	if !f.Entries[4].Obsolete {
+		t.Error("entry 4 should be obsolete")
+	}
+}
+
+func TestString_RoundTrip(t *testing.T) {
+	for _, src := range []string{samplePOT, strings.ReplaceAll(samplePOT, "\n", "\r\n")} {
+		f, err := po.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.String(); got != src {
+			t.Errorf("round trip changed the file:\n%s", got)
+		}
+	}
+}
+
+func TestSetTranslation(t *testing.T) {
+	f, _ := po.Parse(samplePOT)
+	p, known, err := f.SetLanguage("uk")
+	if err != nil || !known || p.N != 3 {
+		t.Fatalf("SetLanguage = %v, %v, %v", p, known, err)
+	}
+	f.Entries[1].SetTranslation([]string{"Відкрити"})
+	f.Entries[2].SetTranslation([]string{"%d файл", "%d файли", "%d файлів"})
+	out := f.String()
+	for _, want := range []string{
+		`"Language: uk\n"`,
+		`"Content-Type: text/plain; charset=UTF-8\n"`,
+		`"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && "`,
+		"#. Shown on the toolbar\n#: src/main.c:10\n#, fuzzy\nmsgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"Відкрити\"\n",
+		"#, c-format, fuzzy\nmsgid \"%d file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"%d файл\"\nmsgstr[1] \"%d файли\"\nmsgstr[2] \"%d файлів\"\n",
+		"msgid \"\"\n\"A long message that \"\n\"spans lines\"\nmsgstr \"Довге повідомлення\"\n",
+		"#~ msgid \"Old\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q\n%s", want, out)
+		}
+	}
+}
+
+func TestSetTranslation_DropsPrevious(t *testing.T) {
+	f, _ := po.Parse("#, fuzzy\n#| msgid \"Save file\"\nmsgid \"Save the file\"\nmsgstr \"Зберегти файл\"\n")
+	e := f.Entries[0]
+	if e.Translated() {
+		t.Error("an entry with a previous msgid counts as untranslated")
+	}
+	e.SetTranslation([]string{"Зберегти цей файл"})
+	if want := "#, fuzzy\nmsgid \"Save the file\"\nmsgstr \"Зберегти цей файл\"\n"; f.String() != want {
+		t.Errorf("String = %q, want %q", f.String(), want)
+	}
+}
+
+func TestSetTranslation_Wraps(t *testing.T) {
+	f, _ := po.Parse("msgid \"x\"\nmsgstr \"\"\n")
+	f.Entries[0].SetTranslation([]string{"Перший рядок\nA \"quoted\" word and a rather long line that needs to be wrapped because it is over the width"})
+	want := "#, fuzzy\nmsgid \"x\"\nmsgstr \"\"\n\"Перший рядок\\n\"\n" +
+		"\"A \\\"quoted\\\" word and a rather long line that needs to be wrapped because it \"\n" +
+		"\"is over the width\"\n"
+	if got := f.String(); got != want {
+		t.Errorf("String =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPluralForms(t *testing.T) {
+	forms, ok := po.PluralFormsFor("uk")
+	if !ok {
+		t.Fatal("uk is known")
+	}
+	p, err := po.ParsePluralForms(forms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, want := range map[int]int{0: 2, 1: 0, 2: 1, 4: 1, 5: 2, 11: 2, 12: 2, 21: 0, 22: 1, 111: 2} {
+		if got := p.Form(n); got != want {
+			t.Errorf("Form(%d) = %d, want %d", n, got, want)
+		}
+	}
+	for form, want := range []int{1, 2, 5} {
+		if n, onlyOne, ok := p.Sample(form); n != want || onlyOne || !ok {
+			t.Errorf("Sample(%d) = %d, %v, %v", form, n, onlyOne, ok)
+		}
+	}
+
+	en, _ := po.ParsePluralForms("nplurals=2; plural=(n != 1);")
+	if n, onlyOne, _ := en.Sample(0); n != 1 || !onlyOne {
+		t.Errorf("English singular Sample = %d, %v", n, onlyOne)
+	}
+	if _, ok := po.PluralFormsFor("xx"); ok {
+		t.Error("xx is not a known language")
+	}
+	if _, err := po.ParsePluralForms("nplurals=INTEGER; plural=EXPRESSION;"); err == nil {
+		t.Error("expected an error for the POT placeholder")
+	}
+}
+
+func TestProtectAndWithCount(t *testing.T) {
+	text, markers := po.Protect("Delete <b>%s</b> and %(count)d {name} items? 100%%")
+	if want := "Delete [PH0][PH2][PH1] and [PH3] [PH4] items? 100[PH5]"; text != want {
+		t.Errorf("Protect = %q, want %q", text, want)
+	}
+	if got := placeholder.Restore(text, markers); got != "Delete <b>%s</b> and %(count)d {name} items? 100%%" {
+		t.Errorf("Restore = %q", got)
+	}
+
+	src, undo, ok := po.WithCount("%s has %d files", 5)
+	if !ok || src != "%s has 5 files" {
+		t.Fatalf("WithCount = %q, %v", src, ok)
+	}
+	if got, ok := undo("%s має 5 файлів"); !ok || got != "%s має %d файлів" {
+		t.Errorf("undo = %q, %v", got, ok)
+	}
+	if _, ok := undo("%s має п'ять файлів"); ok {
+		t.Error("undo should fail when the count is gone")
+	}
+	if _, _, ok := po.WithCount("Some files", 5); ok {
+		t.Error("no integer directive")
+	}
+}
@@ -0,0 +1,277 @@
+// Package prompts renders the LLM prompts used by the translators, the
+// arbiter and the refiner from text/template files. Defaults are embedded in
+// the binary; a directory of *.tmpl files can override or add templates.
+//
+// Every template is identified by its name plus a short hash of its source
+// ("translate@1a2b3c4d"), which is recorded with each result so prompt
+// changes can be A/B compared.
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Template names used by the pipeline.
+const (
+	// Translate is the Ollama translation prompt.
+	Translate = "translate"
+	// TranslateSystem is the system prompt for chat-completion translators
+	// (OpenRouter); the text to translate is sent as the user message.
+	TranslateSystem = "translate-system"
+	// Arbiter asks a model to score and select among all candidates.
+	Arbiter = "arbiter"
+	// ArbiterPairwise asks a model to compare two candidates (tournament mode).
+	ArbiterPairwise = "arbiter-pairwise"
+	// Refine is the Stage 2 literary refinement prompt.
+	Refine = "refine"
+	// Critique asks for a structured list of problems in a translation
+	// (critique refinement mode).
+	Critique = "critique"
+	// Revise asks for a translation revised against a critique.
+	Revise = "revise"
+	// TermRenderings asks how a translation renders a list of source terms
+	// (document consistency pass).
+	TermRenderings = "term-renderings"
+	// Harmonize asks for a translation revised to use the document's
+	// majority renderings of its terms.
+	Harmonize = "harmonize"
+)
+
+// templateExt is the file extension of template files.
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// Candidate is one translation shown to the arbiter.
+type Candidate struct {
+	Name string
+	Text string
+}
+
+// Term is a recurring source term checked by the consistency pass. Found
+// is its rendering in the passage and Required the one to use instead.
+type Term struct {
+	Source   string
+	Found    string
+	Required string
+}
+
+// Data holds the variables available to templates. Callers fill the fields
+// relevant to the template they render; the rest stay empty.
+type Data struct {
+	SourceLang string
+	TargetLang string
+
+	// Source is the text being translated.
+	Source string
+	// Draft is the translation being refined.
+	Draft string
+	// Context is the end of the previous passage, for continuity.
+	Context string
+	// SourceBefore and SourceAfter are the source text around Source, shown
+	// for reference only.
+	SourceBefore string
+	SourceAfter  string
+	// Critique lists the reviewer's problems with Draft, one per line.
+	Critique string
+
+	Glossary     map[string]string
+	Instructions string
+	StyleGuide   string
+
+	// Candidates are the translations being judged by the arbiter.
+	Candidates []Candidate
+	// Terms are the source terms whose renderings are checked or
+	// harmonized.
+	Terms []Term
+}
+
+// SampleData returns representative values for every variable. It is used
+// to check templates when they are loaded and by `peretran prompts render`.
+func SampleData() Data {
+	return Data{
+		SourceLang:   "en",
+		TargetLang:   "uk",
+		Source:       "The quick brown fox jumps over the lazy dog.",
+		Draft:        "Швидка бура лисиця стрибає через ледачого пса.",
+		Context:      "It was a quiet morning on the farm.",
+		SourceBefore: "It was a quiet morning on the farm.",
+		SourceAfter:  "The dog did not even open its eyes.",
+		Critique:     `- [awkward] "стрибає через": use "перестрибує через"`,
+		Glossary:     map[string]string{"fox": "лисиця"},
+		Instructions: "Preserve all [PHn] markers exactly as they appear — do not translate, move, or remove them.",
+		StyleGuide:   "Use a neutral, literary register.",
+		Candidates: []Candidate{
+			{Name: "google", Text: "Швидка бура лисиця стрибає через лінивого собаку."},
+			{Name: "ollama/gemma2:27b", Text: "Прудка руда лисиця перестрибує через ледачого пса."},
+		},
+		Terms: []Term{{Source: "fox", Found: "лис", Required: "лисиця"}},
+	}
+}
+
+var funcs = template.FuncMap{
+	"inc":   func(i int) int { return i + 1 },
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Set is a collection of named prompt templates. A nil *Set renders the
+// embedded defaults.
+type Set struct {
+	templates map[string]*template.Template
+	sources   map[string]string
+	origins   map[string]string
+}
+
+var (
+	defaultOnce sync.Once
+	defaultSet  *Set
+)
+
+// Default returns the embedded default templates.
+func Default() *Set {
+	defaultOnce.Do(func() {
+		s, err := loadFS(&Set{
+			templates: make(map[string]*template.Template),
+			sources:   make(map[string]string),
+			origins:   make(map[string]string),
+		}, embedded, "templates", "embedded")
+		if err != nil {
+			panic(fmt.Sprintf("prompts: invalid embedded template: %v", err))
+		}
+		defaultSet = s
+	})
+	return defaultSet
+}
+
+// Load returns the embedded defaults overridden by the *.tmpl files in dir.
+// A file's name without the extension is its template name, so
+// "translate.tmpl" replaces the default translation prompt and
+// "translate-formal.tmpl" adds a new one. Every template is test-rendered
+// with SampleData, so mistakes surface at startup rather than mid-run. An
+// empty dir returns Default().
+func Load(dir string) (*Set, error) {
+	if dir == "" {
+		return Default(), nil
+	}
+
+	base := Default()
+	s := &Set{
+		templates: make(map[string]*template.Template, len(base.templates)),
+		sources:   make(map[string]string, len(base.sources)),
+		origins:   make(map[string]string, len(base.origins)),
+	}
+	for name := range base.templates {
+		s.templates[name] = base.templates[name]
+		s.sources[name] = base.sources[name]
+		s.origins[name] = base.origins[name]
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("prompts directory: %w", err)
+	}
+	return loadFS(s, os.DirFS(dir), ".", dir)
+}
+
+func loadFS(s *Set, fsys fs.FS, dir, origin string) (*Set, error) {
+	paths, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*"+templateExt)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		raw, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(p), templateExt)
+		// Files end with a newline by convention; the prompt does not.
+		src := strings.TrimSuffix(string(raw), "\n")
+
+		tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+		if err := tmpl.Execute(io.Discard, SampleData()); err != nil {
+			return nil, fmt.Errorf("template %s does not render: %w", name, err)
+		}
+
+		s.templates[name] = tmpl
+		s.sources[name] = src
+		s.origins[name] = filepath.Join(origin, filepath.Base(p))
+	}
+	return s, nil
+}
+
+// Names returns the template names in alphabetical order.
+func (s *Set) Names() []string {
+	if s == nil {
+		s = Default()
+	}
+	names := make([]string, 0, len(s.templates))
+	for name := range s.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Source returns the template text of name.
+func (s *Set) Source(name string) (string, bool) {
+	if s == nil {
+		s = Default()
+	}
+	src, ok := s.sources[name]
+	return src, ok
+}
+
+// Origin returns where name was loaded from: a file path, or
+// "embedded/<name>.tmpl" for a built-in default.
+func (s *Set) Origin(name string) string {
+	if s == nil {
+		s = Default()
+	}
+	return s.origins[name]
+}
+
+// ID returns name with a short hash of its source, e.g. "refine@1a2b3c4d".
+// Editing a template changes its ID, which makes prompt versions
+// distinguishable in the stored results.
+func (s *Set) ID(name string) string {
+	src, ok := s.Source(name)
+	if !ok {
+		return name
+	}
+	sum := sha256.Sum256([]byte(src))
+	return name + "@" + hex.EncodeToString(sum[:4])
+}
+
+// Render executes the template name with data.
+func (s *Set) Render(name string, data Data) (string, error) {
+	if s == nil {
+		s = Default()
+	}
+	tmpl, ok := s.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt template %q", name)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
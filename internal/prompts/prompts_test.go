@@ -0,0 +1,119 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefault_RendersAllTemplates(t *testing.T) {
+	s := Default()
+	for _, name := range []string{Translate, TranslateSystem, Arbiter, ArbiterPairwise, Refine, Critique, Revise, TermRenderings, Harmonize} {
+		out, err := s.Render(name, SampleData())
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !strings.Contains(out, "uk") {
+			t.Errorf("%s: expected target language in prompt, got:\n%s", name, out)
+		}
+	}
+}
+
+func TestRender_TranslateOptionalSections(t *testing.T) {
+	out, err := Default().Render(Translate, Data{SourceLang: "en", TargetLang: "uk", Source: "Hello"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, section := range []string{"TERMINOLOGY", "STYLE GUIDE", "CONTEXT", "SURROUNDING SOURCE TEXT"} {
+		if strings.Contains(out, section) {
+			t.Errorf("expected no %s section without data, got:\n%s", section, out)
+		}
+	}
+	if !strings.HasSuffix(out, "Translation:") {
+		t.Errorf("expected prompt to end with 'Translation:', got %q", out)
+	}
+}
+
+func TestRender_SurroundingSourceText(t *testing.T) {
+	for _, name := range []string{Translate, TranslateSystem} {
+		out, err := Default().Render(name, Data{SourceLang: "en", TargetLang: "uk", Source: "Hello", SourceAfter: "Goodbye"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !strings.Contains(out, "SURROUNDING SOURCE TEXT") || !strings.Contains(out, `After: "Goodbye..."`) {
+			t.Errorf("%s: expected following source text, got:\n%s", name, out)
+		}
+		if strings.Contains(out, "Before:") {
+			t.Errorf("%s: expected no preceding source text, got:\n%s", name, out)
+		}
+	}
+}
+
+func TestRender_UnknownTemplate(t *testing.T) {
+	if _, err := Default().Render("nope", Data{}); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestNilSetUsesDefaults(t *testing.T) {
+	var s *Set
+	if _, err := s.Render(Refine, SampleData()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if s.ID(Refine) != Default().ID(Refine) {
+		t.Error("expected nil set to report default IDs")
+	}
+}
+
+func TestLoad_OverridesAndAdds(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "refine.tmpl"), []byte("Polish {{.Draft}} into {{.TargetLang}}.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "translate-formal.tmpl"), []byte("Formally translate {{.Source}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := s.Render(Refine, Data{Draft: "текст", TargetLang: "uk"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "Polish текст into uk." {
+		t.Errorf("expected override without trailing newline, got %q", out)
+	}
+	if s.ID(Refine) == Default().ID(Refine) {
+		t.Error("expected overridden template to get a new ID")
+	}
+	if s.ID(Translate) != Default().ID(Translate) {
+		t.Error("expected untouched template to keep the default ID")
+	}
+	if _, ok := s.Source("translate-formal"); !ok {
+		t.Error("expected added template to be listed")
+	}
+	if !strings.HasPrefix(s.Origin(Refine), dir) || s.Origin(Translate) != filepath.Join("embedded", "translate.tmpl") {
+		t.Errorf("unexpected origins %q, %q", s.Origin(Refine), s.Origin(Translate))
+	}
+}
+
+func TestLoad_RejectsBrokenTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "arbiter.tmpl"), []byte("{{.NoSuchField}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for template referencing an unknown field")
+	}
+}
+
+func TestLoad_MissingDir(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
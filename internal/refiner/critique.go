@@ -0,0 +1,163 @@
+package refiner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/valpere/peretran/internal/postprocess"
+	"github.com/valpere/peretran/internal/prompts"
+)
+
+// DefaultCritiqueRounds is the default maximum number of critique-and-revise
+// rounds.
+const DefaultCritiqueRounds = 3
+
+// Issue is one problem found by the critique step.
+type Issue struct {
+	// Category is mistranslation, omission, addition, awkward, glossary or
+	// style.
+	Category   string `json:"category"`
+	Span       string `json:"span"`
+	Problem    string `json:"problem"`
+	Suggestion string `json:"suggestion"`
+}
+
+// Round records one critique-and-revise round.
+type Round struct {
+	Number int
+	Issues []Issue
+	// Revised is the translation after this round's revision; empty when
+	// the critique found no issues or could not be parsed.
+	Revised string
+	// Err explains why the round stopped the loop early, e.g. an
+	// unparsable critique.
+	Err string
+}
+
+// Refinement is the outcome of iterative refinement.
+type Refinement struct {
+	Text   string
+	Rounds []Round
+	// Converged is true when the last critique found no issues.
+	Converged bool
+	// PromptTemplate identifies the critique and revise templates used.
+	PromptTemplate string
+}
+
+// CritiqueRefiner refines a draft through repeated critique and revision.
+type CritiqueRefiner interface {
+	RefineWithCritique(ctx context.Context, sourceLang, targetLang, sourceText, draftText string) (*Refinement, error)
+}
+
+// completeFunc sends a prompt to the refiner model and returns its raw
+// reply; jsonMode requests a JSON object.
+type completeFunc func(ctx context.Context, prompt string, jsonMode bool) (string, error)
+
+// critiqueAndRevise runs the critique-then-revise loop shared by the LLM
+// refiners. Each round asks for a structured critique of the current text
+// and, if it lists issues, a revision against it. The loop stops when a
+// critique is empty, a revision changes nothing, a critique cannot be parsed,
+// or maxRounds is reached. Transport errors are returned as-is.
+func critiqueAndRevise(ctx context.Context, complete completeFunc, set *prompts.Set, data prompts.Data, maxRounds int) (*Refinement, error) {
+	if maxRounds <= 0 {
+		maxRounds = DefaultCritiqueRounds
+	}
+
+	res := &Refinement{
+		Text:           data.Draft,
+		PromptTemplate: set.ID(prompts.Critique) + "+" + set.ID(prompts.Revise),
+	}
+
+	for n := 1; n <= maxRounds; n++ {
+		data.Draft = res.Text
+		data.Critique = ""
+
+		prompt, err := set.Render(prompts.Critique, data)
+		if err != nil {
+			return nil, err
+		}
+		response, err := complete(ctx, prompt, true)
+		if err != nil {
+			return nil, err
+		}
+
+		round := Round{Number: n}
+		issues, err := parseCritique(response)
+		if err != nil {
+			round.Err = err.Error()
+			res.Rounds = append(res.Rounds, round)
+			break
+		}
+		round.Issues = issues
+		if len(issues) == 0 {
+			res.Rounds = append(res.Rounds, round)
+			res.Converged = true
+			break
+		}
+
+		data.Critique = FormatIssues(issues)
+		prompt, err = set.Render(prompts.Revise, data)
+		if err != nil {
+			return nil, err
+		}
+		response, err = complete(ctx, prompt, false)
+		if err != nil {
+			return nil, err
+		}
+
+		revised := postprocess.Clean(response)
+		if revised == "" || revised == res.Text {
+			round.Err = "revision made no changes"
+			res.Rounds = append(res.Rounds, round)
+			break
+		}
+		round.Revised = revised
+		res.Rounds = append(res.Rounds, round)
+		res.Text = revised
+	}
+	return res, nil
+}
+
+// FormatIssues renders issues one per line, as shown to the reviser and in
+// reports.
+func FormatIssues(issues []Issue) string {
+	lines := make([]string, 0, len(issues))
+	for _, is := range issues {
+		line := "- [" + is.Category + "]"
+		if is.Span != "" {
+			line += fmt.Sprintf(" %q:", is.Span)
+		}
+		line += " " + is.Problem
+		if is.Suggestion != "" {
+			line += " → " + is.Suggestion
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseCritique(response string) ([]Issue, error) {
+	response = strings.TrimSpace(response)
+	if start, end := strings.Index(response, "{"), strings.LastIndex(response, "}"); start >= 0 && end > start {
+		response = response[start : end+1]
+	}
+
+	var parsed struct {
+		Issues []Issue `json:"issues"`
+	}
+	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse critique as JSON: %w", err)
+	}
+
+	issues := parsed.Issues[:0]
+	for _, is := range parsed.Issues {
+		is.Category = strings.ToLower(strings.TrimSpace(is.Category))
+		if strings.TrimSpace(is.Problem) == "" && strings.TrimSpace(is.Span) == "" {
+			continue
+		}
+		issues = append(issues, is)
+	}
+	return issues, nil
+}
@@ -0,0 +1,116 @@
+package refiner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// scriptedOllama serves the given responses in order, repeating the last one,
+// and records whether each request asked for JSON mode.
+func scriptedOllama(calls *atomic.Int32, formats *[]string, replies ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		n := int(calls.Add(1)) - 1
+		*formats = append(*formats, req.Format)
+		if n >= len(replies) {
+			n = len(replies) - 1
+		}
+		json.NewEncoder(w).Encode(ollamaResponse{Response: replies[n]})
+	}))
+}
+
+func TestOllamaRefiner_RefineWithCritique_Converges(t *testing.T) {
+	var calls atomic.Int32
+	var formats []string
+	server := scriptedOllama(&calls, &formats,
+		`{"issues":[{"category":"Awkward","span":"Привіт світе","problem":"unnatural vocative","suggestion":"Привіт, світе"}]}`,
+		"Привіт, світе",
+		`{"issues":[]}`)
+	defer server.Close()
+
+	r := NewOllamaRefiner("llama3.2", server.URL)
+	res, err := r.RefineWithCritique(context.Background(), "en", "uk", "Hello world", "Привіт світе")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Text != "Привіт, світе" {
+		t.Errorf("expected revised text, got %q", res.Text)
+	}
+	if !res.Converged || len(res.Rounds) != 2 {
+		t.Fatalf("expected convergence after 2 rounds, got %+v", res)
+	}
+	if is := res.Rounds[0].Issues; len(is) != 1 || is[0].Category != "awkward" {
+		t.Errorf("expected one normalised issue in round 1, got %+v", is)
+	}
+	if res.Rounds[0].Revised != "Привіт, світе" || len(res.Rounds[1].Issues) != 0 {
+		t.Errorf("unexpected rounds %+v", res.Rounds)
+	}
+	if strings.Join(formats, ",") != "json,,json" {
+		t.Errorf("expected critiques in JSON mode and revisions in text mode, got %v", formats)
+	}
+	if !strings.HasPrefix(res.PromptTemplate, "critique@") || !strings.Contains(res.PromptTemplate, "+revise@") {
+		t.Errorf("unexpected prompt template %q", res.PromptTemplate)
+	}
+}
+
+func TestOllamaRefiner_RefineWithCritique_MaxRounds(t *testing.T) {
+	var calls atomic.Int32
+	revision := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		calls.Add(1)
+		reply := `{"issues":[{"category":"omission","problem":"still missing a word"}]}`
+		if req.Format == "" {
+			revision++
+			reply = strings.Repeat("ще ", revision) + "переклад"
+		}
+		json.NewEncoder(w).Encode(ollamaResponse{Response: reply})
+	}))
+	defer server.Close()
+
+	r := NewOllamaRefiner("llama3.2", server.URL)
+	r.SetMaxRounds(2)
+	res, err := r.RefineWithCritique(context.Background(), "en", "uk", "More translation", "переклад")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Converged || len(res.Rounds) != 2 || calls.Load() != 4 {
+		t.Errorf("expected 2 unconverged rounds and 4 calls, got %d rounds, %d calls", len(res.Rounds), calls.Load())
+	}
+	if res.Text != "ще ще переклад" {
+		t.Errorf("expected the last revision, got %q", res.Text)
+	}
+}
+
+func TestOllamaRefiner_RefineWithCritique_UnparsableCritique(t *testing.T) {
+	var calls atomic.Int32
+	var formats []string
+	server := scriptedOllama(&calls, &formats, "The translation looks fine to me.")
+	defer server.Close()
+
+	res, err := NewOllamaRefiner("llama3.2", server.URL).RefineWithCritique(context.Background(), "en", "uk", "Hello", "Привіт")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Text != "Привіт" || res.Converged || len(res.Rounds) != 1 || res.Rounds[0].Err == "" {
+		t.Errorf("expected draft kept and the round marked failed, got %+v", res)
+	}
+}
+
+func TestFormatIssues(t *testing.T) {
+	got := FormatIssues([]Issue{
+		{Category: "glossary", Span: "Києв", Problem: "wrong term", Suggestion: "Київ"},
+		{Category: "omission", Problem: "second sentence missing"},
+	})
+	want := "- [glossary] \"Києв\": wrong term → Київ\n- [omission] second sentence missing"
+	if got != want {
+		t.Errorf("FormatIssues() = %q, want %q", got, want)
+	}
+}
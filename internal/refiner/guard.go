@@ -0,0 +1,158 @@
+package refiner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/valpere/peretran/internal/metrics"
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+// Default guardrail thresholds.
+const (
+	// DefaultMinLengthRatio and DefaultMaxLengthRatio bound the refined
+	// length relative to the draft, in runes. Outside them the refiner has
+	// most likely summarised or padded the text.
+	DefaultMinLengthRatio = 0.6
+	DefaultMaxLengthRatio = 1.5
+	// DefaultMaxSimilarityDrop is how much lower the refined back-translation
+	// may score against the source (chrF) than the draft's before the
+	// refinement counts as semantic drift.
+	DefaultMaxSimilarityDrop = 0.15
+)
+
+// minLengthCheckRunes is the draft length below which the length ratio is
+// not checked; a few words more or less swing it too much.
+const minLengthCheckRunes = 20
+
+var (
+	reDigits      = regexp.MustCompile(`\d+`)
+	reURL         = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'()\[\]]+[^\s<>"'()\[\].,;:!?]`)
+	rePlaceholder = regexp.MustCompile(`\[PH\d+\]`)
+)
+
+// LanguageValidator reports whether text is written in lang.
+type LanguageValidator interface {
+	IsValid(text, lang string) (bool, error)
+}
+
+// BackTranslateFunc translates text from one language to another; it is
+// used by the guard to detect semantic drift.
+type BackTranslateFunc func(ctx context.Context, text, sourceLang, targetLang string) (string, error)
+
+// Guard checks a refined translation against its draft and rejects
+// refinements that changed more than style: a length far from the draft's,
+// lost numbers, URLs or [PHn] markers, the wrong language, or — when a back
+// translator is set — a meaning that drifted from the source.
+type Guard struct {
+	minLengthRatio float64
+	maxLengthRatio float64
+	validator      LanguageValidator
+
+	backTranslate     BackTranslateFunc
+	maxSimilarityDrop float64
+}
+
+// NewGuard creates a guard with the default thresholds. A nil validator
+// skips the language check.
+func NewGuard(v LanguageValidator) *Guard {
+	return &Guard{
+		minLengthRatio:    DefaultMinLengthRatio,
+		maxLengthRatio:    DefaultMaxLengthRatio,
+		validator:         v,
+		maxSimilarityDrop: DefaultMaxSimilarityDrop,
+	}
+}
+
+// SetLengthRatio sets the accepted refined/draft length range.
+func (g *Guard) SetLengthRatio(min, max float64) {
+	g.minLengthRatio, g.maxLengthRatio = min, max
+}
+
+// SetBackTranslator enables the back-translation check. Both the draft and
+// the refinement are translated back to the source language and scored
+// against the source with chrF; the refinement is rejected when its score
+// is more than maxDrop below the draft's.
+func (g *Guard) SetBackTranslator(fn BackTranslateFunc, maxDrop float64) {
+	g.backTranslate = fn
+	g.maxSimilarityDrop = maxDrop
+}
+
+// Check returns nil when refined is an acceptable replacement for draft,
+// or an error naming the first failed guardrail. The cheap checks run
+// first; back-translation runs only when they pass. It is skipped when
+// sourceLang is unknown.
+func (g *Guard) Check(ctx context.Context, sourceLang, targetLang, sourceText, draft, refined string) error {
+	if refined == draft {
+		return nil
+	}
+
+	if n := len([]rune(draft)); n >= minLengthCheckRunes {
+		ratio := float64(len([]rune(refined))) / float64(n)
+		if ratio < g.minLengthRatio || ratio > g.maxLengthRatio {
+			return fmt.Errorf("length ratio %.2f is outside %.2f–%.2f of the draft", ratio, g.minLengthRatio, g.maxLengthRatio)
+		}
+	}
+
+	if missing := placeholder.MissingFrom(draft, refined); len(missing) > 0 {
+		return fmt.Errorf("placeholder markers dropped: %s", strings.Join(missing, ", "))
+	}
+
+	if missing := missingTokens(reDigits, rePlaceholder.ReplaceAllString(draft, " "), rePlaceholder.ReplaceAllString(refined, " ")); len(missing) > 0 {
+		return fmt.Errorf("numbers changed or dropped: %s", strings.Join(missing, ", "))
+	}
+
+	if missing := missingTokens(reURL, draft, refined); len(missing) > 0 {
+		return fmt.Errorf("URLs changed or dropped: %s", strings.Join(missing, ", "))
+	}
+
+	if g.validator != nil {
+		if ok, err := g.validator.IsValid(refined, targetLang); !ok {
+			return fmt.Errorf("not in %s: %v", targetLang, err)
+		}
+	}
+
+	if g.backTranslate != nil && sourceLang != "" && sourceLang != "auto" {
+		return g.checkBackTranslation(ctx, sourceLang, targetLang, sourceText, draft, refined)
+	}
+	return nil
+}
+
+func (g *Guard) checkBackTranslation(ctx context.Context, sourceLang, targetLang, sourceText, draft, refined string) error {
+	backDraft, err := g.backTranslate(ctx, draft, targetLang, sourceLang)
+	if err != nil {
+		return fmt.Errorf("back-translation of the draft failed: %w", err)
+	}
+	backRefined, err := g.backTranslate(ctx, refined, targetLang, sourceLang)
+	if err != nil {
+		return fmt.Errorf("back-translation of the refinement failed: %w", err)
+	}
+
+	draftSim := metrics.ChrF(backDraft, sourceText)
+	refinedSim := metrics.ChrF(backRefined, sourceText)
+	if refinedSim < draftSim-g.maxSimilarityDrop {
+		return fmt.Errorf("back-translation similarity fell from %.2f to %.2f", draftSim, refinedSim)
+	}
+	return nil
+}
+
+// missingTokens returns the matches of re in draft that refined lacks,
+// counting repeats, so "5 ... 5" → "5" reports one missing "5".
+func missingTokens(re *regexp.Regexp, draft, refined string) []string {
+	have := make(map[string]int)
+	for _, tok := range re.FindAllString(refined, -1) {
+		have[tok]++
+	}
+
+	var missing []string
+	for _, tok := range re.FindAllString(draft, -1) {
+		if have[tok] > 0 {
+			have[tok]--
+			continue
+		}
+		missing = append(missing, tok)
+	}
+	return missing
+}
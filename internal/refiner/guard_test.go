@@ -0,0 +1,82 @@
+package refiner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type stubValidator struct{ ok bool }
+
+func (v stubValidator) IsValid(text, lang string) (bool, error) {
+	if v.ok {
+		return true, nil
+	}
+	return false, fmt.Errorf("expected %s but detected en", lang)
+}
+
+func TestGuard_Check(t *testing.T) {
+	const draft = "Зустріч відбудеться 12.05.2024 о 15:30 у залі [PH0]А[PH1], деталі на https://example.com/meet."
+
+	tests := []struct {
+		name    string
+		refined string
+		v       LanguageValidator
+		wantErr string
+	}{
+		{"unchanged", draft, stubValidator{false}, ""},
+		{"polished", "Зустріч пройде 12.05.2024 о 15:30 у залі [PH0]А[PH1]; подробиці — на https://example.com/meet.", stubValidator{true}, ""},
+		{"summarised", "Зустріч 12.05.2024.", nil, "length ratio"},
+		{"padded", draft + " " + draft, nil, "length ratio"},
+		{"dropped marker", "Зустріч відбудеться 12.05.2024 о 15:30 у залі А[PH1], деталі на https://example.com/meet.", nil, "[PH0]"},
+		{"changed number", "Зустріч відбудеться 12.05.2024 о 16:30 у залі [PH0]А[PH1], деталі на https://example.com/meet.", nil, "numbers changed or dropped: 15"},
+		{"changed URL", "Зустріч відбудеться 12.05.2024 о 15:30 у залі [PH0]А[PH1], деталі на https://example.org/meet.", nil, "URLs changed"},
+		{"wrong language", "The meeting will take place on 12.05.2024 at 15:30 in hall [PH0]A[PH1], details at https://example.com/meet.", stubValidator{false}, "not in uk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewGuard(tt.v).Check(context.Background(), "en", "uk", "The meeting is on 12.05.2024 at 15:30.", draft, tt.refined)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGuard_BackTranslation(t *testing.T) {
+	const source = "The cat sat on the mat."
+	back := map[string]string{
+		"Кіт сидів на килимку.":     "The cat sat on the mat.",
+		"Кіт сидів на маті.":        "The cat was sitting on the mat.",
+		"Собака бігав по подвір'ю.": "The dog ran around the yard.",
+	}
+	fn := func(ctx context.Context, text, from, to string) (string, error) {
+		if from != "uk" || to != "en" {
+			t.Errorf("unexpected direction %s→%s", from, to)
+		}
+		return back[text], nil
+	}
+
+	g := NewGuard(nil)
+	g.SetBackTranslator(fn, DefaultMaxSimilarityDrop)
+	if err := g.Check(context.Background(), "en", "uk", source, "Кіт сидів на маті.", "Кіт сидів на килимку."); err != nil {
+		t.Errorf("unexpected error for a faithful refinement: %v", err)
+	}
+	err := g.Check(context.Background(), "en", "uk", source, "Кіт сидів на килимку.", "Собака бігав по подвір'ю.")
+	if err == nil || !strings.Contains(err.Error(), "back-translation similarity") {
+		t.Errorf("expected semantic drift error, got %v", err)
+	}
+
+	// Unknown source language: back-translation is skipped.
+	if err := g.Check(context.Background(), "auto", "uk", source, "Кіт сидів на килимку.", "Собака бігав по подвір'ю."); err != nil {
+		t.Errorf("expected no back-translation without a source language, got %v", err)
+	}
+}
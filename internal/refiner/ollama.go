@@ -7,12 +7,12 @@ import (
 	"fmt"
 	"net/http"
 	"time"
-
-	"github.com/valpere/peretran/internal/postprocess"
 )
 
 // OllamaRefiner uses a local Ollama model as a literary editor for Stage 2.
 type OllamaRefiner struct {
+	config
+
 	model   string
 	baseURL string
 	client  *http.Client
@@ -22,6 +22,7 @@ type ollamaRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
 	Stream bool   `json:"stream"`
+	Format string `json:"format,omitempty"`
 }
 
 type ollamaResponse struct {
@@ -40,12 +41,35 @@ func NewOllamaRefiner(model, baseURL string) *OllamaRefiner {
 // Refine sends the draft to the LLM with a literary-editor prompt and returns
 // the polished translation.
 func (r *OllamaRefiner) Refine(ctx context.Context, sourceLang, targetLang, sourceText, draftText string) (string, error) {
-	prompt := buildRefinementPrompt(sourceLang, targetLang, sourceText, draftText)
+	return r.refineOnce(ctx, r.Complete, sourceLang, targetLang, sourceText, draftText)
+}
 
+// RefineWithCritique refines the draft iteratively: the model critiques the
+// translation as a list of issues, then revises it against the critique,
+// until a critique is empty or the round limit is reached.
+func (r *OllamaRefiner) RefineWithCritique(ctx context.Context, sourceLang, targetLang, sourceText, draftText string) (*Refinement, error) {
+	return r.refineWithCritique(ctx, r.Complete, sourceLang, targetLang, sourceText, draftText)
+}
+
+// Complete sends prompt to the model and returns its raw reply; jsonMode
+// requests a JSON object. It serves other LLM passes that reuse the
+// refiner model, such as the document consistency pass.
+func (r *OllamaRefiner) Complete(ctx context.Context, prompt string, jsonMode bool) (string, error) {
+	format := ""
+	if jsonMode {
+		format = "json"
+	}
+	return r.generate(ctx, prompt, format)
+}
+
+// generate sends prompt to Ollama and returns the raw response. A format of
+// "json" enables Ollama's JSON mode.
+func (r *OllamaRefiner) generate(ctx context.Context, prompt, format string) (string, error) {
 	reqBody := ollamaRequest{
 		Model:  r.model,
 		Prompt: prompt,
 		Stream: false,
+		Format: format,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -73,55 +97,5 @@ func (r *OllamaRefiner) Refine(ctx context.Context, sourceLang, targetLang, sour
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return "", fmt.Errorf("failed to decode refinement response: %w", err)
 	}
-
-	refined := postprocess.Clean(ollamaResp.Response)
-	if refined == "" {
-		return draftText, nil
-	}
-	return refined, nil
-}
-
-func buildRefinementPrompt(sourceLang, targetLang, sourceText, draftText string) string {
-	return fmt.Sprintf(`You are an elite %s literary editor and prose stylist.
-
-# YOUR TASK: REFINE AND POLISH
-
-You will receive a DRAFT %s translation that needs improvement.
-Your job is to REWRITE it with perfect literary %s style.
-
-ORIGINAL (%s):
-%s
-
-DRAFT TRANSLATION (%s):
-%s
-
-# REFINEMENT PRINCIPLES
-
-**Priority:**
-1. Natural flow - Sentences should flow beautifully
-2. Idiomatic expressions - Use natural %s idioms
-3. Elegant word choice - Select refined vocabulary
-4. Rhythm and cadence - Pleasant reading rhythm
-5. Preserve meaning - Keep original meaning intact
-
-**What to Fix:**
-- Awkward literal translations → Natural expressions
-- Repetitive vocabulary → Rich, varied word choices
-- Unnatural word order → Proper syntax
-
-**What to Preserve:**
-- All factual content and meaning
-- Character names and proper nouns
-- Technical terms (if any)
-
-CRITICAL: If the draft is already good, return it unchanged.
-
-Output ONLY the refined translation in %s. Do not include any explanation.`,
-		targetLang,
-		targetLang, targetLang,
-		sourceLang, sourceText,
-		targetLang, draftText,
-		targetLang,
-		targetLang,
-	)
+	return ollamaResp.Response, nil
 }
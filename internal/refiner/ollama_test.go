@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/valpere/peretran/internal/prompts"
 )
 
 func TestOllamaRefiner_New(t *testing.T) {
@@ -85,7 +88,11 @@ func TestOllamaRefiner_Refine_InvalidJSON(t *testing.T) {
 }
 
 func TestBuildRefinementPrompt(t *testing.T) {
-	prompt := buildRefinementPrompt("en", "uk", "Hello", "Draft translation")
+	var c config
+	prompt, err := c.prompts.Render(prompts.Refine, c.data("en", "uk", "Hello", "Draft translation"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	if len(prompt) == 0 {
 		t.Error("expected non-empty prompt")
@@ -96,7 +103,24 @@ func TestBuildRefinementPrompt(t *testing.T) {
 	}
 }
 
+func TestBuildRefinementPrompt_GlossaryAndInstructions(t *testing.T) {
+	var c config
+	c.SetGlossary(map[string]string{"Kyiv": "Київ"})
+	c.SetInstructions("Preserve all [PHn] markers exactly as they appear.")
+
+	prompt, err := c.prompts.Render(prompts.Refine, c.data("en", "uk", "Visit [PH0]Kyiv[PH1]", "Відвідайте [PH0]Київ[PH1]"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Kyiv → Київ", "Preserve all [PHn] markers"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+}
+
 func TestRefinerInterface(t *testing.T) {
 	// Verify OllamaRefiner satisfies the Refiner interface
 	var _ Refiner = (*OllamaRefiner)(nil)
+	var _ LLMRefiner = (*OllamaRefiner)(nil)
 }
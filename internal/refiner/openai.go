@@ -0,0 +1,138 @@
+package refiner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// OpenAIRefiner refines drafts with any OpenAI-compatible chat completions
+// endpoint (OpenAI, OpenRouter, vLLM, LM Studio, Ollama's /v1).
+type OpenAIRefiner struct {
+	config
+
+	model   string
+	baseURL string
+	apiKey  string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewOpenAIRefiner creates a refiner for an OpenAI-compatible endpoint.
+// An empty baseURL means the OpenAI API.
+func NewOpenAIRefiner(model, baseURL, apiKey string) *OpenAIRefiner {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &OpenAIRefiner{
+		model:   model,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// NewOpenRouterRefiner creates a refiner backed by OpenRouter.
+// An empty baseURL means the public OpenRouter API.
+func NewOpenRouterRefiner(model, baseURL, apiKey string) *OpenAIRefiner {
+	if baseURL == "" {
+		baseURL = "https://openrouter.ai/api/v1"
+	}
+	r := NewOpenAIRefiner(model, baseURL, apiKey)
+	r.headers = map[string]string{
+		"HTTP-Referer": "https://peretran.local",
+		"X-Title":      "PereTran",
+	}
+	return r
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string                 `json:"model"`
+	Messages       []chatMessage          `json:"messages"`
+	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// Refine sends the draft to the model with a literary-editor prompt and
+// returns the polished translation.
+func (r *OpenAIRefiner) Refine(ctx context.Context, sourceLang, targetLang, sourceText, draftText string) (string, error) {
+	if r.apiKey == "" {
+		return "", fmt.Errorf("refiner API key required")
+	}
+	return r.refineOnce(ctx, r.Complete, sourceLang, targetLang, sourceText, draftText)
+}
+
+// RefineWithCritique refines the draft through critique-and-revise rounds;
+// see OllamaRefiner.RefineWithCritique.
+func (r *OpenAIRefiner) RefineWithCritique(ctx context.Context, sourceLang, targetLang, sourceText, draftText string) (*Refinement, error) {
+	if r.apiKey == "" {
+		return nil, fmt.Errorf("refiner API key required")
+	}
+	return r.refineWithCritique(ctx, r.Complete, sourceLang, targetLang, sourceText, draftText)
+}
+
+// Complete sends prompt as a single user message and returns the first
+// choice's content. jsonMode requests a JSON object response.
+func (r *OpenAIRefiner) Complete(ctx context.Context, prompt string, jsonMode bool) (string, error) {
+	if r.apiKey == "" {
+		return "", fmt.Errorf("refiner API key required")
+	}
+	reqBody := chatRequest{
+		Model:    r.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	}
+	if jsonMode {
+		reqBody.ResponseFormat = map[string]interface{}{"type": "json_object"}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal refinement request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/chat/completions", r.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create refinement request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.apiKey))
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("refinement request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("refiner returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode refinement response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("refiner returned no choices")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
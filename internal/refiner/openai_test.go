@@ -0,0 +1,94 @@
+package refiner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// chatReply builds a chat completions response with one choice.
+func chatReply(content string) map[string]interface{} {
+	return map[string]interface{}{
+		"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+	}
+}
+
+func TestOpenAIRefiner_New_Defaults(t *testing.T) {
+	r := NewOpenAIRefiner("gpt-4o-mini", "", "key")
+	if !strings.Contains(r.baseURL, "api.openai.com") {
+		t.Errorf("expected default OpenAI base URL, got %q", r.baseURL)
+	}
+
+	or := NewOpenRouterRefiner("qwen/qwen2.5-72b-instruct:free", "", "key")
+	if !strings.Contains(or.baseURL, "openrouter.ai") {
+		t.Errorf("expected default OpenRouter base URL, got %q", or.baseURL)
+	}
+	if or.headers["X-Title"] == "" {
+		t.Error("expected OpenRouter attribution headers")
+	}
+}
+
+func TestOpenAIRefiner_Refine_NoAPIKey(t *testing.T) {
+	if _, err := NewOpenAIRefiner("gpt-4o-mini", "", "").Refine(context.Background(), "en", "uk", "Hello", "Привіт"); err == nil {
+		t.Error("expected error without API key")
+	}
+}
+
+func TestOpenAIRefiner_Refine_SendsGlossary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ResponseFormat != nil {
+			t.Error("expected free-text response for literary refinement")
+		}
+		if len(req.Messages) != 1 || !strings.Contains(req.Messages[0].Content, "Kyiv → Київ") {
+			t.Errorf("expected glossary in the prompt, got %+v", req.Messages)
+		}
+		json.NewEncoder(w).Encode(chatReply("Ласкаво просимо до Києва"))
+	}))
+	defer server.Close()
+
+	r := NewOpenAIRefiner("gpt-4o-mini", server.URL, "test-key")
+	r.SetGlossary(map[string]string{"Kyiv": "Київ"})
+	got, err := r.Refine(context.Background(), "en", "uk", "Welcome to Kyiv", "Вітаємо в Києві")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "Ласкаво просимо до Києва" {
+		t.Errorf("unexpected refinement %q", got)
+	}
+}
+
+func TestOpenAIRefiner_RefineWithCritique_JSONMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ResponseFormat == nil || req.ResponseFormat["type"] != "json_object" {
+			t.Errorf("expected JSON mode for the critique, got %v", req.ResponseFormat)
+		}
+		json.NewEncoder(w).Encode(chatReply(`{"issues": []}`))
+	}))
+	defer server.Close()
+
+	res, err := NewOpenRouterRefiner("qwen/qwen2.5-72b-instruct:free", server.URL, "test-key").
+		RefineWithCritique(context.Background(), "en", "uk", "Hello", "Привіт")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Converged || res.Text != "Привіт" {
+		t.Errorf("expected unchanged draft after an empty critique, got %+v", res)
+	}
+}
+
+func TestOpenAIRefinerInterface(t *testing.T) {
+	var _ LLMRefiner = (*OpenAIRefiner)(nil)
+}
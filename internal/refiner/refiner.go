@@ -2,9 +2,114 @@
 // It takes a draft translation and refines it for literary quality using an LLM.
 package refiner
 
-import "context"
+import (
+	"context"
+
+	"github.com/valpere/peretran/internal/postprocess"
+	"github.com/valpere/peretran/internal/prompts"
+	"github.com/valpere/peretran/internal/style"
+)
 
 // Refiner reviews and improves a draft translation for literary quality.
 type Refiner interface {
 	Refine(ctx context.Context, sourceLang, targetLang, sourceText, draftText string) (string, error)
 }
+
+// LLMRefiner is implemented by the LLM-backed refiners. Both refinement
+// modes are available, and the setters configure what the prompts carry.
+type LLMRefiner interface {
+	Refiner
+	CritiqueRefiner
+
+	SetPrompts(p *prompts.Set)
+	SetStyle(p *style.Profile)
+	SetGlossary(terms map[string]string)
+	SetInstructions(instructions string)
+	SetMaxRounds(n int)
+	PromptTemplate() string
+
+	// Complete sends a prompt to the refiner model and returns its raw
+	// reply; jsonMode requests a JSON object.
+	Complete(ctx context.Context, prompt string, jsonMode bool) (string, error)
+}
+
+// config holds the prompt settings shared by the LLM refiners; it is
+// embedded so every backend exposes the same setters.
+type config struct {
+	prompts      *prompts.Set
+	style        *style.Profile
+	glossary     map[string]string
+	instructions string
+	maxRounds    int
+}
+
+// SetPrompts sets the prompt templates; nil means the embedded defaults.
+func (c *config) SetPrompts(p *prompts.Set) {
+	c.prompts = p
+}
+
+// SetStyle sets the style profile the refined translation must follow.
+func (c *config) SetStyle(p *style.Profile) {
+	c.style = p
+}
+
+// SetGlossary sets the required terminology. The refinement prompts list
+// it so the refiner does not undo terms enforced in Stage 1, and the
+// critique step reports violations.
+func (c *config) SetGlossary(terms map[string]string) {
+	c.glossary = terms
+}
+
+// SetInstructions sets extra instructions for the refinement prompts, e.g.
+// the placeholder-preservation hint when the draft contains [PHn] markers.
+func (c *config) SetInstructions(instructions string) {
+	c.instructions = instructions
+}
+
+// SetMaxRounds sets the maximum number of critique-and-revise rounds run by
+// RefineWithCritique; 0 means DefaultCritiqueRounds.
+func (c *config) SetMaxRounds(n int) {
+	c.maxRounds = n
+}
+
+// PromptTemplate identifies the refinement prompt template in use.
+func (c *config) PromptTemplate() string {
+	return c.prompts.ID(prompts.Refine)
+}
+
+func (c *config) data(sourceLang, targetLang, sourceText, draftText string) prompts.Data {
+	return prompts.Data{
+		SourceLang:   sourceLang,
+		TargetLang:   targetLang,
+		Source:       sourceText,
+		Draft:        draftText,
+		Glossary:     c.glossary,
+		Instructions: c.instructions,
+		StyleGuide:   c.style.Guide(),
+	}
+}
+
+// refineOnce runs the single-pass literary refinement shared by the LLM
+// refiners. An empty reply keeps the draft.
+func (c *config) refineOnce(ctx context.Context, complete completeFunc, sourceLang, targetLang, sourceText, draftText string) (string, error) {
+	prompt, err := c.prompts.Render(prompts.Refine, c.data(sourceLang, targetLang, sourceText, draftText))
+	if err != nil {
+		return "", err
+	}
+
+	response, err := complete(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+
+	refined := postprocess.Clean(response)
+	if refined == "" {
+		return draftText, nil
+	}
+	return refined, nil
+}
+
+// refineWithCritique runs critiqueAndRevise with the configured prompts.
+func (c *config) refineWithCritique(ctx context.Context, complete completeFunc, sourceLang, targetLang, sourceText, draftText string) (*Refinement, error) {
+	return critiqueAndRevise(ctx, complete, c.prompts, c.data(sourceLang, targetLang, sourceText, draftText), c.maxRounds)
+}
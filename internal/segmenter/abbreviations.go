@@ -0,0 +1,78 @@
+package segmenter
+
+// abbreviations lists, per language, words that are followed by a dot
+// without ending the sentence. Entries are lower case and omit the final
+// dot; inner dots are kept ("e.g", "z.b").
+var abbreviations = map[string][]string{
+	"en": {
+		"mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "mt", "vs", "etc", "e.g", "i.e",
+		"cf", "al", "fig", "figs", "no", "nos", "vol", "vols", "pp", "ch", "ed", "eds",
+		"inc", "ltd", "co", "corp", "dept", "est", "approx", "ave", "blvd", "rd",
+		"gen", "col", "lt", "sgt", "capt", "rev", "hon", "gov", "sen", "rep",
+		"jan", "feb", "mar", "apr", "jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec",
+		"u.s", "u.k", "a.m", "p.m", "ph.d",
+	},
+	"uk": {
+		"т", "д", "п", "ін", "інш", "т.д", "т.п", "т.і", "див", "напр", "наприкл",
+		"вул", "просп", "пл", "буд", "кв", "м", "с", "смт", "обл", "р", "рр", "ст", "стор",
+		"проф", "доц", "акад", "канд", "ім", "тис", "млн", "млрд", "грн", "коп", "хв", "год",
+		"рис", "табл", "гл", "зокр", "англ", "нім", "франц", "лат",
+	},
+	"ru": {
+		"т", "д", "п", "е", "к", "др", "пр", "т.е", "т.к", "т.д", "т.п", "см", "напр",
+		"г", "гг", "ул", "пл", "пер", "кв", "им", "проф", "доц", "акад", "стр", "рис", "табл",
+		"тыс", "млн", "млрд", "руб", "коп", "мин", "сек", "ч", "англ", "нем", "франц", "лат",
+	},
+	"de": {
+		"z.b", "bzw", "usw", "u.a", "d.h", "ca", "dr", "prof", "nr", "str", "hr", "fr",
+		"vgl", "ggf", "evtl", "inkl", "zzgl", "abs", "jh", "mio", "mrd", "bspw", "etc",
+		"s", "sog", "u.ä", "o.ä", "z.t", "dt", "geb", "gest", "tel",
+		"jan", "feb", "mär", "apr", "jun", "jul", "aug", "sep", "sept", "okt", "nov", "dez",
+	},
+	"fr": {
+		"m", "mm", "mme", "mmes", "mlle", "dr", "pr", "me", "st", "ste", "etc", "cf", "p.ex",
+		"av", "bd", "env", "chap", "p", "vol", "n°", "no", "éd",
+		"janv", "févr", "avr", "juil", "sept", "oct", "nov", "déc",
+	},
+	"es": {
+		"sr", "sra", "srta", "dr", "dra", "d", "dña", "ud", "uds", "vd", "vds", "etc", "p.ej",
+		"pág", "págs", "núm", "av", "avda", "ej", "aprox", "ee.uu", "cap", "vol", "tel",
+		"ene", "feb", "mar", "abr", "jun", "jul", "ago", "sept", "oct", "nov", "dic",
+	},
+	"it": {
+		"sig", "sigg", "sig.ra", "dott", "dott.ssa", "prof", "ing", "avv", "arch", "geom",
+		"ecc", "es", "pag", "pagg", "n", "cap", "vol", "tel", "ca", "s.p.a",
+		"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic",
+	},
+	"pt": {
+		"sr", "sra", "srta", "dr", "dra", "prof", "profa", "etc", "p.ex", "pág", "págs",
+		"av", "n", "nº", "cap", "vol", "tel", "aprox", "ex", "eng",
+		"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez",
+	},
+	"pl": {
+		"np", "tzw", "itd", "itp", "dr", "prof", "mgr", "inż", "ul", "al", "pl", "godz",
+		"r", "w", "tj", "m.in", "ok", "zob", "wg", "tys", "mln", "mld", "zł", "gr", "nr", "ds",
+	},
+	"nl": {
+		"dhr", "mevr", "dr", "prof", "mr", "ir", "drs", "ing", "bijv", "bv", "enz", "etc",
+		"o.a", "d.w.z", "i.p.v", "m.b.t", "nr", "blz", "ca", "jl", "vs",
+	},
+	"cs": {
+		"např", "tzv", "atd", "apod", "resp", "tj", "dr", "prof", "ing", "mgr", "ul", "č",
+		"str", "r", "tis", "mil", "mld", "kč", "min", "hod",
+	},
+	"el": {
+		"κ", "κα", "δρ", "καθ", "π.χ", "δηλ", "κ.λπ", "κ.ά", "σελ", "αρ", "τηλ", "λ", "χλμ",
+	},
+	"tr": {
+		"dr", "prof", "doç", "av", "bkz", "vb", "vs", "örn", "sn", "no", "s", "yy", "yd",
+	},
+}
+
+// ordinalDotLanguages write ordinal numbers with a trailing dot ("am 3.
+// Oktober"), so a number followed by a dot does not end the sentence.
+var ordinalDotLanguages = map[string]bool{
+	"de": true, "pl": true, "cs": true, "sk": true, "da": true, "nb": true, "no": true,
+	"fi": true, "hu": true, "hr": true, "sl": true, "sr": true, "tr": true, "lv": true,
+	"et": true, "is": true,
+}
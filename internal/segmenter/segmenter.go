@@ -0,0 +1,246 @@
+// Package segmenter splits text into sentences with per-language rules, in
+// the spirit of SRX: break rules at sentence-ending punctuation, and
+// no-break exceptions for abbreviations, initials, ordinals and ellipses
+// inside a sentence.
+package segmenter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Segmenter splits text into sentences for one language.
+type Segmenter struct {
+	lang          string
+	abbreviations map[string]bool
+	// ordinalDot is true for languages that write ordinals as "3." (de, pl,
+	// cs, ...), where a number followed by a dot does not end a sentence.
+	ordinalDot bool
+	// greekQuestion is true for Greek, where ";" is the question mark.
+	greekQuestion bool
+}
+
+// New returns a segmenter for lang, an ISO 639-1 code optionally followed
+// by a region ("pt-BR"). Unknown or empty languages get the generic rules:
+// every script's terminators and the English abbreviations.
+func New(lang string) *Segmenter {
+	base := strings.ToLower(lang)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+
+	abbrevs, ok := abbreviations[base]
+	if !ok {
+		abbrevs = abbreviations["en"]
+	}
+	s := &Segmenter{
+		lang:          base,
+		abbreviations: make(map[string]bool, len(abbrevs)),
+		ordinalDot:    ordinalDotLanguages[base],
+		greekQuestion: base == "el",
+	}
+	for _, a := range abbrevs {
+		s.abbreviations[a] = true
+	}
+	return s
+}
+
+// Lang returns the base language code the segmenter was created for.
+func (s *Segmenter) Lang() string {
+	return s.lang
+}
+
+// Split returns the sentences of text with surrounding whitespace trimmed.
+// Text without a sentence break is returned as a single sentence; empty
+// text yields none.
+func (s *Segmenter) Split(text string) []string {
+	var sentences []string
+	prev := 0
+	for _, b := range s.Boundaries(text) {
+		if sentence := strings.TrimSpace(text[prev:b]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		prev = b
+	}
+	if rest := strings.TrimSpace(text[prev:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// Boundaries returns the byte offsets in text right after each sentence end
+// (after the terminator and any closing quotes or brackets). The end of text
+// is not reported.
+func (s *Segmenter) Boundaries(text string) []int {
+	var bounds []int
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isFullWidthTerminator(r):
+			end := skipWhile(text, i, func(r rune) bool { return isFullWidthTerminator(r) || isCloser(r) })
+			if end < len(text) {
+				bounds = append(bounds, end)
+			}
+			i = end
+		case s.isTerminator(r):
+			end := skipWhile(text, i, s.isTerminator)
+			punct := text[i:end]
+			end = skipClosers(text, end)
+			if end < len(text) && s.breaksAfter(text, i, punct, end) {
+				bounds = append(bounds, end)
+			}
+			i = end
+		default:
+			i += size
+		}
+	}
+	return bounds
+}
+
+// breaksAfter applies the break and no-break rules to the terminator run
+// punct, which starts at byte start and is followed (after closers) by
+// text[end:].
+func (s *Segmenter) breaksAfter(text string, start int, punct string, end int) bool {
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	if !unicode.IsSpace(next) {
+		// "3.14", "e.g.x", "example.com": no break without whitespace.
+		return false
+	}
+
+	dotsOnly := strings.Trim(punct, ".…") == ""
+	if !dotsOnly {
+		return true
+	}
+
+	// A sentence continuing in lower case was not ended by the dot or
+	// ellipsis: "etc. and", "wait... what".
+	if first, ok := firstLetter(text[end:]); ok && unicode.IsLower(first) {
+		return false
+	}
+
+	if punct != "." {
+		return true
+	}
+	word := wordBefore(text, start)
+	switch {
+	case word == "":
+		return true
+	case s.abbreviations[strings.ToLower(word)]:
+		return false
+	case isInitial(word):
+		return false
+	case s.ordinalDot && isNumber(word):
+		return false
+	}
+	return true
+}
+
+func (s *Segmenter) isTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '‽',
+		'؟',      // Arabic question mark
+		'۔',      // Urdu full stop
+		'।', '॥', // Devanagari danda, double danda
+		'։', // Armenian full stop
+		'።', // Ethiopic full stop
+		'჻': // Georgian paragraph separator
+		return true
+	case ';':
+		return s.greekQuestion
+	}
+	return false
+}
+
+// isFullWidthTerminator reports CJK sentence ends, which need no following
+// whitespace.
+func isFullWidthTerminator(r rune) bool {
+	switch r {
+	case '。', '！', '？', '．', '｡':
+		return true
+	}
+	return false
+}
+
+// isCloser reports closing quotes and brackets that belong to the sentence
+// before a break when they follow the terminator directly. « and ‹ close
+// German quotations (»Hallo.«).
+func isCloser(r rune) bool {
+	switch r {
+	case '"', '\'', '”', '’', '»', '›', '«', '‹', ')', ']', '}', '」', '』', '）', '】', '〉', '》', '〕':
+		return true
+	}
+	return false
+}
+
+// skipClosers returns the byte offset after the closing quotes and brackets
+// at i, including a French closing guillemet set off by a space
+// ("Bonjour. »").
+func skipClosers(text string, i int) int {
+	i = skipWhile(text, i, isCloser)
+	j := skipWhile(text, i, func(r rune) bool { return r == ' ' || r == '\u00a0' || r == '\u202f' })
+	if j > i && j < len(text) {
+		if r, size := utf8.DecodeRuneInString(text[j:]); r == '»' || r == '›' {
+			return skipWhile(text, j+size, isCloser)
+		}
+	}
+	return i
+}
+
+// skipWhile returns the byte offset of the first rune at or after i for
+// which keep is false.
+func skipWhile(text string, i int, keep func(rune) bool) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !keep(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// wordBefore returns the run of letters, digits and inner dots ending at
+// byte i, e.g. "e.g" for "(e.g." or "U.S" for "U.S.".
+func wordBefore(text string, i int) string {
+	j := i
+	for j > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:j])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' {
+			break
+		}
+		j -= size
+	}
+	return strings.Trim(text[j:i], ".")
+}
+
+// firstLetter returns the first letter of text, skipping whitespace, quotes
+// and opening brackets; ok is false when something else comes first.
+func firstLetter(text string) (rune, bool) {
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			return r, true
+		case unicode.IsSpace(r) || unicode.In(r, unicode.Pi, unicode.Ps) || r == '"' || r == '\'':
+			continue
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// isInitial reports a single upper-case letter, as in "J. R. R. Tolkien".
+func isInitial(word string) bool {
+	r, size := utf8.DecodeRuneInString(word)
+	return size == len(word) && unicode.IsUpper(r)
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return word != ""
+}
@@ -0,0 +1,179 @@
+package segmenter_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/valpere/peretran/internal/segmenter"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		text string
+		want []string
+	}{
+		{
+			name: "en basic",
+			lang: "en",
+			text: "The sun rose. Birds sang! Did you hear them?",
+			want: []string{"The sun rose.", "Birds sang!", "Did you hear them?"},
+		},
+		{
+			name: "en abbreviations and initials",
+			lang: "en",
+			text: "Dr. Smith met J. R. Tolkien, e.g. at the U.S. office. They talked.",
+			want: []string{"Dr. Smith met J. R. Tolkien, e.g. at the U.S. office.", "They talked."},
+		},
+		{
+			name: "en decimals and URLs",
+			lang: "en",
+			text: "Pi is 3.14 approx. Visit example.com today. Done.",
+			want: []string{"Pi is 3.14 approx. Visit example.com today.", "Done."},
+		},
+		{
+			name: "en ellipsis",
+			lang: "en",
+			text: "Wait... what happened? I waited… Nothing came.",
+			want: []string{"Wait... what happened?", "I waited…", "Nothing came."},
+		},
+		{
+			name: "en quotes and mixed punctuation",
+			lang: "en",
+			text: `He asked "Really?!" She nodded.`,
+			want: []string{`He asked "Really?!"`, "She nodded."},
+		},
+		{
+			name: "en lower-case continuation",
+			lang: "en",
+			text: "Apples, pears, etc. and more. Next one.",
+			want: []string{"Apples, pears, etc. and more.", "Next one."},
+		},
+		{
+			name: "uk abbreviations",
+			lang: "uk",
+			text: "Київ, вул. Хрещатик, буд. 1. Проф. Іваненко прийшов о 10 год. Він читав лекцію.",
+			want: []string{"Київ, вул. Хрещатик, буд. 1.", "Проф. Іваненко прийшов о 10 год. Він читав лекцію."},
+		},
+		{
+			name: "uk т. д.",
+			lang: "uk",
+			text: "Яблука, груші і т. д. тощо. Кінець!",
+			want: []string{"Яблука, груші і т. д. тощо.", "Кінець!"},
+		},
+		{
+			name: "ru abbreviations",
+			lang: "ru",
+			text: "Это было в 1990 г. в Москве, т.е. давно. Мы помним?",
+			want: []string{"Это было в 1990 г. в Москве, т.е. давно.", "Мы помним?"},
+		},
+		{
+			name: "de ordinals and abbreviations",
+			lang: "de",
+			text: "Am 3. Oktober kam Dr. Müller, z.B. mit dem Zug. Es regnete.",
+			want: []string{"Am 3. Oktober kam Dr. Müller, z.B. mit dem Zug.", "Es regnete."},
+		},
+		{
+			name: "fr guillemets",
+			lang: "fr",
+			text: "M. Dupont a dit « Bonjour. » Puis il est parti.",
+			want: []string{"M. Dupont a dit « Bonjour. »", "Puis il est parti."},
+		},
+		{
+			name: "es inverted marks",
+			lang: "es",
+			text: "¿Dónde está la Sra. García? ¡Aquí! Vive en la Avda. Sol.",
+			want: []string{"¿Dónde está la Sra. García?", "¡Aquí!", "Vive en la Avda. Sol."},
+		},
+		{
+			name: "zh full-width",
+			lang: "zh",
+			text: "今天天气很好。我们去公园吧！你来吗？",
+			want: []string{"今天天气很好。", "我们去公园吧！", "你来吗？"},
+		},
+		{
+			name: "ja with closing bracket",
+			lang: "ja",
+			text: "彼は「行きます。」と言った。雨だった。",
+			want: []string{"彼は「行きます。」", "と言った。", "雨だった。"},
+		},
+		{
+			name: "ko",
+			lang: "ko",
+			text: "안녕하세요. 만나서 반갑습니다! 어디 가세요?",
+			want: []string{"안녕하세요.", "만나서 반갑습니다!", "어디 가세요?"},
+		},
+		{
+			name: "ar question mark",
+			lang: "ar",
+			text: "كيف حالك؟ أنا بخير. شكرا!",
+			want: []string{"كيف حالك؟", "أنا بخير.", "شكرا!"},
+		},
+		{
+			name: "hi danda",
+			lang: "hi",
+			text: "मैं घर जा रहा हूँ। तुम कहाँ हो? आओ॥",
+			want: []string{"मैं घर जा रहा हूँ।", "तुम कहाँ हो?", "आओ॥"},
+		},
+		{
+			name: "el question mark",
+			lang: "el",
+			text: "Πού είσαι; Είμαι σπίτι.",
+			want: []string{"Πού είσαι;", "Είμαι σπίτι."},
+		},
+		{
+			name: "semicolon outside Greek",
+			lang: "en",
+			text: "First part; second part.",
+			want: []string{"First part; second part."},
+		},
+		{
+			name: "region code",
+			lang: "pt-BR",
+			text: "O Sr. Silva chegou. Ele sorriu.",
+			want: []string{"O Sr. Silva chegou.", "Ele sorriu."},
+		},
+		{
+			name: "no terminator",
+			lang: "en",
+			text: "  just a fragment  ",
+			want: []string{"just a fragment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := segmenter.New(tt.lang).Split(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q)\n got %q\nwant %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit_Empty(t *testing.T) {
+	if got := segmenter.New("en").Split("   "); len(got) != 0 {
+		t.Errorf("expected no sentences, got %q", got)
+	}
+}
+
+func TestBoundaries_EndNotReported(t *testing.T) {
+	text := "One. Two."
+	got := segmenter.New("en").Boundaries(text)
+	if !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("expected a single boundary after %q, got %v", "One.", got)
+	}
+}
+
+func TestNew_UnknownLanguageUsesGenericRules(t *testing.T) {
+	s := segmenter.New("xx")
+	got := s.Split("Mr. Brown left. 他走了。")
+	want := []string{"Mr. Brown left.", "他走了。"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if s.Lang() != "xx" {
+		t.Errorf("expected lang xx, got %q", s.Lang())
+	}
+}
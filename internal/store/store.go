@@ -2,8 +2,12 @@ package store
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +15,7 @@ import (
 	"golang.org/x/text/unicode/norm"
 
 	"github.com/valpere/peretran/internal"
+	"github.com/valpere/peretran/internal/style"
 )
 
 type Store struct {
@@ -49,6 +54,12 @@ func (s *Store) migrate() error {
 		confidence REAL,
 		latency_ms INTEGER,
 		error TEXT,
+		accuracy REAL,
+		fluency REAL,
+		terminology REAL,
+		style REAL,
+		formatting REAL,
+		prompt_template TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (request_id) REFERENCES translation_requests(id)
 	);
@@ -60,6 +71,8 @@ func (s *Store) migrate() error {
 		final_text TEXT NOT NULL,
 		is_composite BOOLEAN DEFAULT FALSE,
 		arbiter_reasoning TEXT,
+		arbiter_prompt TEXT,
+		refiner_prompt TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (request_id) REFERENCES translation_requests(id)
 	);
@@ -76,7 +89,9 @@ func (s *Store) migrate() error {
 		invalidated BOOLEAN DEFAULT FALSE,
 		last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(source_text, source_lang, target_lang)
+		refine_key TEXT,
+		context_hash TEXT NOT NULL DEFAULT '',
+		UNIQUE(source_text, source_lang, target_lang, context_hash)
 	);
 
 	-- stage1_cache stores primary translation drafts (pre-refinement)
@@ -89,7 +104,8 @@ func (s *Store) migrate() error {
 		service_used TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(source_text, source_lang, target_lang, service_used)
+		settings_key TEXT NOT NULL DEFAULT '',
+		UNIQUE(source_text, source_lang, target_lang, service_used, settings_key)
 	);
 
 	-- csv_checkpoints tracks progress of CSV translation jobs for resume support
@@ -126,14 +142,223 @@ func (s *Store) migrate() error {
 		UNIQUE(source_lang, target_lang, source_term)
 	);
 
+	-- glossary_candidates is the review list of extracted terms with proposed
+	-- translations; accepted ones move to glossary, rejected ones are kept so
+	-- they are not proposed again
+	CREATE TABLE IF NOT EXISTS glossary_candidates (
+		id TEXT PRIMARY KEY,
+		source_lang TEXT NOT NULL,
+		target_lang TEXT NOT NULL,
+		source_term TEXT NOT NULL,
+		proposed_term TEXT NOT NULL,
+		kind TEXT,
+		frequency INTEGER DEFAULT 0,
+		example TEXT,
+		service_used TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(source_lang, target_lang, source_term)
+	);
+
+	-- refinement_rounds stores each critique-and-revise round of iterative
+	-- refinement; critique holds the issues one per line
+	CREATE TABLE IF NOT EXISTS refinement_rounds (
+		id TEXT PRIMARY KEY,
+		request_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		critique TEXT NOT NULL,
+		revised_text TEXT,
+		note TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (request_id) REFERENCES translation_requests(id)
+	);
+
+	-- style_profiles stores named tone and style requirements; forbidden_words
+	-- is newline-separated
+	CREATE TABLE IF NOT EXISTS style_profiles (
+		name TEXT PRIMARY KEY,
+		formality TEXT NOT NULL DEFAULT '',
+		audience TEXT NOT NULL DEFAULT '',
+		register TEXT NOT NULL DEFAULT '',
+		forbidden_words TEXT NOT NULL DEFAULT '',
+		quotes TEXT NOT NULL DEFAULT '',
+		punctuation TEXT NOT NULL DEFAULT '',
+		number_format TEXT NOT NULL DEFAULT '',
+		notes TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_memory_lookup ON translation_memory(source_text, source_lang, target_lang);
 	CREATE INDEX IF NOT EXISTS idx_stage1_lookup ON stage1_cache(source_text, source_lang, target_lang);
 	CREATE INDEX IF NOT EXISTS idx_results_request ON translation_results(request_id);
 	CREATE INDEX IF NOT EXISTS idx_checkpoint_cells ON csv_checkpoint_cells(checkpoint_id);
 	CREATE INDEX IF NOT EXISTS idx_glossary_lookup ON glossary(source_lang, target_lang);
+	CREATE INDEX IF NOT EXISTS idx_refinement_request ON refinement_rounds(request_id);
 	`
 
-	_, err := s.db.Exec(schema)
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema; CREATE TABLE IF NOT EXISTS
+	// leaves databases created by older versions without them.
+	columns := []struct{ table, column, colType string }{
+		{"translation_results", "accuracy", "REAL"},
+		{"translation_results", "fluency", "REAL"},
+		{"translation_results", "terminology", "REAL"},
+		{"translation_results", "style", "REAL"},
+		{"translation_results", "formatting", "REAL"},
+		{"translation_results", "prompt_template", "TEXT"},
+		{"final_translations", "arbiter_prompt", "TEXT"},
+		{"final_translations", "refiner_prompt", "TEXT"},
+		{"translation_memory", "refine_key", "TEXT"},
+		{"stage1_cache", "settings_key", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := s.addColumnIfMissing(c.table, c.column, c.colType); err != nil {
+			return err
+		}
+	}
+	if err := s.migrateMemoryContextKey(); err != nil {
+		return err
+	}
+	return s.migrateStage1SettingsKey()
+}
+
+// migrateMemoryContextKey rebuilds a translation_memory table created before
+// context_hash was part of its unique key. SQLite cannot alter a table
+// constraint, so the rows are copied into a new table.
+func (s *Store) migrateMemoryContextKey() error {
+	var tableSQL string
+	if err := s.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'translation_memory'`).Scan(&tableSQL); err != nil {
+		return err
+	}
+	if strings.Contains(tableSQL, "context_hash") {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`ALTER TABLE translation_memory RENAME TO translation_memory_old`,
+		`CREATE TABLE translation_memory (
+			id TEXT PRIMARY KEY,
+			source_text TEXT NOT NULL,
+			source_lang TEXT NOT NULL,
+			target_lang TEXT NOT NULL,
+			final_text TEXT NOT NULL,
+			draft_text TEXT,
+			service_used TEXT,
+			usage_count INTEGER DEFAULT 1,
+			invalidated BOOLEAN DEFAULT FALSE,
+			last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			refine_key TEXT,
+			context_hash TEXT NOT NULL DEFAULT '',
+			UNIQUE(source_text, source_lang, target_lang, context_hash)
+		)`,
+		`INSERT INTO translation_memory (id, source_text, source_lang, target_lang, final_text, draft_text, service_used, usage_count, invalidated, last_used, created_at, refine_key)
+			SELECT id, source_text, source_lang, target_lang, final_text, draft_text, service_used, usage_count, invalidated, last_used, created_at, refine_key FROM translation_memory_old`,
+		`DROP TABLE translation_memory_old`,
+		`CREATE INDEX IF NOT EXISTS idx_memory_lookup ON translation_memory(source_text, source_lang, target_lang)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild translation_memory: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateStage1SettingsKey rebuilds a stage1_cache table created before
+// settings_key was part of its unique key, so drafts saved under different
+// settings no longer replace each other.
+func (s *Store) migrateStage1SettingsKey() error {
+	var tableSQL string
+	if err := s.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'stage1_cache'`).Scan(&tableSQL); err != nil {
+		return err
+	}
+	if strings.Contains(tableSQL, "service_used, settings_key)") {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`ALTER TABLE stage1_cache RENAME TO stage1_cache_old`,
+		`CREATE TABLE stage1_cache (
+			id TEXT PRIMARY KEY,
+			source_text TEXT NOT NULL,
+			source_lang TEXT NOT NULL,
+			target_lang TEXT NOT NULL,
+			draft_text TEXT NOT NULL,
+			service_used TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			settings_key TEXT NOT NULL DEFAULT '',
+			UNIQUE(source_text, source_lang, target_lang, service_used, settings_key)
+		)`,
+		`INSERT INTO stage1_cache (id, source_text, source_lang, target_lang, draft_text, service_used, created_at, last_used, settings_key)
+			SELECT id, source_text, source_lang, target_lang, draft_text, service_used, created_at, last_used, COALESCE(settings_key, '') FROM stage1_cache_old`,
+		`DROP TABLE stage1_cache_old`,
+		`CREATE INDEX IF NOT EXISTS idx_stage1_lookup ON stage1_cache(source_text, source_lang, target_lang)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild stage1_cache: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ContextHash returns the cache key part identifying the source text around
+// a segment, so the same sentence translated in different contexts is
+// cached separately. It is "" when there is no context.
+func ContextHash(before, after string) string {
+	before, after = normalizeText(before), normalizeText(after)
+	if before == "" && after == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(before + "\x00" + after))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *Store) addColumnIfMissing(table, column, colType string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			ctype     string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, colType))
 	return err
 }
 
@@ -152,6 +377,163 @@ func (s *Store) SaveResult(ctx context.Context, requestID, serviceName, translat
 	return err
 }
 
+// SaveResultScores records the arbiter's rubric scores for a result saved
+// with SaveResult. Dimensions that were not scored are stored as NULL.
+func (s *Store) SaveResultScores(ctx context.Context, requestID, serviceName string, scores internal.RubricScores) error {
+	id := fmt.Sprintf("%s_%s", requestID, serviceName)
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE translation_results SET accuracy = NULLIF(?, 0), fluency = NULLIF(?, 0), terminology = NULLIF(?, 0), style = NULLIF(?, 0), formatting = NULLIF(?, 0) WHERE id = ?`,
+		scores.Accuracy, scores.Fluency, scores.Terminology, scores.Style, scores.Formatting, id)
+	return err
+}
+
+// SaveResultPromptTemplate records which prompt template (see
+// prompts.Set.ID) produced a result saved with SaveResult.
+func (s *Store) SaveResultPromptTemplate(ctx context.Context, requestID, serviceName, promptTemplate string) error {
+	id := fmt.Sprintf("%s_%s", requestID, serviceName)
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE translation_results SET prompt_template = ? WHERE id = ?`,
+		promptTemplate, id)
+	return err
+}
+
+// SaveFinalPromptTemplates records the arbiter and refiner prompt templates
+// behind a translation saved with SaveFinalTranslation. Empty values mean
+// the stage did not run.
+func (s *Store) SaveFinalPromptTemplates(ctx context.Context, requestID, arbiterPrompt, refinerPrompt string) error {
+	id := fmt.Sprintf("%s_final", requestID)
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE final_translations SET arbiter_prompt = NULLIF(?, ''), refiner_prompt = NULLIF(?, '') WHERE id = ?`,
+		arbiterPrompt, refinerPrompt, id)
+	return err
+}
+
+// RefinementRound is one stored critique-and-revise round.
+type RefinementRound struct {
+	Round       int
+	Critique    string
+	RevisedText string
+	Note        string
+}
+
+// SaveRefinementRound records one round of iterative refinement for a
+// request saved with SaveRequest. An empty revisedText means the round made
+// no revision; note explains why the loop stopped early, if it did.
+func (s *Store) SaveRefinementRound(ctx context.Context, requestID string, round int, critique, revisedText, note string) error {
+	id := fmt.Sprintf("%s_round%d", requestID, round)
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO refinement_rounds (id, request_id, round, critique, revised_text, note)
+		 VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))`,
+		id, requestID, round, critique, revisedText, note)
+	return err
+}
+
+// GetRefinementRounds returns the refinement rounds of a request in order.
+func (s *Store) GetRefinementRounds(ctx context.Context, requestID string) ([]RefinementRound, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT round, critique, COALESCE(revised_text, ''), COALESCE(note, '')
+		 FROM refinement_rounds WHERE request_id = ? ORDER BY round`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rounds []RefinementRound
+	for rows.Next() {
+		var r RefinementRound
+		if err := rows.Scan(&r.Round, &r.Critique, &r.RevisedText, &r.Note); err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, r)
+	}
+	return rounds, rows.Err()
+}
+
+// ServiceQuality is the average rubric score of one service and prompt
+// template over the scored results in a period.
+type ServiceQuality struct {
+	ServiceName string
+	// PromptTemplate is empty for services that do not use a prompt.
+	PromptTemplate string
+	Samples        int
+	internal.RubricScores
+}
+
+// ServiceQuality returns average rubric scores per service and prompt
+// template for results recorded since the given time (zero means all), best
+// accuracy first. Splitting by template makes prompt A/B tests comparable.
+// Each average covers only the results that scored its dimension; a
+// dimension never scored is 0.
+func (s *Store) ServiceQuality(ctx context.Context, since time.Time) ([]ServiceQuality, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT service_name, COALESCE(prompt_template, ''), COUNT(*),
+		        COALESCE(AVG(accuracy), 0), COALESCE(AVG(fluency), 0), COALESCE(AVG(terminology), 0),
+		        COALESCE(AVG(style), 0), COALESCE(AVG(formatting), 0)
+		 FROM translation_results
+		 WHERE COALESCE(accuracy, fluency, terminology, style, formatting) IS NOT NULL AND created_at >= ?
+		 GROUP BY service_name, COALESCE(prompt_template, '')
+		 ORDER BY AVG(accuracy) DESC, service_name`,
+		since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ServiceQuality
+	for rows.Next() {
+		var q ServiceQuality
+		if err := rows.Scan(&q.ServiceName, &q.PromptTemplate, &q.Samples, &q.Accuracy, &q.Fluency, &q.Terminology, &q.Style, &q.Formatting); err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	return out, rows.Err()
+}
+
+// LatencyPercentile returns the p-th percentile (0–1) of successful call
+// latencies recorded for serviceName, computed over its most recent
+// maxSamples results. Per-model results ("ollama/gemma2:27b") count towards
+// their service ("ollama"). ok is false when fewer than minSamples are
+// available, since a percentile over a handful of calls is not a useful threshold.
+func (s *Store) LatencyPercentile(ctx context.Context, serviceName string, p float64, minSamples, maxSamples int) (time.Duration, bool, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT latency_ms FROM translation_results
+		 WHERE (service_name = ? OR substr(service_name, 1, length(?) + 1) = ? || '/')
+		   AND (error IS NULL OR error = '') AND latency_ms > 0
+		 ORDER BY created_at DESC LIMIT ?`,
+		serviceName, serviceName, serviceName, maxSamples)
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+
+	var latencies []int
+	for rows.Next() {
+		var ms int
+		if err := rows.Scan(&ms); err != nil {
+			return 0, false, err
+		}
+		latencies = append(latencies, ms)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, false, err
+	}
+
+	if len(latencies) == 0 || len(latencies) < minSamples {
+		return 0, false, nil
+	}
+
+	sort.Ints(latencies)
+	idx := int(math.Ceil(p*float64(len(latencies)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(latencies) {
+		idx = len(latencies) - 1
+	}
+	return time.Duration(latencies[idx]) * time.Millisecond, true, nil
+}
+
 func (s *Store) SaveFinalTranslation(ctx context.Context, requestID, selectedService, finalText string, isComposite bool, reasoning string) error {
 	id := fmt.Sprintf("%s_final", requestID)
 	_, err := s.db.ExecContext(ctx,
@@ -161,12 +543,19 @@ func (s *Store) SaveFinalTranslation(ctx context.Context, requestID, selectedSer
 }
 
 func (s *Store) GetCachedTranslation(ctx context.Context, sourceText, sourceLang, targetLang string) (string, bool, error) {
+	return s.GetCachedTranslationInContext(ctx, sourceText, sourceLang, targetLang, "")
+}
+
+// GetCachedTranslationInContext is GetCachedTranslation for a segment
+// translated with the surrounding text identified by contextHash (see
+// ContextHash).
+func (s *Store) GetCachedTranslationInContext(ctx context.Context, sourceText, sourceLang, targetLang, contextHash string) (string, bool, error) {
 	var finalText string
 	var invalidated bool
 
 	err := s.db.QueryRowContext(ctx,
-		`SELECT final_text, invalidated FROM translation_memory WHERE source_text = ? AND source_lang = ? AND target_lang = ?`,
-		normalizeText(sourceText), sourceLang, targetLang).Scan(&finalText, &invalidated)
+		`SELECT final_text, invalidated FROM translation_memory WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND context_hash = ?`,
+		normalizeText(sourceText), sourceLang, targetLang, contextHash).Scan(&finalText, &invalidated)
 
 	if err == sql.ErrNoRows {
 		return "", false, nil
@@ -180,34 +569,107 @@ func (s *Store) GetCachedTranslation(ctx context.Context, sourceText, sourceLang
 	}
 
 	_, err = s.db.ExecContext(ctx,
-		`UPDATE translation_memory SET usage_count = usage_count + 1, last_used = ? WHERE source_text = ? AND source_lang = ? AND target_lang = ?`,
-		time.Now(), normalizeText(sourceText), sourceLang, targetLang)
+		`UPDATE translation_memory SET usage_count = usage_count + 1, last_used = ? WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND context_hash = ?`,
+		time.Now(), normalizeText(sourceText), sourceLang, targetLang, contextHash)
 
 	return finalText, true, err
 }
 
 func (s *Store) SaveToMemory(ctx context.Context, sourceText, sourceLang, targetLang, finalText, draftText, serviceUsed string) error {
+	return s.SaveToMemoryInContext(ctx, sourceText, sourceLang, targetLang, finalText, draftText, serviceUsed, "")
+}
+
+// SaveToMemoryInContext is SaveToMemory for a segment translated with the
+// surrounding text identified by contextHash (see ContextHash).
+func (s *Store) SaveToMemoryInContext(ctx context.Context, sourceText, sourceLang, targetLang, finalText, draftText, serviceUsed, contextHash string) error {
 	id := fmt.Sprintf("mem_%d", time.Now().UnixNano())
 	_, err := s.db.ExecContext(ctx,
-		`INSERT OR REPLACE INTO translation_memory (id, source_text, source_lang, target_lang, final_text, draft_text, service_used, usage_count, invalidated, last_used, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, 1, FALSE, ?, ?)`,
-		id, normalizeText(sourceText), sourceLang, targetLang, finalText, draftText, serviceUsed, time.Now(), time.Now())
+		`INSERT OR REPLACE INTO translation_memory (id, source_text, source_lang, target_lang, final_text, draft_text, service_used, usage_count, invalidated, last_used, created_at, context_hash) VALUES (?, ?, ?, ?, ?, ?, ?, 1, FALSE, ?, ?, ?)`,
+		id, normalizeText(sourceText), sourceLang, targetLang, finalText, draftText, serviceUsed, time.Now(), time.Now(), contextHash)
+	return err
+}
+
+// SetMemoryRefineKey records which refiner settings produced a translation
+// memory entry saved with SaveToMemoryInContext ("" contextHash for
+// SaveToMemory). An empty key means it was not refined.
+func (s *Store) SetMemoryRefineKey(ctx context.Context, sourceText, sourceLang, targetLang, contextHash, refineKey string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE translation_memory SET refine_key = NULLIF(?, '') WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND context_hash = ?`,
+		refineKey, normalizeText(sourceText), sourceLang, targetLang, contextHash)
+	return err
+}
+
+// UpdateMemoryText replaces the final text of a translation memory entry
+// saved with SaveToMemoryInContext, keeping its draft, service and refiner
+// key, e.g. after the consistency pass revised the chunk.
+func (s *Store) UpdateMemoryText(ctx context.Context, sourceText, sourceLang, targetLang, contextHash, finalText string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE translation_memory SET final_text = ? WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND context_hash = ?`,
+		finalText, normalizeText(sourceText), sourceLang, targetLang, contextHash)
 	return err
 }
 
+// GetMemoryRefineKey returns the refiner settings key of a translation
+// memory entry; found is false when there is no entry.
+func (s *Store) GetMemoryRefineKey(ctx context.Context, sourceText, sourceLang, targetLang, contextHash string) (string, bool, error) {
+	var refineKey string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(refine_key, '') FROM translation_memory WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND context_hash = ?`,
+		normalizeText(sourceText), sourceLang, targetLang, contextHash).Scan(&refineKey)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return refineKey, true, nil
+}
+
 // SaveToStage1Cache stores the primary (pre-refinement) translation draft.
 func (s *Store) SaveToStage1Cache(ctx context.Context, sourceText, sourceLang, targetLang, draftText, serviceUsed string) error {
+	return s.SaveStage1Draft(ctx, sourceText, sourceLang, targetLang, draftText, serviceUsed, "")
+}
+
+// SaveStage1Draft stores a stage1 draft together with a key identifying the
+// Stage 1 settings that produced it (services, models, arbiter, prompts), so
+// a later run with the same settings can skip Stage 1.
+func (s *Store) SaveStage1Draft(ctx context.Context, sourceText, sourceLang, targetLang, draftText, serviceUsed, settingsKey string) error {
 	id := fmt.Sprintf("s1_%d", time.Now().UnixNano())
 	_, err := s.db.ExecContext(ctx,
-		`INSERT OR REPLACE INTO stage1_cache (id, source_text, source_lang, target_lang, draft_text, service_used, created_at, last_used) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		id, normalizeText(sourceText), sourceLang, targetLang, draftText, serviceUsed, time.Now(), time.Now())
+		`INSERT OR REPLACE INTO stage1_cache (id, source_text, source_lang, target_lang, draft_text, service_used, settings_key, created_at, last_used) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, normalizeText(sourceText), sourceLang, targetLang, draftText, serviceUsed, settingsKey, time.Now(), time.Now())
 	return err
 }
 
+// LatestStage1Draft returns the most recent stage1 draft for a text and the
+// service that produced it. A non-empty settingsKey restricts the lookup to
+// drafts saved with that key; an empty one accepts any draft.
+func (s *Store) LatestStage1Draft(ctx context.Context, sourceText, sourceLang, targetLang, settingsKey string) (string, string, bool, error) {
+	query := `SELECT id, draft_text, COALESCE(service_used, '') FROM stage1_cache WHERE source_text = ? AND source_lang = ? AND target_lang = ?`
+	args := []interface{}{normalizeText(sourceText), sourceLang, targetLang}
+	if settingsKey != "" {
+		query += ` AND settings_key = ?`
+		args = append(args, settingsKey)
+	}
+	query += ` ORDER BY created_at DESC LIMIT 1`
+
+	var id, draftText, serviceUsed string
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&id, &draftText, &serviceUsed)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+	_, _ = s.db.ExecContext(ctx, `UPDATE stage1_cache SET last_used = ? WHERE id = ?`, time.Now(), id)
+	return draftText, serviceUsed, true, nil
+}
+
 // GetStage1Draft returns a cached stage1 draft if available.
 func (s *Store) GetStage1Draft(ctx context.Context, sourceText, sourceLang, targetLang, serviceUsed string) (string, bool, error) {
 	var draftText string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT draft_text FROM stage1_cache WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND service_used = ?`,
+		`SELECT draft_text FROM stage1_cache WHERE source_text = ? AND source_lang = ? AND target_lang = ? AND service_used = ? ORDER BY created_at DESC LIMIT 1`,
 		normalizeText(sourceText), sourceLang, targetLang, serviceUsed).Scan(&draftText)
 	if err == sql.ErrNoRows {
 		return "", false, nil
@@ -458,7 +920,7 @@ func (s *Store) FuzzyGetCachedTranslation(ctx context.Context, sourceText, sourc
 
 	rows, err := s.db.QueryContext(ctx,
 		`SELECT source_text, final_text FROM translation_memory
-		 WHERE source_lang = ? AND target_lang = ? AND NOT invalidated`,
+		 WHERE source_lang = ? AND target_lang = ? AND context_hash = '' AND NOT invalidated`,
 		sourceLang, targetLang)
 	if err != nil {
 		return "", false, err
@@ -588,3 +1050,206 @@ func (s *Store) DeleteGlossaryTerm(ctx context.Context, id string) error {
 	_, err := s.db.ExecContext(ctx, `DELETE FROM glossary WHERE id = ?`, id)
 	return err
 }
+
+// Glossary candidate review states.
+const (
+	CandidatePending  = "pending"
+	CandidateRejected = "rejected"
+)
+
+// GlossaryCandidate is an extracted term awaiting review, with the
+// translation proposed for it.
+type GlossaryCandidate struct {
+	ID           string
+	SourceLang   string
+	TargetLang   string
+	SourceTerm   string
+	ProposedTerm string
+	// Kind is how the term was found: name, phrase or domain.
+	Kind        string
+	Frequency   int
+	Example     string
+	ServiceUsed string
+	Status      string
+	CreatedAt   time.Time
+}
+
+// AddGlossaryCandidate adds c to the review list as pending. It returns
+// false without changes when the term was already proposed for the
+// language pair, whatever its status.
+func (s *Store) AddGlossaryCandidate(ctx context.Context, c GlossaryCandidate) (bool, error) {
+	id := fmt.Sprintf("gc_%d", time.Now().UnixNano())
+	res, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO glossary_candidates
+		 (id, source_lang, target_lang, source_term, proposed_term, kind, frequency, example, service_used, status)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, c.SourceLang, c.TargetLang, c.SourceTerm, c.ProposedTerm, c.Kind, c.Frequency, c.Example, c.ServiceUsed, CandidatePending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListGlossaryCandidates returns the review list, optionally filtered by
+// language pair and status (empty strings match everything), most frequent
+// first.
+func (s *Store) ListGlossaryCandidates(ctx context.Context, sourceLang, targetLang, status string) ([]GlossaryCandidate, error) {
+	query := `SELECT id, source_lang, target_lang, source_term, proposed_term, COALESCE(kind, ''), frequency,
+		COALESCE(example, ''), COALESCE(service_used, ''), status, created_at
+		FROM glossary_candidates WHERE 1 = 1`
+	var args []interface{}
+	if sourceLang != "" {
+		query += ` AND source_lang = ?`
+		args = append(args, sourceLang)
+	}
+	if targetLang != "" {
+		query += ` AND target_lang = ?`
+		args = append(args, targetLang)
+	}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY frequency DESC, source_term`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cands []GlossaryCandidate
+	for rows.Next() {
+		var c GlossaryCandidate
+		if err := rows.Scan(&c.ID, &c.SourceLang, &c.TargetLang, &c.SourceTerm, &c.ProposedTerm, &c.Kind,
+			&c.Frequency, &c.Example, &c.ServiceUsed, &c.Status, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		cands = append(cands, c)
+	}
+	return cands, rows.Err()
+}
+
+// AcceptGlossaryCandidate moves a pending candidate into the glossary, with
+// targetTerm as its translation or, if empty, the proposed one. It returns
+// the accepted candidate.
+func (s *Store) AcceptGlossaryCandidate(ctx context.Context, id, targetTerm string) (GlossaryCandidate, error) {
+	var c GlossaryCandidate
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return c, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, source_lang, target_lang, source_term, proposed_term, status FROM glossary_candidates WHERE id = ?`, id).
+		Scan(&c.ID, &c.SourceLang, &c.TargetLang, &c.SourceTerm, &c.ProposedTerm, &c.Status)
+	if err == sql.ErrNoRows {
+		return c, fmt.Errorf("no glossary candidate %s", id)
+	}
+	if err != nil {
+		return c, err
+	}
+	if c.Status != CandidatePending {
+		return c, fmt.Errorf("glossary candidate %s is %s, not pending", id, c.Status)
+	}
+	if targetTerm != "" {
+		c.ProposedTerm = targetTerm
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO glossary (id, source_lang, target_lang, source_term, target_term)
+		 VALUES (?, ?, ?, ?, ?)`,
+		fmt.Sprintf("gl_%d", time.Now().UnixNano()), c.SourceLang, c.TargetLang, c.SourceTerm, c.ProposedTerm); err != nil {
+		return c, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM glossary_candidates WHERE id = ?`, id); err != nil {
+		return c, err
+	}
+	return c, tx.Commit()
+}
+
+// RejectGlossaryCandidate marks a candidate rejected, so extraction does not
+// propose the term again.
+func (s *Store) RejectGlossaryCandidate(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE glossary_candidates SET status = ? WHERE id = ?`, CandidateRejected, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no glossary candidate %s", id)
+	}
+	return nil
+}
+
+// SaveStyleProfile inserts or replaces the style profile p.Name.
+func (s *Store) SaveStyleProfile(ctx context.Context, p style.Profile) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO style_profiles
+		 (name, formality, audience, register, forbidden_words, quotes, punctuation, number_format, notes)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Name, p.Formality, p.Audience, p.Register, strings.Join(p.ForbiddenWords, "\n"),
+		p.Quotes, p.Punctuation, p.NumberFormat, p.Notes)
+	return err
+}
+
+const styleProfileColumns = `name, formality, audience, register, forbidden_words, quotes, punctuation, number_format, notes`
+
+func scanStyleProfile(row interface{ Scan(...interface{}) error }) (*style.Profile, error) {
+	var p style.Profile
+	var forbidden string
+	if err := row.Scan(&p.Name, &p.Formality, &p.Audience, &p.Register, &forbidden,
+		&p.Quotes, &p.Punctuation, &p.NumberFormat, &p.Notes); err != nil {
+		return nil, err
+	}
+	if forbidden != "" {
+		p.ForbiddenWords = strings.Split(forbidden, "\n")
+	}
+	return &p, nil
+}
+
+// GetStyleProfile returns the style profile called name.
+func (s *Store) GetStyleProfile(ctx context.Context, name string) (*style.Profile, bool, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+styleProfileColumns+` FROM style_profiles WHERE name = ?`, name)
+	p, err := scanStyleProfile(row)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return p, true, nil
+}
+
+// ListStyleProfiles returns all style profiles ordered by name.
+func (s *Store) ListStyleProfiles(ctx context.Context) ([]style.Profile, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+styleProfileColumns+` FROM style_profiles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []style.Profile
+	for rows.Next() {
+		p, err := scanStyleProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// DeleteStyleProfile removes the style profile called name. It reports
+// whether a profile was deleted.
+func (s *Store) DeleteStyleProfile(ctx context.Context, name string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM style_profiles WHERE name = ?`, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
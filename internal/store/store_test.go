@@ -2,11 +2,14 @@ package store
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/valpere/peretran/internal"
+	"github.com/valpere/peretran/internal/style"
 )
 
 func TestStore_New(t *testing.T) {
@@ -690,3 +693,528 @@ func TestStore_AddGlossaryTerm_Upsert(t *testing.T) {
 	}
 }
 
+
+func TestStore_LatencyPercentile(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := New(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	for i := 1; i <= 20; i++ {
+		reqID := fmt.Sprintf("req-%d", i)
+		name := "ollama"
+		if i%2 == 0 {
+			name = "ollama/gemma2:27b"
+		}
+		if err := s.SaveResult(ctx, reqID, name, "text", 0.7, i*100, ""); err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+	}
+	// Failed calls must not count towards the latency distribution.
+	if err := s.SaveResult(ctx, "req-err", "ollama", "", 0, 60000, "timeout"); err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	p95, ok, err := s.LatencyPercentile(ctx, "ollama", 0.95, 10, 500)
+	if err != nil {
+		t.Fatalf("LatencyPercentile failed: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected enough samples")
+	}
+	if p95 != 1900*time.Millisecond {
+		t.Errorf("expected p95 1.9s, got %v", p95)
+	}
+
+	if _, ok, _ := s.LatencyPercentile(ctx, "ollama", 0.95, 50, 500); ok {
+		t.Error("expected ok=false below minSamples")
+	}
+	if _, ok, _ := s.LatencyPercentile(ctx, "oll", 0.95, 1, 500); ok {
+		t.Error("expected ok=false for a mere name prefix")
+	}
+	if _, ok, _ := s.LatencyPercentile(ctx, "openrouter", 0.95, 1, 500); ok {
+		t.Error("expected ok=false for service without history")
+	}
+}
+
+func TestStore_ServiceQuality(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	s, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	for i, acc := range []float64{4, 2} {
+		reqID := fmt.Sprintf("req-%d", i)
+		_ = s.SaveRequest(ctx, internal.TranslationRequest{ID: reqID, SourceText: "Hello", SourceLang: "en", TargetLang: "uk", Timestamp: time.Now()})
+		_ = s.SaveResult(ctx, reqID, "google", "Привіт", 0, 100, "")
+		_ = s.SaveResult(ctx, reqID, "mymemory", "Хелло", 0, 100, "")
+		if err := s.SaveResultScores(ctx, reqID, "google", internal.RubricScores{Accuracy: acc, Fluency: 5, Terminology: 4, Style: 4, Formatting: 5}); err != nil {
+			t.Fatalf("SaveResultScores failed: %v", err)
+		}
+	}
+
+	quality, err := s.ServiceQuality(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("ServiceQuality failed: %v", err)
+	}
+	// mymemory was never scored and must not appear.
+	if len(quality) != 1 {
+		t.Fatalf("expected 1 scored service, got %d", len(quality))
+	}
+	q := quality[0]
+	if q.ServiceName != "google" || q.Samples != 2 || q.Accuracy != 3 || q.Fluency != 5 {
+		t.Errorf("unexpected quality row: %+v", q)
+	}
+
+	recent, err := s.ServiceQuality(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ServiceQuality failed: %v", err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("expected no rows after the cut-off, got %d", len(recent))
+	}
+}
+
+func TestStore_ServiceQuality_UnscoredDimension(t *testing.T) {
+	s, _ := New(filepath.Join(t.TempDir(), "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	for i, formatting := range []float64{0, 4} {
+		reqID := fmt.Sprintf("req-%d", i)
+		_ = s.SaveRequest(ctx, internal.TranslationRequest{ID: reqID, SourceText: "Hello", SourceLang: "en", TargetLang: "uk", Timestamp: time.Now()})
+		_ = s.SaveResult(ctx, reqID, "google", "Привіт", 0, 100, "")
+		_ = s.SaveResultScores(ctx, reqID, "google", internal.RubricScores{Fluency: 4, Formatting: formatting})
+	}
+
+	var formatting *float64
+	if err := s.db.QueryRowContext(ctx, `SELECT formatting FROM translation_results WHERE id = ?`, "req-0_google").Scan(&formatting); err != nil || formatting != nil {
+		t.Errorf("expected an unscored dimension to be stored as NULL, got %v (err=%v)", formatting, err)
+	}
+
+	quality, err := s.ServiceQuality(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("ServiceQuality failed: %v", err)
+	}
+	if len(quality) != 1 {
+		t.Fatalf("expected results without an accuracy score to count, got %d rows", len(quality))
+	}
+	if q := quality[0]; q.Samples != 2 || q.Accuracy != 0 || q.Formatting != 4 || q.Mean() != 4 {
+		t.Errorf("unexpected quality row: %+v", q)
+	}
+}
+
+func TestStore_PromptTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	s, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	for i, tmpl := range []string{"translate@aaaa", "translate@bbbb"} {
+		reqID := fmt.Sprintf("req-%d", i)
+		_ = s.SaveRequest(ctx, internal.TranslationRequest{ID: reqID, SourceText: "Hello", SourceLang: "en", TargetLang: "uk", Timestamp: time.Now()})
+		_ = s.SaveResult(ctx, reqID, "ollama/gemma2:27b", "Привіт", 0.7, 100, "")
+		if err := s.SaveResultPromptTemplate(ctx, reqID, "ollama/gemma2:27b", tmpl); err != nil {
+			t.Fatalf("SaveResultPromptTemplate failed: %v", err)
+		}
+		_ = s.SaveResultScores(ctx, reqID, "ollama/gemma2:27b", internal.RubricScores{Accuracy: float64(3 + i), Fluency: 4, Terminology: 4, Style: 4, Formatting: 4})
+
+		_ = s.SaveFinalTranslation(ctx, reqID, "ollama/gemma2:27b", "Привіт", false, "")
+		if err := s.SaveFinalPromptTemplates(ctx, reqID, "arbiter@cccc", ""); err != nil {
+			t.Fatalf("SaveFinalPromptTemplates failed: %v", err)
+		}
+	}
+
+	quality, err := s.ServiceQuality(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("ServiceQuality failed: %v", err)
+	}
+	if len(quality) != 2 {
+		t.Fatalf("expected one row per prompt template, got %d", len(quality))
+	}
+	if quality[0].PromptTemplate != "translate@bbbb" || quality[0].Accuracy != 4 {
+		t.Errorf("expected better template first, got %+v", quality[0])
+	}
+
+	var arbiterPrompt string
+	var refinerPrompt *string
+	row := s.db.QueryRowContext(ctx, `SELECT arbiter_prompt, refiner_prompt FROM final_translations WHERE id = ?`, "req-0_final")
+	if err := row.Scan(&arbiterPrompt, &refinerPrompt); err != nil {
+		t.Fatalf("failed to read final prompts: %v", err)
+	}
+	if arbiterPrompt != "arbiter@cccc" || refinerPrompt != nil {
+		t.Errorf("unexpected final prompts %q, %v", arbiterPrompt, refinerPrompt)
+	}
+}
+
+// --- Style profile tests ---
+
+func TestStore_StyleProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	p := style.Profile{
+		Name:           "ui-uk",
+		Formality:      style.FormalityFormal,
+		Audience:       "end users",
+		ForbiddenWords: []string{"юзер", "клацнути"},
+		Quotes:         "«»",
+	}
+	if err := s.SaveStyleProfile(ctx, p); err != nil {
+		t.Fatalf("SaveStyleProfile failed: %v", err)
+	}
+
+	got, ok, err := s.GetStyleProfile(ctx, "ui-uk")
+	if err != nil || !ok {
+		t.Fatalf("GetStyleProfile failed: ok=%v err=%v", ok, err)
+	}
+	if got.Formality != style.FormalityFormal || got.Quotes != "«»" || len(got.ForbiddenWords) != 2 || got.ForbiddenWords[1] != "клацнути" {
+		t.Errorf("unexpected profile %+v", got)
+	}
+
+	p.Formality = style.FormalityInformal
+	p.ForbiddenWords = nil
+	if err := s.SaveStyleProfile(ctx, p); err != nil {
+		t.Fatalf("SaveStyleProfile (replace) failed: %v", err)
+	}
+	got, _, _ = s.GetStyleProfile(ctx, "ui-uk")
+	if got.Formality != style.FormalityInformal || got.ForbiddenWords != nil {
+		t.Errorf("expected replaced profile, got %+v", got)
+	}
+
+	_ = s.SaveStyleProfile(ctx, style.Profile{Name: "docs-de"})
+	list, err := s.ListStyleProfiles(ctx)
+	if err != nil {
+		t.Fatalf("ListStyleProfiles failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "docs-de" {
+		t.Errorf("expected 2 profiles ordered by name, got %+v", list)
+	}
+
+	deleted, err := s.DeleteStyleProfile(ctx, "ui-uk")
+	if err != nil || !deleted {
+		t.Fatalf("DeleteStyleProfile failed: deleted=%v err=%v", deleted, err)
+	}
+	if _, ok, _ := s.GetStyleProfile(ctx, "ui-uk"); ok {
+		t.Error("expected profile to be deleted")
+	}
+	if deleted, _ := s.DeleteStyleProfile(ctx, "missing"); deleted {
+		t.Error("expected no deletion for a missing profile")
+	}
+}
+
+func TestStore_RefinementRounds(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	_ = s.SaveRequest(ctx, internal.TranslationRequest{ID: "req-1", SourceText: "Hello world", SourceLang: "en", TargetLang: "uk", Timestamp: time.Now()})
+	if err := s.SaveRefinementRound(ctx, "req-1", 2, "", "", ""); err != nil {
+		t.Fatalf("SaveRefinementRound failed: %v", err)
+	}
+	if err := s.SaveRefinementRound(ctx, "req-1", 1, "- [awkward] unnatural vocative", "Привіт, світе", ""); err != nil {
+		t.Fatalf("SaveRefinementRound failed: %v", err)
+	}
+
+	rounds, err := s.GetRefinementRounds(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetRefinementRounds failed: %v", err)
+	}
+	if len(rounds) != 2 || rounds[0].Round != 1 || rounds[0].RevisedText != "Привіт, світе" || rounds[1].Critique != "" {
+		t.Errorf("unexpected rounds %+v", rounds)
+	}
+}
+
+func TestStore_LatestStage1Draft(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, _, found, err := s.LatestStage1Draft(ctx, "Hello", "en", "uk", ""); err != nil || found {
+		t.Fatalf("expected no draft, got found=%v err=%v", found, err)
+	}
+
+	_ = s.SaveStage1Draft(ctx, "Hello", "en", "uk", "Привіт", "google", "s1-aaaa")
+	time.Sleep(2 * time.Millisecond)
+	_ = s.SaveStage1Draft(ctx, "Hello", "en", "uk", "Вітаю", "ollama/gemma2:27b", "s1-bbbb")
+
+	draft, service, found, err := s.LatestStage1Draft(ctx, "Hello", "en", "uk", "")
+	if err != nil || !found || draft != "Вітаю" || service != "ollama/gemma2:27b" {
+		t.Errorf("expected the latest draft, got %q from %q (found=%v, err=%v)", draft, service, found, err)
+	}
+
+	draft, _, found, _ = s.LatestStage1Draft(ctx, "Hello", "en", "uk", "s1-aaaa")
+	if !found || draft != "Привіт" {
+		t.Errorf("expected the draft with the matching settings key, got %q (found=%v)", draft, found)
+	}
+	if _, _, found, _ := s.LatestStage1Draft(ctx, "Hello", "en", "uk", "s1-cccc"); found {
+		t.Error("expected no draft for unknown settings key")
+	}
+}
+
+func TestStore_Stage1DraftsPerSettingsKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	if err := s.SaveStage1Draft(ctx, "Hello", "en", "uk", "Привіт", "google", "s1-aaaa"); err != nil {
+		t.Fatalf("SaveStage1Draft failed: %v", err)
+	}
+	if err := s.SaveStage1Draft(ctx, "Hello", "en", "uk", "Вітаю", "google", "s1-bbbb"); err != nil {
+		t.Fatalf("SaveStage1Draft failed: %v", err)
+	}
+	for key, want := range map[string]string{"s1-aaaa": "Привіт", "s1-bbbb": "Вітаю"} {
+		if draft, _, found, _ := s.LatestStage1Draft(ctx, "Hello", "en", "uk", key); !found || draft != want {
+			t.Errorf("settings key %s: got %q (found=%v), want %q", key, draft, found, want)
+		}
+	}
+
+	_ = s.SaveStage1Draft(ctx, "Hello", "en", "uk", "Здрастуй", "google", "s1-aaaa")
+	if draft, _, _, _ := s.LatestStage1Draft(ctx, "Hello", "en", "uk", "s1-aaaa"); draft != "Здрастуй" {
+		t.Errorf("expected the draft to be replaced under the same key, got %q", draft)
+	}
+}
+
+func TestStore_MemoryRefineKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, found, _ := s.GetMemoryRefineKey(ctx, "Hello", "en", "uk", ""); found {
+		t.Fatal("expected no memory entry")
+	}
+
+	_ = s.SaveToMemory(ctx, "Hello", "en", "uk", "Привіт", "Привіт", "google")
+	if key, found, err := s.GetMemoryRefineKey(ctx, "Hello", "en", "uk", ""); err != nil || !found || key != "" {
+		t.Errorf("expected unrefined entry, got %q (found=%v, err=%v)", key, found, err)
+	}
+
+	if err := s.SetMemoryRefineKey(ctx, "Hello", "en", "uk", "", "rf-1234"); err != nil {
+		t.Fatalf("SetMemoryRefineKey failed: %v", err)
+	}
+	if key, _, _ := s.GetMemoryRefineKey(ctx, "Hello", "en", "uk", ""); key != "rf-1234" {
+		t.Errorf("expected refine key rf-1234, got %q", key)
+	}
+}
+
+func TestStore_UpdateMemoryText(t *testing.T) {
+	s, _ := New(filepath.Join(t.TempDir(), "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	hash := ContextHash("Anna came.", "")
+	_ = s.SaveToMemoryInContext(ctx, "Anna smiled.", "en", "uk", "Ганна усміхнулася.", "Ганна усміхнулася.", "google", hash)
+	_ = s.SetMemoryRefineKey(ctx, "Anna smiled.", "en", "uk", hash, "rf-1")
+	if err := s.UpdateMemoryText(ctx, "Anna smiled.", "en", "uk", hash, "Анна усміхнулася."); err != nil {
+		t.Fatalf("UpdateMemoryText failed: %v", err)
+	}
+	if got, _, _ := s.GetCachedTranslationInContext(ctx, "Anna smiled.", "en", "uk", hash); got != "Анна усміхнулася." {
+		t.Errorf("expected the updated text, got %q", got)
+	}
+	if key, _, _ := s.GetMemoryRefineKey(ctx, "Anna smiled.", "en", "uk", hash); key != "rf-1" {
+		t.Errorf("expected the refine key to be kept, got %q", key)
+	}
+}
+
+func TestStore_MemoryInContext(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	bank := ContextHash("She walked to the river.", "")
+	money := ContextHash("He needed cash.", "")
+	if bank == "" || bank == money {
+		t.Fatalf("expected distinct non-empty context hashes, got %q and %q", bank, money)
+	}
+	if ContextHash(" ", "") != "" {
+		t.Error("expected empty context hash without context")
+	}
+
+	_ = s.SaveToMemory(ctx, "The bank was closed.", "en", "uk", "Банк був закритий.", "", "google")
+	_ = s.SaveToMemoryInContext(ctx, "The bank was closed.", "en", "uk", "Берег був недоступний.", "", "google", bank)
+
+	for _, tt := range []struct {
+		hash, want string
+	}{
+		{"", "Банк був закритий."},
+		{bank, "Берег був недоступний."},
+	} {
+		got, found, err := s.GetCachedTranslationInContext(ctx, "The bank was closed.", "en", "uk", tt.hash)
+		if err != nil || !found || got != tt.want {
+			t.Errorf("context %q: got %q (found=%v, err=%v), want %q", tt.hash, got, found, err, tt.want)
+		}
+	}
+	if _, found, _ := s.GetCachedTranslationInContext(ctx, "The bank was closed.", "en", "uk", money); found {
+		t.Error("expected no entry for an unseen context")
+	}
+
+	if err := s.SetMemoryRefineKey(ctx, "The bank was closed.", "en", "uk", bank, "rf-1"); err != nil {
+		t.Fatalf("SetMemoryRefineKey failed: %v", err)
+	}
+	if key, _, _ := s.GetMemoryRefineKey(ctx, "The bank was closed.", "en", "uk", ""); key != "" {
+		t.Errorf("expected the context-free entry to stay unrefined, got %q", key)
+	}
+}
+
+func TestStore_MigratesMemoryContextKey(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE translation_memory (
+		id TEXT PRIMARY KEY,
+		source_text TEXT NOT NULL,
+		source_lang TEXT NOT NULL,
+		target_lang TEXT NOT NULL,
+		final_text TEXT NOT NULL,
+		draft_text TEXT,
+		service_used TEXT,
+		usage_count INTEGER DEFAULT 1,
+		invalidated BOOLEAN DEFAULT FALSE,
+		last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(source_text, source_lang, target_lang)
+	);
+	INSERT INTO translation_memory (id, source_text, source_lang, target_lang, final_text) VALUES ('mem_1', 'Hello', 'en', 'uk', 'Привіт');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open old database: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	if got, found, _ := s.GetCachedTranslation(ctx, "Hello", "en", "uk"); !found || got != "Привіт" {
+		t.Errorf("expected migrated entry, got %q (found=%v)", got, found)
+	}
+	if err := s.SaveToMemoryInContext(ctx, "Hello", "en", "uk", "Вітаю", "", "google", ContextHash("Hi.", "")); err != nil {
+		t.Fatalf("expected a second entry for another context, got %v", err)
+	}
+	if got, _, _ := s.GetCachedTranslation(ctx, "Hello", "en", "uk"); got != "Привіт" {
+		t.Errorf("expected context-free entry to be kept, got %q", got)
+	}
+}
+
+func TestStore_GlossaryCandidates(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, _ := New(filepath.Join(tmpDir, "test.db"))
+	defer s.Close()
+	ctx := context.Background()
+
+	for _, c := range []GlossaryCandidate{
+		{SourceLang: "en", TargetLang: "uk", SourceTerm: "control plane", ProposedTerm: "площина керування", Kind: "phrase", Frequency: 5},
+		{SourceLang: "en", TargetLang: "uk", SourceTerm: "worker node", ProposedTerm: "робочий вузол", Kind: "phrase", Frequency: 7},
+		{SourceLang: "en", TargetLang: "de", SourceTerm: "worker node", ProposedTerm: "Worker-Knoten", Kind: "phrase", Frequency: 7},
+	} {
+		if added, err := s.AddGlossaryCandidate(ctx, c); err != nil || !added {
+			t.Fatalf("AddGlossaryCandidate(%q) = %v, %v", c.SourceTerm, added, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if added, _ := s.AddGlossaryCandidate(ctx, GlossaryCandidate{SourceLang: "en", TargetLang: "uk", SourceTerm: "control plane", ProposedTerm: "x"}); added {
+		t.Error("expected a proposed term not to be added twice")
+	}
+
+	pending, err := s.ListGlossaryCandidates(ctx, "en", "uk", CandidatePending)
+	if err != nil {
+		t.Fatalf("ListGlossaryCandidates failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].SourceTerm != "worker node" || pending[1].ProposedTerm != "площина керування" {
+		t.Fatalf("unexpected pending list: %+v", pending)
+	}
+
+	accepted, err := s.AcceptGlossaryCandidate(ctx, pending[0].ID, "вузол-виконавець")
+	if err != nil {
+		t.Fatalf("AcceptGlossaryCandidate failed: %v", err)
+	}
+	if accepted.ProposedTerm != "вузол-виконавець" {
+		t.Errorf("expected the edited translation, got %q", accepted.ProposedTerm)
+	}
+	terms, _ := s.GetGlossaryTerms(ctx, "en", "uk")
+	if terms["worker node"] != "вузол-виконавець" {
+		t.Errorf("expected accepted term in glossary, got %v", terms)
+	}
+	if _, err := s.AcceptGlossaryCandidate(ctx, pending[0].ID, ""); err == nil {
+		t.Error("expected an error accepting a candidate twice")
+	}
+
+	if err := s.RejectGlossaryCandidate(ctx, pending[1].ID); err != nil {
+		t.Fatalf("RejectGlossaryCandidate failed: %v", err)
+	}
+	if _, err := s.AcceptGlossaryCandidate(ctx, pending[1].ID, ""); err == nil {
+		t.Error("expected an error accepting a rejected candidate")
+	}
+	if rejected, _ := s.ListGlossaryCandidates(ctx, "", "", CandidateRejected); len(rejected) != 1 {
+		t.Errorf("expected one rejected candidate, got %+v", rejected)
+	}
+	if err := s.RejectGlossaryCandidate(ctx, "gc_missing"); err == nil {
+		t.Error("expected an error rejecting an unknown candidate")
+	}
+}
+
+func TestStore_MigratesStage1SettingsKey(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE stage1_cache (
+		id TEXT PRIMARY KEY,
+		source_text TEXT NOT NULL,
+		source_lang TEXT NOT NULL,
+		target_lang TEXT NOT NULL,
+		draft_text TEXT NOT NULL,
+		service_used TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(source_text, source_lang, target_lang, service_used)
+	);
+	INSERT INTO stage1_cache (id, source_text, source_lang, target_lang, draft_text, service_used) VALUES ('s1_1', 'Hello', 'en', 'uk', 'Привіт', 'google');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open old database: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	if err := s.SaveStage1Draft(ctx, "Hello", "en", "uk", "Вітаю", "google", "s1-aaaa"); err != nil {
+		t.Fatalf("SaveStage1Draft failed: %v", err)
+	}
+	if got, found, _ := s.GetStage1Draft(ctx, "Hello", "en", "uk", "google"); !found || got == "" {
+		t.Errorf("expected a draft, got %q (found=%v)", got, found)
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM stage1_cache`).Scan(&n); err != nil || n != 2 {
+		t.Errorf("expected the migrated draft to be kept beside the new one, got %d rows (err=%v)", n, err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package style defines named style profiles — formality, audience,
+// register, forbidden words and typographic conventions — and renders them
+// into a style guide for LLM prompts.
+package style
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Formality levels. They match the values used by engines with a native
+// formality parameter (DeepL, Amazon Translate).
+const (
+	FormalityDefault  = ""
+	FormalityFormal   = "formal"
+	FormalityInformal = "informal"
+)
+
+// Profile is a named set of style requirements for a translation.
+type Profile struct {
+	Name string
+
+	// Formality is FormalityFormal, FormalityInformal or FormalityDefault.
+	Formality string
+	// Audience describes the readers, e.g. "developers" or "children 8-12".
+	Audience string
+	// Register names the kind of text, e.g. "marketing", "legal", "UI copy".
+	Register string
+	// ForbiddenWords must not appear in the translation.
+	ForbiddenWords []string
+	// Quotes are the quotation marks to use, e.g. "«»" or "„“".
+	Quotes string
+	// Punctuation holds free-form punctuation conventions.
+	Punctuation string
+	// NumberFormat describes number, date and currency formatting,
+	// e.g. "1 234,56; dates as DD.MM.YYYY".
+	NumberFormat string
+	// Notes holds any further instructions.
+	Notes string
+}
+
+// ParseFormality validates a formality level. "default" and "" both mean
+// FormalityDefault.
+func ParseFormality(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "default":
+		return FormalityDefault, nil
+	case FormalityFormal:
+		return FormalityFormal, nil
+	case FormalityInformal:
+		return FormalityInformal, nil
+	default:
+		return "", fmt.Errorf("unknown formality %q (valid: formal, informal, default)", s)
+	}
+}
+
+// Guide renders the profile as a style guide for LLM prompts. It returns ""
+// for a nil or empty profile.
+func (p *Profile) Guide() string {
+	if p == nil {
+		return ""
+	}
+
+	var lines []string
+	switch p.Formality {
+	case FormalityFormal:
+		lines = append(lines, `Formality: formal — address the reader with the polite form (e.g. Ukrainian "ви", German "Sie", French "vous").`)
+	case FormalityInformal:
+		lines = append(lines, `Formality: informal — address the reader with the familiar form (e.g. Ukrainian "ти", German "du", French "tu").`)
+	}
+	if p.Audience != "" {
+		lines = append(lines, "Audience: "+p.Audience+".")
+	}
+	if p.Register != "" {
+		lines = append(lines, "Register: "+p.Register+".")
+	}
+	if len(p.ForbiddenWords) > 0 {
+		lines = append(lines, "Never use these words: "+strings.Join(p.ForbiddenWords, ", ")+".")
+	}
+	if p.Quotes != "" {
+		lines = append(lines, "Quotation marks: "+p.Quotes+".")
+	}
+	if p.Punctuation != "" {
+		lines = append(lines, "Punctuation: "+p.Punctuation+".")
+	}
+	if p.NumberFormat != "" {
+		lines = append(lines, "Numbers: "+p.NumberFormat+".")
+	}
+	if p.Notes != "" {
+		lines = append(lines, p.Notes)
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "- " + strings.Join(lines, "\n- ")
+}
+
+// ForbiddenIn returns the forbidden words that occur in text as whole
+// words, compared case-insensitively. Nil profiles forbid nothing.
+func (p *Profile) ForbiddenIn(text string) []string {
+	if p == nil || len(p.ForbiddenWords) == 0 {
+		return nil
+	}
+
+	lower := strings.ToLower(text)
+	var found []string
+	for _, w := range p.ForbiddenWords {
+		if w != "" && containsWord(lower, strings.ToLower(w)) {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+// containsWord reports whether word occurs in text bounded by non-letters,
+// so "ти" does not match inside "тихо".
+func containsWord(text, word string) bool {
+	for from := 0; ; {
+		i := strings.Index(text[from:], word)
+		if i < 0 {
+			return false
+		}
+		start := from + i
+		end := start + len(word)
+		if !letterBefore(text, start) && !letterAfter(text, end) {
+			return true
+		}
+		from = start + 1
+		for from < len(text) && !isRuneStart(text[from]) {
+			from++
+		}
+	}
+}
+
+func letterBefore(s string, i int) bool {
+	if i == 0 {
+		return false
+	}
+	r := []rune(s[:i])
+	return isWordRune(r[len(r)-1])
+}
+
+func letterAfter(s string, i int) bool {
+	for _, r := range s[i:] {
+		return isWordRune(r)
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
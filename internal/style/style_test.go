@@ -0,0 +1,76 @@
+package style
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFormality(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", FormalityDefault, false},
+		{"default", FormalityDefault, false},
+		{"Formal", FormalityFormal, false},
+		{" informal ", FormalityInformal, false},
+		{"casual", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormality(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormality(%q) = %q, %v; want %q, error=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestProfile_Guide(t *testing.T) {
+	var nilProfile *Profile
+	if g := nilProfile.Guide(); g != "" {
+		t.Errorf("expected empty guide for nil profile, got %q", g)
+	}
+	if g := (&Profile{Name: "empty"}).Guide(); g != "" {
+		t.Errorf("expected empty guide for empty profile, got %q", g)
+	}
+
+	g := (&Profile{
+		Formality:      FormalityInformal,
+		Audience:       "teenagers",
+		ForbiddenWords: []string{"юзер", "девайс"},
+		Quotes:         "«»",
+	}).Guide()
+	for _, want := range []string{"informal", `"ти"`, "Audience: teenagers.", "Never use these words: юзер, девайс.", "Quotation marks: «»."} {
+		if !strings.Contains(g, want) {
+			t.Errorf("expected guide to contain %q, got:\n%s", want, g)
+		}
+	}
+	if !strings.HasPrefix(g, "- ") || strings.Count(g, "\n- ") != 3 {
+		t.Errorf("expected one bullet per requirement, got:\n%s", g)
+	}
+}
+
+func TestProfile_ForbiddenIn(t *testing.T) {
+	p := &Profile{ForbiddenWords: []string{"ти", "Юзер", "log in"}}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Тихо, будь ласка.", nil},
+		{"Ти готовий?", []string{"ти"}},
+		{"Кожен юзер, будь ласка, log in.", []string{"Юзер", "log in"}},
+		{"Юзери", nil},
+	}
+	for _, tt := range tests {
+		if got := p.ForbiddenIn(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ForbiddenIn(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	var nilProfile *Profile
+	if got := nilProfile.ForbiddenIn("anything"); got != nil {
+		t.Errorf("expected nil for nil profile, got %v", got)
+	}
+}
@@ -0,0 +1,189 @@
+// Package subtitle reads and writes SRT and WebVTT subtitles. Cue
+// numbers, identifiers, timings and every block that is not a cue
+// (the WebVTT header, NOTE, STYLE and REGION blocks) are written back as
+// they were read; only cue text changes.
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// reTiming matches a cue timing line of SRT (00:00:01,000) or WebVTT
+// (00:01.000 or 00:00:01.000), which may be followed by cue settings.
+var reTiming = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{2})[,.](\d{3})\s+-->\s+(?:(\d+):)?(\d{1,2}):(\d{2})[,.](\d{3})`)
+
+// reTag matches the markup of cue text: HTML-like tags and ASS override
+// codes such as {\an8}.
+var reTag = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// File is a parsed subtitle file.
+type File struct {
+	// VTT is true for WebVTT, false for SRT.
+	VTT    bool
+	Cues   []*Cue
+	blocks []*block
+	crlf   bool
+	bom    bool
+}
+
+// Cue is one subtitle.
+type Cue struct {
+	// ID is the number of an SRT cue or the identifier of a WebVTT cue,
+	// "" when there is none.
+	ID         string
+	Start, End time.Duration
+	// Lines is the text of the cue.
+	Lines []string
+
+	timing string
+}
+
+type block struct {
+	// sep holds the blank lines before the block.
+	sep []string
+	// raw holds the lines of a block that is not a cue.
+	raw []string
+	cue *Cue
+}
+
+// Duration returns how long the cue is shown.
+func (c *Cue) Duration() time.Duration {
+	return c.End - c.Start
+}
+
+// Text returns the lines of the cue joined by spaces.
+func (c *Cue) Text() string {
+	return strings.Join(c.Lines, " ")
+}
+
+// Label names the cue in messages: its number or identifier and start
+// time.
+func (c *Cue) Label() string {
+	if c.ID != "" {
+		return fmt.Sprintf("cue %s (%s)", c.ID, formatTime(c.Start))
+	}
+	return fmt.Sprintf("cue at %s", formatTime(c.Start))
+}
+
+// Parse parses SRT or WebVTT subtitles; a file starting with "WEBVTT" is
+// WebVTT.
+func Parse(src string) (*File, error) {
+	f := &File{}
+	if strings.HasPrefix(src, "\ufeff") {
+		f.bom = true
+		src = src[len("\ufeff"):]
+	}
+	f.crlf = strings.Contains(src, "\r\n")
+	f.VTT = strings.HasPrefix(src, "WEBVTT")
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var sep, cur []string
+	flush := func() error {
+		if len(cur) == 0 {
+			return nil
+		}
+		b := &block{sep: sep}
+		sep = nil
+		timing := -1
+		for i, line := range cur {
+			if i > 1 {
+				break
+			}
+			if reTiming.MatchString(strings.TrimSpace(line)) {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			b.raw = cur
+		} else {
+			c := &Cue{timing: cur[timing], Lines: cur[timing+1:]}
+			if timing == 1 {
+				c.ID = cur[0]
+			}
+			m := reTiming.FindStringSubmatch(strings.TrimSpace(cur[timing]))
+			c.Start, c.End = parseTime(m[1:5]), parseTime(m[5:9])
+			if c.End < c.Start {
+				return fmt.Errorf("%s ends before it starts", c.Label())
+			}
+			b.cue = c
+			f.Cues = append(f.Cues, c)
+		}
+		f.blocks = append(f.blocks, b)
+		cur = nil
+		return nil
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			sep = append(sep, line)
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(sep) > 0 {
+		f.blocks = append(f.blocks, &block{sep: sep})
+	}
+	if len(f.Cues) == 0 {
+		return nil, fmt.Errorf("no subtitle cues found")
+	}
+	return f, nil
+}
+
+// String returns the subtitles in their original format.
+func (f *File) String() string {
+	var lines []string
+	for _, b := range f.blocks {
+		lines = append(lines, b.sep...)
+		switch {
+		case b.cue != nil:
+			if b.cue.ID != "" {
+				lines = append(lines, b.cue.ID)
+			}
+			lines = append(lines, b.cue.timing)
+			lines = append(lines, b.cue.Lines...)
+		default:
+			lines = append(lines, b.raw...)
+		}
+	}
+	nl := "\n"
+	if f.crlf {
+		nl = "\r\n"
+	}
+	s := strings.Join(lines, nl) + nl
+	if f.bom {
+		s = "\ufeff" + s
+	}
+	return s
+}
+
+func parseTime(m []string) time.Duration {
+	n := func(s string) time.Duration {
+		v, _ := strconv.Atoi(s)
+		return time.Duration(v)
+	}
+	return n(m[0])*time.Hour + n(m[1])*time.Minute + n(m[2])*time.Second + n(m[3])*time.Millisecond
+}
+
+func formatTime(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, int(d.Milliseconds())%1000)
+}
+
+// visibleLen returns the number of characters of s shown on screen,
+// without markup or the markers standing for it.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(reMarker.ReplaceAllString(reTag.ReplaceAllString(s, ""), ""))
+}
@@ -0,0 +1,152 @@
+package subtitle_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valpere/peretran/internal/subtitle"
+)
+
+const sampleSRT = `1
+00:00:01,000 --> 00:00:03,000
+I told you that we would
+meet again,
+
+2
+00:00:03,000 --> 00:00:05,000
+and here we are.
+
+3
+00:00:10,000 --> 00:00:12,000
+- Who are you?
+- Nobody.
+
+4
+00:00:12,500 --> 00:00:14,000
+<i>Somewhere far away...</i>
+
+5
+00:00:14,000 --> 00:00:15,000
+<i>a bell rang.</i>
+
+6
+00:00:16,000 --> 00:00:18,000
+It was <b>very</b> loud.
+`
+
+const sampleVTT = `WEBVTT
+Kind: captions
+
+NOTE a comment
+
+intro
+00:01.000 --> 00:03.500 align:start
+{\an8}Hello there.
+`
+
+func TestParse(t *testing.T) {
+	for _, src := range []string{sampleSRT, strings.ReplaceAll(sampleSRT, "\n", "\r\n"), sampleVTT} {
+		f, err := subtitle.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.String(); got != src {
+			t.Errorf("round trip changed the file:\n%s", got)
+		}
+	}
+
+	f, _ := subtitle.Parse(sampleVTT)
+	c := f.Cues[0]
+	if !f.VTT || c.ID != "intro" || c.Start != time.Second || c.End != 3500*time.Millisecond || c.Text() != `{\an8}Hello there.` {
+		t.Errorf("cue = %+v", c)
+	}
+}
+
+func TestUnits(t *testing.T) {
+	f, _ := subtitle.Parse(sampleSRT)
+	var got []string
+	for _, u := range f.Units() {
+		got = append(got, u.Text)
+	}
+	want := []string{
+		"I told you that we would meet again, and here we are.",
+		"- Who are you?\n- Nobody.",
+		"Somewhere far away... a bell rang.",
+		"It was [PH0]very[PH1] loud.",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("units = %q", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	f, _ := subtitle.Parse(sampleSRT)
+	units := f.Units()
+	lim := subtitle.Limits{MaxLineChars: 20, MaxLines: 2, MaxCPS: 17}
+	for i, tr := range []string{
+		"Я казав тобі, що ми ще зустрінемося, і ось ми тут.",
+		"- Хто ти?\n- Ніхто.",
+		"Десь далеко-далеко... задзвонив дзвін.",
+		"Було [PH0]дуже[PH1] гучно.",
+	} {
+		if err := f.Apply(units[i], tr, lim); err != nil {
+			t.Fatalf("unit %d: %v", i, err)
+		}
+	}
+	out := f.String()
+	for _, want := range []string{
+		"1\n00:00:01,000 --> 00:00:03,000\nЯ казав тобі,\nщо ми ще\n\n",
+		"2\n00:00:03,000 --> 00:00:05,000\nзустрінемося,\nі ось ми тут.\n",
+		"00:00:10,000 --> 00:00:12,000\n- Хто ти?\n- Ніхто.\n",
+		"<i>Десь\nдалеко-далеко...</i>\n",
+		"<i>задзвонив дзвін.</i>\n",
+		"Було <b>дуже</b> гучно.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q\n%s", want, out)
+		}
+	}
+
+	if err := f.Apply(units[3], "Було дуже гучно.", lim); err == nil {
+		t.Error("a lost marker should be rejected")
+	}
+	if err := f.Apply(units[0], "Так.", lim); err == nil {
+		t.Error("a translation shorter than its cues should be rejected")
+	}
+	if err := f.Apply(units[0], "我告诉过你我们会再见面，我们来了。", lim); err != nil {
+		t.Errorf("text without spaces is spread by characters: %v", err)
+	}
+	for _, u := range f.Split(units[0]) {
+		if len(u.Cues) != 1 {
+			t.Errorf("Split unit has cues %v", u.Cues)
+		}
+	}
+}
+
+func TestApply_CollapsesLineBreaks(t *testing.T) {
+	f, _ := subtitle.Parse("1\n00:00:01,000 --> 00:00:04,000\nIt was very loud.\n\n2\n00:00:06,000 --> 00:00:08,000\nThe end.\n")
+	units := f.Units()
+	if err := f.Apply(units[0], "Було\n\nдуже гучно.", subtitle.Limits{MaxLineChars: 42, MaxLines: 2}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := subtitle.Parse(f.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Cues) != 2 || again.Cues[0].Text() != "Було дуже гучно." || again.Cues[1].Text() != "The end." {
+		t.Errorf("round trip lost the cue layout:\n%s", f.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	f, _ := subtitle.Parse("1\n00:00:01,000 --> 00:00:02,000\nA line that is far too long for the screen\nsecond\nthird\n")
+	v := f.Check(subtitle.Limits{MaxLineChars: 20, MaxLines: 2, MaxCPS: 17})
+	if len(v) != 1 {
+		t.Fatalf("got %d violations", len(v))
+	}
+	want := "cue 1 (00:00:01.000): 3 lines (max 2); line 1 has 42 characters (max 20); 53.0 characters per second (max 17)"
+	if v[0].String() != want {
+		t.Errorf("violation = %q", v[0].String())
+	}
+}
@@ -0,0 +1,369 @@
+package subtitle
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/valpere/peretran/internal/placeholder"
+)
+
+const (
+	// maxUnitCues is the most cues merged into one unit.
+	maxUnitCues = 4
+	// maxGap is the longest pause between two cues of one unit.
+	maxGap = 2 * time.Second
+)
+
+var (
+	// reWrap splits cue text into the markup wrapping all of it ({\an8},
+	// <i>, <v Speaker>), the body, and the closing tags at its end.
+	reWrap = regexp.MustCompile(`(?s)^((?:<[^/>][^>]*>|\{\\[^}]*\})*)(.*?)((?:</[^>]*>)*)$`)
+
+	reMarker = regexp.MustCompile(`\[PH\d+\]`)
+)
+
+// Limits are the readability limits translated cues are fitted to.
+type Limits struct {
+	// MaxLineChars is the most characters on a line.
+	MaxLineChars int
+	// MaxLines is the most lines in a cue.
+	MaxLines int
+	// MaxCPS is the most characters shown per second.
+	MaxCPS float64
+}
+
+// Unit is a piece translated at once: consecutive cues that make up a
+// sentence, or a single cue.
+type Unit struct {
+	// Text is the text to translate. A cue translated alone because it
+	// has markup inside its text has the markup as [PHn] markers.
+	Text string
+	// Cues are the indices of the unit's cues in File.Cues.
+	Cues []int
+
+	wraps    []wrap
+	markers  []string
+	dialogue int
+}
+
+// PlainText returns Text without the markers.
+func (u *Unit) PlainText() string {
+	return strings.Join(strings.Fields(reMarker.ReplaceAllString(u.Text, " ")), " ")
+}
+
+// Dialogue reports whether u is a cue with a line per speaker, whose
+// translation should keep the lines.
+func (u *Unit) Dialogue() bool {
+	return u.dialogue > 0
+}
+
+// wrap is the markup around the whole text of a cue.
+type wrap struct{ prefix, suffix string }
+
+// Units groups the cues into units. Consecutive cues are merged until a
+// sentence ends, the pause between them exceeds two seconds, their
+// wrapping markup changes, or four cues are merged. A cue with markup
+// inside its text, or with dialogue lines starting with "-", is a unit of
+// its own. Cues without text are left out.
+func (f *File) Units() []*Unit {
+	var units []*Unit
+	var cur *Unit
+	for i, c := range f.Cues {
+		w, body := split(c)
+		if !strings.ContainsFunc(reTag.ReplaceAllString(body, ""), unicode.IsLetter) {
+			cur = nil
+			continue
+		}
+		if u, ok := alone(i, w, body); ok {
+			units = append(units, u)
+			cur = nil
+			continue
+		}
+		text := strings.Join(strings.Fields(body), " ")
+		if cur != nil {
+			last := f.Cues[cur.Cues[len(cur.Cues)-1]]
+			if c.Start-last.End > maxGap || cur.wraps[0] != w || len(cur.Cues) >= maxUnitCues {
+				cur = nil
+			}
+		}
+		if cur == nil {
+			cur = &Unit{Text: text}
+			units = append(units, cur)
+		} else {
+			cur.Text += " " + text
+		}
+		cur.Cues = append(cur.Cues, i)
+		cur.wraps = append(cur.wraps, w)
+		if sentenceEnds(text) {
+			cur = nil
+		}
+	}
+	return units
+}
+
+// Split returns a unit for each cue of u, for when the translation of u
+// cannot be spread over its cues.
+func (f *File) Split(u *Unit) []*Unit {
+	units := make([]*Unit, len(u.Cues))
+	for k, i := range u.Cues {
+		w, body := split(f.Cues[i])
+		units[k] = &Unit{Text: strings.Join(strings.Fields(body), " "), Cues: []int{i}, wraps: []wrap{w}}
+	}
+	return units
+}
+
+// split returns the wrapping markup and the body of a cue.
+func split(c *Cue) (wrap, string) {
+	m := reWrap.FindStringSubmatch(strings.Join(c.Lines, "\n"))
+	return wrap{m[1], m[3]}, m[2]
+}
+
+// alone returns the unit of a cue translated on its own: one with markup
+// inside its text, or a dialogue with a line per speaker.
+func alone(i int, w wrap, body string) (*Unit, bool) {
+	lines := strings.Split(body, "\n")
+	dashes := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "-") {
+			dashes++
+		}
+	}
+	if dashes >= 2 {
+		text, markers := protect(body)
+		return &Unit{Text: text, Cues: []int{i}, wraps: []wrap{w}, markers: markers, dialogue: len(lines)}, true
+	}
+	if reTag.MatchString(body) {
+		text, markers := protect(strings.Join(strings.Fields(body), " "))
+		return &Unit{Text: text, Cues: []int{i}, wraps: []wrap{w}, markers: markers}, true
+	}
+	return nil, false
+}
+
+// protect replaces the markup of cue text with [PHn] markers.
+func protect(text string) (string, []string) {
+	var markers []string
+	text = reTag.ReplaceAllStringFunc(text, func(m string) string {
+		markers = append(markers, m)
+		return fmt.Sprintf("[PH%d]", len(markers)-1)
+	})
+	return text, markers
+}
+
+// sentenceEnds reports whether text ends a sentence. A trailing ellipsis
+// marks a sentence continued in the next cue.
+func sentenceEnds(text string) bool {
+	text = strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"')]»”’`, r)
+	})
+	if strings.HasSuffix(text, "...") || strings.HasSuffix(text, "…") {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return strings.ContainsRune(".!?。！？", r)
+}
+
+// Apply writes the translation of u into its cues. The translation of
+// merged cues is spread over them in proportion to how long each is
+// shown, breaking after punctuation where it can, and every cue is
+// wrapped into lines of at most lim.MaxLineChars characters. It fails
+// when a marker is lost or the translation has fewer words than the unit
+// has cues.
+func (f *File) Apply(u *Unit, translation string, lim Limits) error {
+	translation = strings.TrimSpace(translation)
+	if missing := placeholder.Validate(translation, u.markers); len(missing) > 0 {
+		return fmt.Errorf("translation lost %d of %d markup placeholder(s)", len(missing), len(u.markers))
+	}
+
+	if u.dialogue > 0 {
+		var lines []string
+		for _, line := range strings.Split(translation, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, placeholder.Restore(line, u.markers))
+			}
+		}
+		if len(lines) == u.dialogue {
+			f.setLines(u.Cues[0], u.wraps[0], lines)
+			return nil
+		}
+	}
+	// Line breaks are chosen by wrapLines; a model's own, blank lines
+	// especially, would end the cue early.
+	translation = strings.Join(strings.Fields(translation), " ")
+
+	pieces := []string{translation}
+	if len(u.Cues) > 1 {
+		weights := make([]float64, len(u.Cues))
+		total := 0.0
+		for k, i := range u.Cues {
+			weights[k] = f.Cues[i].Duration().Seconds()
+			total += weights[k]
+		}
+		if total == 0 {
+			for k, i := range u.Cues {
+				weights[k] = float64(visibleLen(f.Cues[i].Text()))
+			}
+		}
+		var err error
+		if pieces, err = distribute(translation, weights); err != nil {
+			return err
+		}
+	}
+	for k, i := range u.Cues {
+		lines := wrapLines(pieces[k], lim)
+		for j := range lines {
+			lines[j] = placeholder.Restore(lines[j], u.markers)
+		}
+		f.setLines(i, u.wraps[k], lines)
+	}
+	return nil
+}
+
+func (f *File) setLines(i int, w wrap, lines []string) {
+	lines[0] = w.prefix + lines[0]
+	lines[len(lines)-1] += w.suffix
+	f.Cues[i].Lines = lines
+}
+
+// tokens splits text into words, or into characters for a language
+// written without spaces, and returns the separator that joins them.
+func tokens(text string) ([]string, string) {
+	if strings.ContainsRune(text, ' ') || !strings.ContainsFunc(text, spaceless) {
+		return strings.Fields(text), " "
+	}
+	var chars []string
+	for _, r := range text {
+		chars = append(chars, string(r))
+	}
+	return chars, ""
+}
+
+// spaceless reports whether r belongs to a script written without spaces
+// between words.
+func spaceless(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
+
+// distribute splits text into len(weights) pieces with lengths in
+// proportion to the weights, at word boundaries, preferring the ends of
+// clauses.
+func distribute(text string, weights []float64) ([]string, error) {
+	words, sep := tokens(text)
+	k := len(weights)
+	if len(words) < k {
+		return nil, fmt.Errorf("translation has %d word(s) for %d cues", len(words), k)
+	}
+	// cum[j] is the length of the first j words.
+	cum := make([]float64, len(words)+1)
+	for j, w := range words {
+		cum[j+1] = cum[j] + float64(visibleLen(w)+len(sep))
+	}
+	total, sum := 0.0, 0.0
+	for _, w := range weights {
+		total += w
+	}
+	bonus := cum[len(words)] / float64(k) * 0.2
+
+	var pieces []string
+	prev := 0
+	for i := 0; i < k-1; i++ {
+		sum += weights[i]
+		target := cum[len(words)] * sum / total
+		best, bestCost := prev+1, math.Inf(1)
+		for j := prev + 1; j <= len(words)-(k-1-i); j++ {
+			cost := math.Abs(cum[j] - target)
+			if r, _ := utf8.DecodeLastRuneInString(words[j-1]); strings.ContainsRune(".,!?;:…。，！？", r) {
+				cost -= bonus
+			}
+			if cost < bestCost {
+				best, bestCost = j, cost
+			}
+		}
+		pieces = append(pieces, strings.Join(words[prev:best], sep))
+		prev = best
+	}
+	return append(pieces, strings.Join(words[prev:], sep)), nil
+}
+
+// wrapLines breaks text into lines of at most lim.MaxLineChars
+// characters: two balanced lines when they fit, otherwise as many full
+// lines as needed.
+func wrapLines(text string, lim Limits) []string {
+	if lim.MaxLineChars <= 0 || visibleLen(text) <= lim.MaxLineChars {
+		return []string{text}
+	}
+	words, sep := tokens(text)
+	best, bestLen := 0, math.MaxInt
+	for j := 1; j < len(words); j++ {
+		n := max(visibleLen(strings.Join(words[:j], sep)), visibleLen(strings.Join(words[j:], sep)))
+		if r, _ := utf8.DecodeLastRuneInString(words[j-1]); strings.ContainsRune(".,!?;:…。，！？", r) {
+			n -= 3
+		}
+		if n < bestLen {
+			best, bestLen = j, n
+		}
+	}
+	if best > 0 {
+		first, second := strings.Join(words[:best], sep), strings.Join(words[best:], sep)
+		if visibleLen(first) <= lim.MaxLineChars && visibleLen(second) <= lim.MaxLineChars {
+			return []string{first, second}
+		}
+	}
+
+	var lines []string
+	line := ""
+	for _, w := range words {
+		if line != "" && visibleLen(line+sep+w) > lim.MaxLineChars {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line == "" {
+			line = w
+		} else {
+			line += sep + w
+		}
+	}
+	return append(lines, line)
+}
+
+// Violation lists how a cue exceeds the limits.
+type Violation struct {
+	Cue      *Cue
+	Problems []string
+}
+
+func (v Violation) String() string {
+	return v.Cue.Label() + ": " + strings.Join(v.Problems, "; ")
+}
+
+// Check returns the cues that exceed lim. A zero limit is not checked.
+func (f *File) Check(lim Limits) []Violation {
+	var violations []Violation
+	for _, c := range f.Cues {
+		var problems []string
+		if lim.MaxLines > 0 && len(c.Lines) > lim.MaxLines {
+			problems = append(problems, fmt.Sprintf("%d lines (max %d)", len(c.Lines), lim.MaxLines))
+		}
+		chars := 0
+		for j, line := range c.Lines {
+			n := visibleLen(line)
+			chars += n
+			if lim.MaxLineChars > 0 && n > lim.MaxLineChars {
+				problems = append(problems, fmt.Sprintf("line %d has %d characters (max %d)", j+1, n, lim.MaxLineChars))
+			}
+		}
+		if d := c.Duration().Seconds(); lim.MaxCPS > 0 && d > 0 {
+			if cps := float64(chars) / d; cps > lim.MaxCPS {
+				problems = append(problems, fmt.Sprintf("%.1f characters per second (max %g)", cps, lim.MaxCPS))
+			}
+		}
+		if len(problems) > 0 {
+			violations = append(violations, Violation{Cue: c, Problems: problems})
+		}
+	}
+	return violations
+}
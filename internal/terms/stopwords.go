@@ -0,0 +1,95 @@
+package terms
+
+import "strings"
+
+// stopwords lists, per language, function words that neither start nor end
+// a phrase candidate and are never domain terms. Entries are lower case.
+var stopwords = map[string][]string{
+	"en": {
+		"a", "an", "the", "and", "or", "but", "nor", "so", "yet", "if", "then", "than",
+		"of", "in", "on", "at", "to", "for", "from", "by", "with", "without", "about",
+		"into", "onto", "over", "under", "between", "through", "after", "before", "as",
+		"is", "are", "was", "were", "be", "been", "being", "am", "has", "have", "had",
+		"do", "does", "did", "will", "would", "shall", "should", "can", "could", "may",
+		"might", "must", "not", "no", "this", "that", "these", "those", "it", "its",
+		"i", "you", "he", "she", "we", "they", "me", "him", "her", "us", "them", "my",
+		"your", "his", "our", "their", "which", "who", "whom", "whose", "what", "when",
+		"where", "why", "how", "all", "any", "each", "every", "some", "such", "more",
+		"most", "other", "also", "only", "very", "just", "there", "here", "up", "out",
+	},
+	"uk": {
+		"і", "й", "та", "а", "але", "або", "чи", "що", "як", "це", "той", "ця", "ці", "цей",
+		"в", "у", "на", "з", "із", "зі", "до", "від", "для", "по", "про", "при", "за", "під",
+		"над", "між", "через", "без", "після", "перед", "не", "ні", "так", "же", "б", "би",
+		"я", "ти", "він", "вона", "воно", "ми", "ви", "вони", "його", "її", "їх", "мій",
+		"твій", "свій", "наш", "ваш", "який", "яка", "яке", "які", "коли", "де", "там",
+		"тут", "є", "був", "була", "було", "були", "буде", "бути", "також", "вже", "ще",
+	},
+	"ru": {
+		"и", "а", "но", "или", "что", "как", "это", "тот", "эта", "эти", "этот", "в", "во",
+		"на", "с", "со", "к", "ко", "до", "от", "для", "по", "о", "об", "при", "за", "под",
+		"над", "между", "через", "без", "после", "перед", "не", "ни", "так", "же", "бы",
+		"я", "ты", "он", "она", "оно", "мы", "вы", "они", "его", "ее", "её", "их", "мой",
+		"твой", "свой", "наш", "ваш", "который", "которая", "которое", "которые", "когда",
+		"где", "там", "тут", "есть", "был", "была", "было", "были", "будет", "быть",
+		"также", "уже", "еще", "ещё",
+	},
+	"de": {
+		"der", "die", "das", "den", "dem", "des", "ein", "eine", "einen", "einem", "einer",
+		"eines", "und", "oder", "aber", "wenn", "dann", "als", "wie", "in", "im", "an",
+		"am", "auf", "aus", "bei", "mit", "nach", "von", "vom", "zu", "zum", "zur", "für",
+		"über", "unter", "durch", "ohne", "ist", "sind", "war", "waren", "sein", "hat",
+		"haben", "wird", "werden", "kann", "nicht", "kein", "keine", "es", "ich", "du",
+		"er", "sie", "wir", "ihr", "sich", "auch", "nur", "noch", "schon", "so", "dass",
+	},
+	"fr": {
+		"le", "la", "les", "l", "un", "une", "des", "du", "de", "d", "et", "ou", "mais",
+		"si", "que", "qui", "quoi", "dont", "où", "en", "dans", "sur", "sous", "à", "au",
+		"aux", "par", "pour", "avec", "sans", "entre", "est", "sont", "était", "être",
+		"a", "ont", "avoir", "ne", "pas", "plus", "ce", "cet", "cette", "ces", "il",
+		"elle", "ils", "elles", "on", "nous", "vous", "je", "tu", "se", "son", "sa", "ses",
+		"leur", "leurs", "aussi", "très",
+	},
+	"es": {
+		"el", "la", "los", "las", "un", "una", "unos", "unas", "y", "e", "o", "u", "pero",
+		"si", "que", "quien", "donde", "de", "del", "en", "a", "al", "por", "para", "con",
+		"sin", "sobre", "entre", "es", "son", "era", "ser", "estar", "está", "ha", "han",
+		"haber", "no", "más", "este", "esta", "estos", "estas", "ese", "esa", "lo", "le",
+		"les", "se", "su", "sus", "yo", "tú", "él", "ella", "nosotros", "ellos", "también",
+		"muy",
+	},
+	"it": {
+		"il", "lo", "la", "i", "gli", "le", "un", "uno", "una", "e", "ed", "o", "ma", "se",
+		"che", "chi", "dove", "di", "del", "della", "dei", "delle", "in", "nel", "nella",
+		"a", "al", "alla", "da", "dal", "per", "con", "su", "sul", "tra", "fra", "è",
+		"sono", "era", "essere", "ha", "hanno", "non", "più", "questo", "questa", "quello",
+		"quella", "si", "suo", "sua", "anche", "molto",
+	},
+	"pt": {
+		"o", "a", "os", "as", "um", "uma", "uns", "umas", "e", "ou", "mas", "se", "que",
+		"quem", "onde", "de", "do", "da", "dos", "das", "em", "no", "na", "nos", "nas",
+		"ao", "à", "por", "para", "com", "sem", "sobre", "entre", "é", "são", "era",
+		"ser", "estar", "está", "tem", "não", "mais", "este", "esta", "esse", "essa",
+		"seu", "sua", "também", "muito",
+	},
+	"pl": {
+		"i", "a", "ale", "lub", "albo", "czy", "że", "jak", "to", "ten", "ta", "te", "w",
+		"we", "na", "z", "ze", "do", "od", "dla", "po", "o", "przy", "za", "pod", "nad",
+		"między", "przez", "bez", "nie", "jest", "są", "był", "była", "było", "być",
+		"się", "jego", "jej", "ich", "który", "która", "które", "także", "też", "już",
+	},
+}
+
+// stopwordSet returns the stopwords of lang (region stripped) as a set; an
+// unknown language has none.
+func stopwordSet(lang string) map[string]bool {
+	base := strings.ToLower(lang)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	set := make(map[string]bool, len(stopwords[base]))
+	for _, w := range stopwords[base] {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,374 @@
+// Package terms finds terminology candidates in a source corpus: names and
+// other capitalized phrases, frequent multi-word phrases, and single words
+// that look domain-specific (acronyms, compounds, identifiers and long
+// words). The candidates bootstrap a glossary; they are meant for review,
+// not for direct use.
+package terms
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/valpere/peretran/internal/segmenter"
+)
+
+// Kind classifies a candidate by how it was found.
+type Kind string
+
+const (
+	// KindName is a capitalized word or phrase: a person, place, product.
+	KindName Kind = "name"
+	// KindPhrase is a frequent multi-word phrase.
+	KindPhrase Kind = "phrase"
+	// KindDomain is a single word that looks domain-specific.
+	KindDomain Kind = "domain"
+)
+
+// Extraction defaults.
+const (
+	DefaultMinCount = 3
+	DefaultMaxWords = 3
+	DefaultLimit    = 100
+)
+
+// domainWordLen is the length from which a frequent word counts as a
+// domain term even without other signs.
+const domainWordLen = 9
+
+// maxExampleLen caps Candidate.Example, in runes.
+const maxExampleLen = 200
+
+// Candidate is a proposed glossary term.
+type Candidate struct {
+	Term  string
+	Kind  Kind
+	Count int
+	// Example is the first sentence of the corpus containing the term.
+	Example string
+}
+
+// Options tunes Extract. Zero values mean the defaults.
+type Options struct {
+	// MinCount is how often a term must occur.
+	MinCount int
+	// MaxWords is the longest phrase considered, in words.
+	MaxWords int
+	// Limit caps the number of candidates returned.
+	Limit int
+}
+
+// Extract returns the terminology candidates of text, a corpus in lang,
+// most frequent first. A phrase that only occurs inside a longer candidate
+// is left out, as is a phrase that is also found as a name.
+func Extract(lang, text string, opts Options) []Candidate {
+	if opts.MinCount <= 0 {
+		opts.MinCount = DefaultMinCount
+	}
+	if opts.MaxWords <= 0 {
+		opts.MaxWords = DefaultMaxWords
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+	stop := stopwordSet(lang)
+	sentences := Sentences(lang, text)
+
+	found := map[string]*Candidate{}
+	var order []string
+	add := func(key, term string, kind Kind, sentence string) {
+		c, ok := found[key]
+		if !ok {
+			c = &Candidate{Term: term, Kind: kind, Example: truncate(sentence, maxExampleLen)}
+			found[key] = c
+			order = append(order, key)
+		}
+		c.Count++
+	}
+
+	for i, names := range Names(lang, sentences) {
+		for _, name := range names {
+			add(strings.ToLower(name), name, KindName, sentences[i])
+		}
+	}
+
+	// surface remembers the most frequent spelling of a word, so acronyms
+	// and identifiers keep their case.
+	surface := map[string]map[string]int{}
+	for _, sentence := range sentences {
+		for _, segment := range segments(sentence) {
+			lower := make([]string, len(segment))
+			for i, w := range segment {
+				lower[i] = strings.ToLower(w)
+				if surface[lower[i]] == nil {
+					surface[lower[i]] = map[string]int{}
+				}
+				surface[lower[i]][w]++
+			}
+			for n := 2; n <= opts.MaxWords; n++ {
+				for i := 0; i+n <= len(lower); i++ {
+					gram := lower[i : i+n]
+					if stop[gram[0]] || stop[gram[n-1]] || hasNumber(gram) {
+						continue
+					}
+					phrase := strings.Join(gram, " ")
+					if c, ok := found[phrase]; ok && c.Kind == KindName {
+						continue
+					}
+					add(phrase, phrase, KindPhrase, sentence)
+				}
+			}
+			for i, w := range segment {
+				if !stop[lower[i]] && looksDomain(w) {
+					if c, ok := found[lower[i]]; ok && c.Kind == KindName {
+						continue
+					}
+					add(lower[i], lower[i], KindDomain, sentence)
+				}
+			}
+		}
+	}
+
+	var out []Candidate
+	for _, key := range order {
+		c := found[key]
+		if c.Count < opts.MinCount {
+			continue
+		}
+		if c.Kind == KindDomain {
+			c.Term = mostFrequent(surface[key])
+		}
+		out = append(out, *c)
+	}
+	out = dropSubsumed(out)
+
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].Count != out[b].Count {
+			return out[a].Count > out[b].Count
+		}
+		return len(strings.Fields(out[a].Term)) > len(strings.Fields(out[b].Term))
+	})
+	if len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out
+}
+
+// Sentences splits text into lines and the lines into sentences, so a
+// heading without a full stop does not run into the next paragraph.
+func Sentences(lang, text string) []string {
+	seg := segmenter.New(lang)
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		sentences = append(sentences, seg.Split(line)...)
+	}
+	return sentences
+}
+
+// Names returns the capitalized words and phrases of each text. Every text
+// is taken as one sentence or more; a capitalized word at the start of a
+// sentence only counts if it is also found capitalized inside a sentence
+// of any text, so "The" and "When" are not taken for names, and a phrase
+// starting a sentence loses its first word otherwise ("The Station Master"
+// yields "Station Master"). Scripts without capitals yield no names.
+func Names(lang string, texts []string) [][]string {
+	seg := segmenter.New(lang)
+	runs := make([][]capRun, len(texts))
+	inside := map[string]bool{}
+	for i, text := range texts {
+		for _, sentence := range seg.Split(text) {
+			for _, r := range capitalizedRuns(sentence) {
+				runs[i] = append(runs[i], r)
+				switch {
+				case !r.atStart:
+					inside[strings.Join(r.words, " ")] = true
+				case len(r.words) > 1:
+					// "Ask Maria Lopez": the words after the first are
+					// capitalized inside the sentence.
+					inside[strings.Join(r.words[1:], " ")] = true
+				}
+			}
+		}
+	}
+
+	names := make([][]string, len(texts))
+	for i, textRuns := range runs {
+		for _, r := range textRuns {
+			phrase := strings.Join(r.words, " ")
+			switch {
+			case !r.atStart || inside[phrase]:
+			case len(r.words) > 1:
+				phrase = strings.Join(r.words[1:], " ")
+			default:
+				continue
+			}
+			if utf8.RuneCountInString(phrase) > 1 {
+				names[i] = append(names[i], phrase)
+			}
+		}
+	}
+	return names
+}
+
+// capRun is a run of consecutive capitalized words in a sentence.
+type capRun struct {
+	words   []string
+	atStart bool
+}
+
+// capitalizedRuns returns the runs of capitalized words in sentence. Words
+// are separated by whitespace only; punctuation between them ends a run,
+// and a possessive "'s" is dropped ("Anna's" counts as "Anna").
+func capitalizedRuns(sentence string) []capRun {
+	var runs []capRun
+	var cur *capRun
+	for n, field := range strings.Fields(sentence) {
+		word := strings.TrimLeftFunc(field, isOpener)
+		trimmed := strings.TrimRightFunc(word, isTrailing)
+		for _, suffix := range []string{"'s", "’s"} {
+			trimmed = strings.TrimSuffix(trimmed, suffix)
+		}
+		first, _ := utf8.DecodeRuneInString(trimmed)
+		if trimmed == "" || !unicode.IsUpper(first) {
+			cur = nil
+			continue
+		}
+
+		if cur != nil && word == field {
+			cur.words = append(cur.words, trimmed)
+		} else {
+			runs = append(runs, capRun{words: []string{trimmed}, atStart: n == 0})
+			cur = &runs[len(runs)-1]
+		}
+		if trimmed != word {
+			cur = nil
+		}
+	}
+	return runs
+}
+
+// segments splits sentence into runs of words not separated by punctuation,
+// so phrases do not span commas, brackets or quotes. Inner hyphens and
+// apostrophes belong to the word.
+func segments(sentence string) [][]string {
+	var segs [][]string
+	var cur []string
+	var word strings.Builder
+	flushWord := func() {
+		if w := strings.Trim(word.String(), "-'’"); w != "" {
+			cur = append(cur, w)
+		}
+		word.Reset()
+	}
+	flushSegment := func() {
+		flushWord()
+		if len(cur) > 0 {
+			segs = append(segs, cur)
+		}
+		cur = nil
+	}
+	for _, r := range sentence {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '-' || r == '\'' || r == '’':
+			word.WriteRune(r)
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			flushSegment()
+		}
+	}
+	flushSegment()
+	return segs
+}
+
+// looksDomain reports a word that is likely terminology rather than
+// general vocabulary: an acronym ("API"), a mixed-case identifier
+// ("JavaScript"), a compound with letters and digits or hyphens ("IPv6",
+// "end-to-end") or a long word.
+func looksDomain(w string) bool {
+	var letters, upper, digits, hyphens int
+	for _, r := range w {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+			letters++
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsDigit(r):
+			digits++
+		case r == '-':
+			hyphens++
+		}
+	}
+	first, _ := utf8.DecodeRuneInString(w)
+	switch {
+	case letters == 0:
+		return false
+	case upper >= 2 && upper == letters:
+		return true
+	case upper >= 2 || (upper == 1 && !unicode.IsUpper(first)):
+		return true
+	case digits > 0 || hyphens > 0:
+		return letters >= 2
+	}
+	return letters >= domainWordLen
+}
+
+// dropSubsumed removes candidates that only occur as part of a longer
+// candidate, i.e. whose count equals that of a candidate containing them.
+func dropSubsumed(cands []Candidate) []Candidate {
+	var out []Candidate
+	for i, c := range cands {
+		inner := " " + strings.ToLower(c.Term) + " "
+		subsumed := false
+		for j, other := range cands {
+			if i == j || other.Count != c.Count || len(other.Term) <= len(c.Term) {
+				continue
+			}
+			if strings.Contains(" "+strings.ToLower(other.Term)+" ", inner) {
+				subsumed = true
+				break
+			}
+		}
+		if !subsumed {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func hasNumber(words []string) bool {
+	for _, w := range words {
+		if strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func mostFrequent(counts map[string]int) string {
+	best, bestN := "", 0
+	for s, n := range counts {
+		if n > bestN || (n == bestN && s < best) {
+			best, bestN = s, n
+		}
+	}
+	return best
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+func isOpener(r rune) bool {
+	return unicode.In(r, unicode.Pi, unicode.Ps) || r == '"' || r == '\''
+}
+
+// isTrailing reports punctuation after a word: "Anna," becomes "Anna".
+func isTrailing(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,89 @@
+package terms_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/valpere/peretran/internal/terms"
+)
+
+const corpus = `Kubernetes Cluster Setup
+
+The control plane schedules pods on worker nodes. Each worker node runs a kubelet.
+When the control plane fails, pods keep running on worker nodes. Ask Maria Lopez.
+The API server is part of the control plane. Maria Lopez wrote the API docs.
+Use end-to-end encryption between worker nodes, as Maria Lopez advised; the API checks it.
+Kubernetes restarts pods. Kubernetes also handles end-to-end tests and containerization, so containerization matters: containerization rules.`
+
+func find(cands []terms.Candidate, term string) (terms.Candidate, bool) {
+	for _, c := range cands {
+		if c.Term == term {
+			return c, true
+		}
+	}
+	return terms.Candidate{}, false
+}
+
+func TestExtract(t *testing.T) {
+	got := terms.Extract("en", corpus, terms.Options{})
+
+	for _, tt := range []struct {
+		term  string
+		kind  terms.Kind
+		count int
+	}{
+		{"control plane", terms.KindPhrase, 3},
+		{"worker nodes", terms.KindPhrase, 3},
+		{"Maria Lopez", terms.KindName, 3},
+		{"API", terms.KindName, 3},
+		{"containerization", terms.KindDomain, 3},
+	} {
+		c, ok := find(got, tt.term)
+		if !ok {
+			t.Errorf("missing candidate %q in %+v", tt.term, got)
+			continue
+		}
+		if c.Kind != tt.kind || c.Count != tt.count {
+			t.Errorf("%q: got kind %s count %d, want %s %d", tt.term, c.Kind, c.Count, tt.kind, tt.count)
+		}
+	}
+
+	// end-to-end occurs only twice, below the default minimum count.
+	for _, unwanted := range []string{"the control", "plane", "The", "When", "end-to-end"} {
+		if _, ok := find(got, unwanted); ok {
+			t.Errorf("unexpected candidate %q", unwanted)
+		}
+	}
+
+	if c, _ := find(got, "control plane"); c.Example != "The control plane schedules pods on worker nodes." {
+		t.Errorf("unexpected example %q", c.Example)
+	}
+}
+
+func TestExtract_OptionsAndOrder(t *testing.T) {
+	got := terms.Extract("en", corpus, terms.Options{MinCount: 2, Limit: 3})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 candidates, got %+v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Count > got[i-1].Count {
+			t.Errorf("candidates not ordered by count: %+v", got)
+		}
+	}
+	all := terms.Extract("en", corpus, terms.Options{MinCount: 2})
+	if _, ok := find(all, "end-to-end"); !ok {
+		t.Errorf("expected end-to-end with --min-count 2, got %+v", all)
+	}
+}
+
+func TestNames(t *testing.T) {
+	got := terms.Names("en", []string{
+		"The Station Master waved. Anna's brother came.",
+		"Then he saw Anna. When it rained, nobody left.",
+		"Ask Maria Lopez. Maria Lopez knows.",
+	})
+	want := [][]string{{"Station Master", "Anna"}, {"Anna"}, {"Maria Lopez", "Maria Lopez"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
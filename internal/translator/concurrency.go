@@ -0,0 +1,157 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ConcurrencyLimiter bounds the number of in-flight requests an LLM backend
+// receives, both per model and across all models of the backend. It is shared
+// between the per-model services created for fan-out, so e.g. four Ollama
+// models on one GPU box can be limited to one request at a time in total.
+// A nil *ConcurrencyLimiter imposes no limits.
+type ConcurrencyLimiter struct {
+	total chan struct{}
+
+	mu           sync.Mutex
+	models       map[string]chan struct{}
+	modelLimits  map[string]int
+	defaultLimit int
+}
+
+// NewConcurrencyLimiter creates a limiter. total caps in-flight requests
+// across all models; defaultPerModel caps each model not listed in perModel.
+// Zero or negative values mean unlimited.
+func NewConcurrencyLimiter(total, defaultPerModel int, perModel map[string]int) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		models:       make(map[string]chan struct{}),
+		modelLimits:  perModel,
+		defaultLimit: defaultPerModel,
+	}
+	if total > 0 {
+		l.total = make(chan struct{}, total)
+	}
+	return l
+}
+
+// Acquire blocks until a slot for model is free or ctx is done. The returned
+// release function must be called exactly once when the request completes.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, model string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	modelSem := l.modelSem(model)
+	if modelSem != nil {
+		select {
+		case modelSem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if l.total != nil {
+		select {
+		case l.total <- struct{}{}:
+		case <-ctx.Done():
+			if modelSem != nil {
+				<-modelSem
+			}
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() {
+		if l.total != nil {
+			<-l.total
+		}
+		if modelSem != nil {
+			<-modelSem
+		}
+	}, nil
+}
+
+// TryAcquire takes a slot for model only if one is free right now. ok is
+// false when the model or the backend is at its limit.
+func (l *ConcurrencyLimiter) TryAcquire(model string) (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+
+	modelSem := l.modelSem(model)
+	if modelSem != nil {
+		select {
+		case modelSem <- struct{}{}:
+		default:
+			return nil, false
+		}
+	}
+	if l.total != nil {
+		select {
+		case l.total <- struct{}{}:
+		default:
+			if modelSem != nil {
+				<-modelSem
+			}
+			return nil, false
+		}
+	}
+
+	return func() {
+		if l.total != nil {
+			<-l.total
+		}
+		if modelSem != nil {
+			<-modelSem
+		}
+	}, true
+}
+
+func (l *ConcurrencyLimiter) modelSem(model string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if sem, ok := l.models[model]; ok {
+		return sem
+	}
+	limit := l.defaultLimit
+	if n, ok := l.modelLimits[model]; ok {
+		limit = n
+	}
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+	l.models[model] = sem
+	return sem
+}
+
+// ParseModelLimits parses per-model concurrency specs of the form "model=N".
+// A bare "N" sets the default limit applied to every unlisted model.
+func ParseModelLimits(specs []string) (map[string]int, int, error) {
+	limits := make(map[string]int)
+	defaultLimit := 0
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		i := strings.LastIndex(spec, "=")
+		if i < 0 {
+			n, err := strconv.Atoi(spec)
+			if err != nil || n < 0 {
+				return nil, 0, fmt.Errorf("invalid concurrency limit %q", spec)
+			}
+			defaultLimit = n
+			continue
+		}
+		n, err := strconv.Atoi(spec[i+1:])
+		if err != nil || n < 0 {
+			return nil, 0, fmt.Errorf("invalid concurrency limit in %q", spec)
+		}
+		limits[spec[:i]] = n
+	}
+	return limits, defaultLimit, nil
+}
@@ -0,0 +1,111 @@
+package translator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter_Nil(t *testing.T) {
+	var l *ConcurrencyLimiter
+
+	release, err := l.Acquire(context.Background(), "any")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+}
+
+func TestConcurrencyLimiter_TotalLimit(t *testing.T) {
+	l := NewConcurrencyLimiter(1, 0, nil)
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for _, model := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(m string) {
+			defer wg.Done()
+			release, err := l.Acquire(context.Background(), m)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			inFlight.Add(-1)
+			release()
+		}(model)
+	}
+	wg.Wait()
+
+	if peak.Load() != 1 {
+		t.Errorf("expected at most 1 request in flight, saw %d", peak.Load())
+	}
+}
+
+func TestConcurrencyLimiter_PerModelLimit(t *testing.T) {
+	l := NewConcurrencyLimiter(0, 0, map[string]int{"gemma2:27b": 1})
+
+	release, err := l.Acquire(context.Background(), "gemma2:27b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	// Another model is not affected by gemma2's limit.
+	other, err := l.Acquire(context.Background(), "qwen3:14b")
+	if err != nil {
+		t.Fatalf("unexpected error for unlimited model: %v", err)
+	}
+	other()
+
+	// A second gemma2 request waits until the context gives up.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "gemma2:27b"); err == nil {
+		t.Error("expected second acquire of a saturated model to time out")
+	}
+}
+
+func TestParseModelLimits(t *testing.T) {
+	limits, def, err := ParseModelLimits([]string{"2", "gemma2:27b=1", "qwen3:14b=3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if def != 2 {
+		t.Errorf("expected default limit 2, got %d", def)
+	}
+	if limits["gemma2:27b"] != 1 || limits["qwen3:14b"] != 3 {
+		t.Errorf("unexpected per-model limits: %v", limits)
+	}
+
+	if _, _, err := ParseModelLimits([]string{"gemma2:27b=many"}); err == nil {
+		t.Error("expected error for non-numeric limit")
+	}
+}
+
+func TestConcurrencyLimiter_TryAcquire(t *testing.T) {
+	l := NewConcurrencyLimiter(1, 0, nil)
+
+	release, ok := l.TryAcquire("a")
+	if !ok {
+		t.Fatal("expected a free slot")
+	}
+	if _, ok := l.TryAcquire("b"); ok {
+		t.Error("expected no slot while the backend is at its limit")
+	}
+	release()
+	if release, ok := l.TryAcquire("b"); !ok {
+		t.Error("expected the released slot to be free")
+	} else {
+		release()
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/valpere/peretran/internal/prompts"
 )
 
 func TestMyMemoryService_IsAvailable(t *testing.T) {
@@ -157,6 +161,34 @@ func TestOllamaTranslator_Translate_Success(t *testing.T) {
 	}
 }
 
+func TestOllamaTranslator_Translate_FinishReason(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"response":    "Привіт, як",
+			"done_reason": "length",
+		})
+	}))
+	defer server.Close()
+
+	svc := &OllamaTranslator{
+		baseURL: server.URL,
+		models:  []string{"llama3.2"},
+		client:  server.Client(),
+	}
+
+	result, err := svc.Translate(context.Background(), ServiceConfig{}, TranslateRequest{
+		Text:       "Hello, how are you?",
+		SourceLang: "en",
+		TargetLang: "uk",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Metadata["finish_reason"] != "length" {
+		t.Errorf("expected finish_reason length in metadata, got %v", result.Metadata)
+	}
+}
+
 func TestOllamaTranslator_Translate_AutoSourceLang(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req map[string]interface{}
@@ -293,3 +325,41 @@ func TestOllamaTranslator_SetModels_Empty(t *testing.T) {
 		t.Errorf("expected 1 model (unchanged), got %d", len(got))
 	}
 }
+
+func TestOllamaTranslator_Translate_CustomPromptTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "translate.tmpl"), []byte("{{.SourceLang}}>{{.TargetLang}}: {{.Source}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := prompts.Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load prompts: %v", err)
+	}
+
+	var gotPrompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		gotPrompt, _ = req["prompt"].(string)
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": "Привіт"})
+	}))
+	defer server.Close()
+
+	svc := NewOllamaTranslator(server.URL, []string{"llama3.2"})
+	svc.SetPrompts(set)
+
+	result, err := svc.Translate(context.Background(), ServiceConfig{}, TranslateRequest{
+		Text:       "Hello",
+		SourceLang: "en",
+		TargetLang: "uk",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPrompt != "en>uk: Hello" {
+		t.Errorf("expected custom prompt, got %q", gotPrompt)
+	}
+	if result.Metadata["prompt_template"] != set.ID(prompts.Translate) {
+		t.Errorf("expected prompt template ID in metadata, got %v", result.Metadata)
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/valpere/peretran/internal/postprocess"
+	"github.com/valpere/peretran/internal/prompts"
 )
 
 var DefaultOllamaModels = []string{
@@ -22,9 +22,13 @@ var DefaultOllamaModels = []string{
 }
 
 type OllamaTranslator struct {
-	baseURL string
-	models  []string
-	client  *http.Client
+	name     string
+	baseURL  string
+	models   []string
+	rotation *ModelRotation
+	limiter  *ConcurrencyLimiter
+	prompts  *prompts.Set
+	client   *http.Client
 }
 
 func NewOllamaTranslator(baseURL string, models []string) *OllamaTranslator {
@@ -42,19 +46,71 @@ func NewOllamaTranslator(baseURL string, models []string) *OllamaTranslator {
 }
 
 func (s *OllamaTranslator) Name() string {
+	if s.name != "" {
+		return s.name
+	}
 	return "ollama"
 }
 
-func (s *OllamaTranslator) getRandomModel() string {
+// NextModel advances the model rotation and returns the model for the next
+// call. Without a configured rotation a random model is picked.
+func (s *OllamaTranslator) NextModel() string {
 	if len(s.models) == 0 {
 		return "llama3.2"
 	}
-	return s.models[rand.Intn(len(s.models))]
+	if s.rotation == nil {
+		return s.models[rand.Intn(len(s.models))]
+	}
+	return s.rotation.Next()
 }
 
 func (s *OllamaTranslator) SetModels(models []string) {
 	if len(models) > 0 {
 		s.models = models
+		if s.rotation != nil {
+			s.rotation, _ = NewModelRotation(s.rotation.Policy(), models, nil, s.rotation.rng.Int63())
+		}
+	}
+}
+
+// SetRotation replaces the model list and selection policy with r.
+func (s *OllamaTranslator) SetRotation(r *ModelRotation) {
+	s.rotation = r
+	s.models = r.Models()
+}
+
+// SetLimiter bounds concurrent requests per model and across the backend.
+// Copies made by ForModel share the limiter.
+func (s *OllamaTranslator) SetLimiter(l *ConcurrencyLimiter) {
+	s.limiter = l
+}
+
+// AcquireSlot waits for a free request slot for model under the configured
+// limiter. The orchestrator calls it before each attempt.
+func (s *OllamaTranslator) AcquireSlot(ctx context.Context, model string) (func(), error) {
+	return s.limiter.Acquire(ctx, model)
+}
+
+// TryAcquireSlot takes a request slot for model only if one is free now.
+func (s *OllamaTranslator) TryAcquireSlot(model string) (func(), bool) {
+	return s.limiter.TryAcquire(model)
+}
+
+// SetPrompts sets the prompt templates; nil means the embedded defaults.
+func (s *OllamaTranslator) SetPrompts(p *prompts.Set) {
+	s.prompts = p
+}
+
+// ForModel returns a copy of the translator pinned to a single model and
+// named "ollama/<model>", for fan-out where every model is its own candidate.
+func (s *OllamaTranslator) ForModel(model string) *OllamaTranslator {
+	return &OllamaTranslator{
+		name:    modelResultName("ollama", model),
+		baseURL: s.baseURL,
+		models:  []string{model},
+		limiter: s.limiter,
+		prompts: s.prompts,
+		client:  s.client,
 	}
 }
 
@@ -65,15 +121,20 @@ func (s *OllamaTranslator) Translate(ctx context.Context, cfg ServiceConfig, req
 
 	model := cfg.Model
 	if model == "" {
-		model = s.getRandomModel()
+		model = s.NextModel()
 	}
+	result.ServiceName = modelResultName("ollama", model)
 
 	sourceLang := req.SourceLang
 	if sourceLang == "" || sourceLang == "auto" {
 		sourceLang = "detect"
 	}
 
-	prompt := buildOllamaPrompt(sourceLang, req.TargetLang, req.Text, req.PreviousContext, req.GlossaryTerms, req.Instructions)
+	prompt, err := s.prompts.Render(prompts.Translate, promptData(sourceLang, req))
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
 
 	ollamaReq := map[string]interface{}{
 		"model":  model,
@@ -108,7 +169,8 @@ func (s *OllamaTranslator) Translate(ctx context.Context, cfg ServiceConfig, req
 	}
 
 	var ollamaResp struct {
-		Response string `json:"response"`
+		Response   string `json:"response"`
+		DoneReason string `json:"done_reason"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
@@ -118,7 +180,13 @@ func (s *OllamaTranslator) Translate(ctx context.Context, cfg ServiceConfig, req
 
 	result.TranslatedText = postprocess.Clean(ollamaResp.Response)
 	result.Confidence = 0.7
-	result.Metadata = map[string]string{"model": model}
+	result.Metadata = map[string]string{
+		"model":           model,
+		"prompt_template": s.prompts.ID(prompts.Translate),
+	}
+	if ollamaResp.DoneReason != "" {
+		result.Metadata["finish_reason"] = ollamaResp.DoneReason
+	}
 
 	return result, nil
 }
@@ -143,33 +211,3 @@ func (s *OllamaTranslator) SupportedLanguages(ctx context.Context) ([]string, er
 func (s *OllamaTranslator) GetModels() []string {
 	return s.models
 }
-
-// buildOllamaPrompt constructs the Ollama translation prompt, optionally
-// prepending glossary terms, a sliding-window context, and extra instructions.
-func buildOllamaPrompt(sourceLang, targetLang, text, previousContext string, glossary map[string]string, instructions string) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("Translate the following text from %s to %s.\n", sourceLang, targetLang))
-	sb.WriteString("Only respond with the translation, nothing else.")
-
-	if instructions != "" {
-		sb.WriteString(" ")
-		sb.WriteString(instructions)
-	}
-	sb.WriteString("\n\n")
-
-	if len(glossary) > 0 {
-		sb.WriteString("TERMINOLOGY (use these exact translations):\n")
-		for src, tgt := range glossary {
-			sb.WriteString(fmt.Sprintf("  %s → %s\n", src, tgt))
-		}
-		sb.WriteString("\n")
-	}
-
-	if previousContext != "" {
-		sb.WriteString(fmt.Sprintf("CONTEXT (previous passage for continuity — do NOT retranslate this):\n...%s\n\n", previousContext))
-	}
-
-	sb.WriteString(fmt.Sprintf("Text: \"%s\"\n\nTranslation:", text))
-	return sb.String()
-}
@@ -7,10 +7,10 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/valpere/peretran/internal/postprocess"
+	"github.com/valpere/peretran/internal/prompts"
 )
 
 var DefaultOpenRouterModels = []string{
@@ -20,11 +20,19 @@ var DefaultOpenRouterModels = []string{
 	"meta-llama/llama-3.1-8b-instruct:free",
 }
 
+// OpenRouterMaxTokens is the max_tokens limit sent with every request; longer
+// translations are cut off with finish_reason "length".
+const OpenRouterMaxTokens = 4096
+
 type OpenRouterService struct {
-	apiKey  string
-	baseURL string
-	models  []string
-	client  *http.Client
+	name     string
+	apiKey   string
+	baseURL  string
+	models   []string
+	rotation *ModelRotation
+	limiter  *ConcurrencyLimiter
+	prompts  *prompts.Set
+	client   *http.Client
 }
 
 func NewOpenRouterService(apiKey string, baseURL string, models []string) *OpenRouterService {
@@ -43,19 +51,72 @@ func NewOpenRouterService(apiKey string, baseURL string, models []string) *OpenR
 }
 
 func (s *OpenRouterService) Name() string {
+	if s.name != "" {
+		return s.name
+	}
 	return "openrouter"
 }
 
-func (s *OpenRouterService) getRandomModel() string {
+// NextModel advances the model rotation and returns the model for the next
+// call. Without a configured rotation a random model is picked.
+func (s *OpenRouterService) NextModel() string {
 	if len(s.models) == 0 {
 		return "google/gemini-2.0-flash-exp:free"
 	}
-	return s.models[rand.Intn(len(s.models))]
+	if s.rotation == nil {
+		return s.models[rand.Intn(len(s.models))]
+	}
+	return s.rotation.Next()
 }
 
 func (s *OpenRouterService) SetModels(models []string) {
 	if len(models) > 0 {
 		s.models = models
+		if s.rotation != nil {
+			s.rotation, _ = NewModelRotation(s.rotation.Policy(), models, nil, s.rotation.rng.Int63())
+		}
+	}
+}
+
+// SetRotation replaces the model list and selection policy with r.
+func (s *OpenRouterService) SetRotation(r *ModelRotation) {
+	s.rotation = r
+	s.models = r.Models()
+}
+
+// SetLimiter bounds concurrent requests per model and across the backend.
+// Copies made by ForModel share the limiter.
+func (s *OpenRouterService) SetLimiter(l *ConcurrencyLimiter) {
+	s.limiter = l
+}
+
+// AcquireSlot waits for a free request slot for model under the configured
+// limiter. The orchestrator calls it before each attempt.
+func (s *OpenRouterService) AcquireSlot(ctx context.Context, model string) (func(), error) {
+	return s.limiter.Acquire(ctx, model)
+}
+
+// TryAcquireSlot takes a request slot for model only if one is free now.
+func (s *OpenRouterService) TryAcquireSlot(model string) (func(), bool) {
+	return s.limiter.TryAcquire(model)
+}
+
+// SetPrompts sets the prompt templates; nil means the embedded defaults.
+func (s *OpenRouterService) SetPrompts(p *prompts.Set) {
+	s.prompts = p
+}
+
+// ForModel returns a copy of the service pinned to a single model and named
+// "openrouter/<model>", for fan-out where every model is its own candidate.
+func (s *OpenRouterService) ForModel(model string) *OpenRouterService {
+	return &OpenRouterService{
+		name:    modelResultName("openrouter", model),
+		apiKey:  s.apiKey,
+		baseURL: s.baseURL,
+		models:  []string{model},
+		limiter: s.limiter,
+		prompts: s.prompts,
+		client:  s.client,
 	}
 }
 
@@ -74,14 +135,22 @@ func (s *OpenRouterService) Translate(ctx context.Context, cfg ServiceConfig, re
 		return result, fmt.Errorf("OpenRouter API key required")
 	}
 
-	model := s.getRandomModel()
+	model := cfg.Model
+	if model == "" {
+		model = s.NextModel()
+	}
+	result.ServiceName = modelResultName("openrouter", model)
 
 	sourceLang := req.SourceLang
 	if sourceLang == "" || sourceLang == "auto" {
 		sourceLang = "the detected language"
 	}
 
-	systemPrompt := buildOpenRouterSystemPrompt(sourceLang, req.TargetLang, req.PreviousContext, req.GlossaryTerms, req.Instructions)
+	systemPrompt, err := s.prompts.Render(prompts.TranslateSystem, promptData(sourceLang, req))
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
 
 	openrouterReq := map[string]interface{}{
 		"model": model,
@@ -89,7 +158,7 @@ func (s *OpenRouterService) Translate(ctx context.Context, cfg ServiceConfig, re
 			{"role": "system", "content": systemPrompt},
 			{"role": "user", "content": req.Text},
 		},
-		"max_tokens": 4096,
+		"max_tokens": OpenRouterMaxTokens,
 	}
 
 	jsonData, err := json.Marshal(openrouterReq)
@@ -128,6 +197,7 @@ func (s *OpenRouterService) Translate(ctx context.Context, cfg ServiceConfig, re
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
 		Usage struct {
 			PromptTokens     int `json:"prompt_tokens"`
@@ -150,9 +220,13 @@ func (s *OpenRouterService) Translate(ctx context.Context, cfg ServiceConfig, re
 	result.Confidence = 0.7
 	result.Metadata = map[string]string{
 		"model":             model,
+		"prompt_template":   s.prompts.ID(prompts.TranslateSystem),
 		"prompt_tokens":     fmt.Sprintf("%d", openrouterResp.Usage.PromptTokens),
 		"completion_tokens": fmt.Sprintf("%d", openrouterResp.Usage.CompletionTokens),
 	}
+	if reason := openrouterResp.Choices[0].FinishReason; reason != "" {
+		result.Metadata["finish_reason"] = reason
+	}
 
 	return result, nil
 }
@@ -171,30 +245,3 @@ func (s *OpenRouterService) SupportedLanguages(ctx context.Context) ([]string, e
 func (s *OpenRouterService) GetModels() []string {
 	return s.models
 }
-
-// buildOpenRouterSystemPrompt constructs the system prompt, optionally
-// injecting glossary terms, a sliding-window context, and extra instructions.
-func buildOpenRouterSystemPrompt(sourceLang, targetLang, previousContext string, glossary map[string]string, instructions string) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("You are a professional translator. Translate the following text from %s to %s.\n", sourceLang, targetLang))
-	sb.WriteString("Only respond with the translation, nothing else. No explanations, no quotes, just the translation.")
-
-	if instructions != "" {
-		sb.WriteString(" ")
-		sb.WriteString(instructions)
-	}
-
-	if len(glossary) > 0 {
-		sb.WriteString("\n\nTERMINOLOGY (use these exact translations):\n")
-		for src, tgt := range glossary {
-			sb.WriteString(fmt.Sprintf("  %s → %s\n", src, tgt))
-		}
-	}
-
-	if previousContext != "" {
-		sb.WriteString(fmt.Sprintf("\n\nCONTEXT (previous passage for continuity — do NOT retranslate this):\n...%s", previousContext))
-	}
-
-	return sb.String()
-}
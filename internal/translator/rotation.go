@@ -0,0 +1,182 @@
+package translator
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotationPolicy selects how a multi-model LLM service chooses a model per call.
+type RotationPolicy string
+
+const (
+	// RotationRandom picks a uniformly random model for every call.
+	RotationRandom RotationPolicy = "random"
+	// RotationRoundRobin cycles through the models in the configured order.
+	RotationRoundRobin RotationPolicy = "round-robin"
+	// RotationWeighted picks a random model with probability proportional to its weight.
+	RotationWeighted RotationPolicy = "weighted"
+	// RotationSticky picks one model for the first call and keeps it for the
+	// rest of the document, so all chunks share one voice.
+	RotationSticky RotationPolicy = "sticky"
+	// RotationFixed always uses the first configured model.
+	RotationFixed RotationPolicy = "fixed"
+	// RotationFanOut runs every configured model as a separate service whose
+	// results appear as independent candidates (see ForModel).
+	RotationFanOut RotationPolicy = "fan-out"
+)
+
+// RotationPolicies lists every accepted policy name, for flag help and validation.
+var RotationPolicies = []RotationPolicy{
+	RotationRandom, RotationRoundRobin, RotationWeighted, RotationSticky, RotationFixed, RotationFanOut,
+}
+
+// ParseRotationPolicy validates a policy name; an empty string means RotationRandom.
+func ParseRotationPolicy(name string) (RotationPolicy, error) {
+	if name == "" {
+		return RotationRandom, nil
+	}
+	for _, p := range RotationPolicies {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown rotation policy %q (valid: %s)", name, joinPolicies())
+}
+
+func joinPolicies() string {
+	names := make([]string, len(RotationPolicies))
+	for i, p := range RotationPolicies {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseWeightedModels splits "model=weight" specs into model names and weights.
+// A spec without "=weight" gets weight 1. The separator is "=" because model
+// names routinely contain ":" (gemma2:27b, …:free).
+func ParseWeightedModels(specs []string) ([]string, []float64, error) {
+	models := make([]string, 0, len(specs))
+	weights := make([]float64, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		model, weight := spec, 1.0
+		if i := strings.LastIndex(spec, "="); i >= 0 {
+			w, err := strconv.ParseFloat(spec[i+1:], 64)
+			if err != nil || w < 0 {
+				return nil, nil, fmt.Errorf("invalid weight in %q", spec)
+			}
+			model, weight = spec[:i], w
+		}
+		models = append(models, model)
+		weights = append(weights, weight)
+	}
+	return models, weights, nil
+}
+
+// ModelRotation chooses a model per call according to a RotationPolicy. It is
+// safe for concurrent use. With a non-zero seed the sequence of choices is
+// reproducible across runs.
+type ModelRotation struct {
+	mu      sync.Mutex
+	policy  RotationPolicy
+	models  []string
+	weights []float64
+	rng     *rand.Rand
+	next    int
+	sticky  string
+}
+
+// NewModelRotation creates a rotation over models. weights may be nil (all
+// equal); otherwise it must have one entry per model. A seed of 0 seeds from
+// the clock.
+func NewModelRotation(policy RotationPolicy, models []string, weights []float64, seed int64) (*ModelRotation, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("model rotation requires at least one model")
+	}
+	if weights != nil && len(weights) != len(models) {
+		return nil, fmt.Errorf("got %d weights for %d models", len(weights), len(models))
+	}
+	if policy == RotationWeighted {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		if weights != nil && total <= 0 {
+			return nil, fmt.Errorf("weighted rotation requires a positive total weight")
+		}
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ModelRotation{
+		policy:  policy,
+		models:  models,
+		weights: weights,
+		rng:     rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// Policy returns the rotation policy.
+func (r *ModelRotation) Policy() RotationPolicy {
+	return r.policy
+}
+
+// Models returns the models being rotated.
+func (r *ModelRotation) Models() []string {
+	return r.models
+}
+
+// Next returns the model to use for the next call.
+func (r *ModelRotation) Next() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.policy {
+	case RotationRoundRobin:
+		m := r.models[r.next%len(r.models)]
+		r.next++
+		return m
+	case RotationWeighted:
+		return r.weightedPick()
+	case RotationSticky:
+		if r.sticky == "" {
+			r.sticky = r.models[r.rng.Intn(len(r.models))]
+		}
+		return r.sticky
+	case RotationFixed, RotationFanOut:
+		return r.models[0]
+	default:
+		return r.models[r.rng.Intn(len(r.models))]
+	}
+}
+
+func (r *ModelRotation) weightedPick() string {
+	if r.weights == nil {
+		return r.models[r.rng.Intn(len(r.models))]
+	}
+	total := 0.0
+	for _, w := range r.weights {
+		total += w
+	}
+	x := r.rng.Float64() * total
+	for i, w := range r.weights {
+		if x < w {
+			return r.models[i]
+		}
+		x -= w
+	}
+	return r.models[len(r.models)-1]
+}
+
+// modelResultName returns the per-model service name recorded on results,
+// e.g. "ollama/gemma2:27b", so the arbiter and reports can tell models apart.
+func modelResultName(provider, model string) string {
+	return provider + "/" + model
+}
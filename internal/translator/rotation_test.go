@@ -0,0 +1,128 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRotationPolicy(t *testing.T) {
+	if p, err := ParseRotationPolicy(""); err != nil || p != RotationRandom {
+		t.Errorf("expected empty name to mean random, got %q, %v", p, err)
+	}
+	if p, err := ParseRotationPolicy("round-robin"); err != nil || p != RotationRoundRobin {
+		t.Errorf("expected round-robin, got %q, %v", p, err)
+	}
+	if _, err := ParseRotationPolicy("lottery"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestParseWeightedModels(t *testing.T) {
+	models, weights, err := ParseWeightedModels([]string{"gemma2:27b=3", "qwen3:14b", "google/gemini:free=0.5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantModels := []string{"gemma2:27b", "qwen3:14b", "google/gemini:free"}
+	wantWeights := []float64{3, 1, 0.5}
+	for i := range wantModels {
+		if models[i] != wantModels[i] || weights[i] != wantWeights[i] {
+			t.Errorf("entry %d: got %s=%v, want %s=%v", i, models[i], weights[i], wantModels[i], wantWeights[i])
+		}
+	}
+
+	if _, _, err := ParseWeightedModels([]string{"gemma2:27b=heavy"}); err == nil {
+		t.Error("expected error for non-numeric weight")
+	}
+}
+
+func TestModelRotation_RoundRobin(t *testing.T) {
+	r, err := NewModelRotation(RotationRoundRobin, []string{"a", "b", "c"}, nil, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"a", "b", "c", "a"}
+	for i, w := range want {
+		if got := r.Next(); got != w {
+			t.Errorf("call %d: got %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestModelRotation_SeedIsReproducible(t *testing.T) {
+	models := []string{"a", "b", "c", "d"}
+	r1, _ := NewModelRotation(RotationRandom, models, nil, 42)
+	r2, _ := NewModelRotation(RotationRandom, models, nil, 42)
+	for i := 0; i < 20; i++ {
+		if a, b := r1.Next(), r2.Next(); a != b {
+			t.Fatalf("call %d: same seed diverged (%q vs %q)", i, a, b)
+		}
+	}
+}
+
+func TestModelRotation_Weighted(t *testing.T) {
+	r, err := NewModelRotation(RotationWeighted, []string{"never", "always"}, []float64{0, 1}, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if got := r.Next(); got != "always" {
+			t.Fatalf("zero-weight model picked on call %d", i)
+		}
+	}
+
+	if _, err := NewModelRotation(RotationWeighted, []string{"a"}, []float64{0}, 1); err == nil {
+		t.Error("expected error for all-zero weights")
+	}
+}
+
+func TestModelRotation_StickyAndFixed(t *testing.T) {
+	sticky, _ := NewModelRotation(RotationSticky, []string{"a", "b", "c"}, nil, 3)
+	first := sticky.Next()
+	for i := 0; i < 10; i++ {
+		if got := sticky.Next(); got != first {
+			t.Fatalf("sticky rotation changed model from %q to %q", first, got)
+		}
+	}
+
+	fixed, _ := NewModelRotation(RotationFixed, []string{"a", "b"}, nil, 3)
+	if got := fixed.Next(); got != "a" {
+		t.Errorf("fixed rotation: got %q, want 'a'", got)
+	}
+}
+
+func TestOllamaTranslator_PerModelResultName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": "Привіт"})
+	}))
+	defer server.Close()
+
+	svc := NewOllamaTranslator(server.URL, nil)
+	rot, _ := NewModelRotation(RotationRoundRobin, []string{"gemma2:27b", "qwen3:14b"}, nil, 1)
+	svc.SetRotation(rot)
+
+	for _, want := range []string{"ollama/gemma2:27b", "ollama/qwen3:14b"} {
+		res, err := svc.Translate(context.Background(), ServiceConfig{}, TranslateRequest{Text: "Hello", TargetLang: "uk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.ServiceName != want {
+			t.Errorf("expected result name %q, got %q", want, res.ServiceName)
+		}
+	}
+}
+
+func TestOllamaTranslator_ForModel(t *testing.T) {
+	svc := NewOllamaTranslator("", []string{"gemma2:27b", "qwen3:14b"})
+
+	pinned := svc.ForModel("qwen3:14b")
+
+	if pinned.Name() != "ollama/qwen3:14b" {
+		t.Errorf("expected name 'ollama/qwen3:14b', got %q", pinned.Name())
+	}
+	if got := pinned.NextModel(); got != "qwen3:14b" {
+		t.Errorf("expected pinned model, got %q", got)
+	}
+}
@@ -3,6 +3,8 @@ package translator
 import (
 	"context"
 	"time"
+
+	"github.com/valpere/peretran/internal/prompts"
 )
 
 type ServiceConfig struct {
@@ -24,6 +26,12 @@ type TranslateRequest struct {
 	// LLM-based services use it to maintain continuity across chunk boundaries.
 	PreviousContext string `json:"previous_context,omitempty"`
 
+	// SourceBefore and SourceAfter hold the source text just before and
+	// after Text (the end of the preceding chunk and the start of the next).
+	// LLM-based services show them as context that must not be translated.
+	SourceBefore string `json:"source_before,omitempty"`
+	SourceAfter  string `json:"source_after,omitempty"`
+
 	// GlossaryTerms maps source terms to required target translations.
 	// LLM-based services inject these into the translation prompt.
 	GlossaryTerms map[string]string `json:"glossary_terms,omitempty"`
@@ -31,6 +39,26 @@ type TranslateRequest struct {
 	// Instructions is an optional extra instruction appended to the LLM prompt,
 	// e.g. a placeholder-preservation hint when placeholder mode is active.
 	Instructions string `json:"instructions,omitempty"`
+
+	// StyleGuide describes the required tone and conventions (see the style
+	// package). LLM-based services inject it into the translation prompt.
+	StyleGuide string `json:"style_guide,omitempty"`
+}
+
+// promptData maps a request onto the prompt template variables. sourceLang
+// is the display form chosen by the service for an undetected language.
+func promptData(sourceLang string, req TranslateRequest) prompts.Data {
+	return prompts.Data{
+		SourceLang:   sourceLang,
+		TargetLang:   req.TargetLang,
+		Source:       req.Text,
+		Context:      req.PreviousContext,
+		SourceBefore: req.SourceBefore,
+		SourceAfter:  req.SourceAfter,
+		Glossary:     req.GlossaryTerms,
+		Instructions: req.Instructions,
+		StyleGuide:   req.StyleGuide,
+	}
 }
 
 type ServiceResult struct {
@@ -48,3 +76,21 @@ type TranslationService interface {
 	IsAvailable(ctx context.Context) error
 	SupportedLanguages(ctx context.Context) ([]string, error)
 }
+
+// ModelRotator is implemented by LLM-backed services that rotate between
+// several models. NextModel advances the rotation and returns the model for
+// the next call; passing a model back via ServiceConfig.Model pins a call to it.
+type ModelRotator interface {
+	GetModels() []string
+	NextModel() string
+}
+
+// SlotAcquirer is implemented by services that bound concurrent requests per
+// model (see ConcurrencyLimiter). The orchestrator acquires a slot before the
+// per-attempt timeout starts, so time spent queueing behind other models is
+// not counted against the call. Hedged requests use TryAcquireSlot instead:
+// a hedge is only worth sending when a slot is free right away.
+type SlotAcquirer interface {
+	AcquireSlot(ctx context.Context, model string) (release func(), err error)
+	TryAcquireSlot(model string) (release func(), ok bool)
+}
@@ -9,3 +9,30 @@ type TranslationRequest struct {
 	TargetLang string    `json:"target_lang"`
 	Timestamp  time.Time `json:"timestamp"`
 }
+
+// RubricScores rates one candidate translation on MQM-inspired dimensions,
+// each from 1 (unusable) to 5 (flawless). A zero dimension was not scored.
+type RubricScores struct {
+	Accuracy    float64 `json:"accuracy"`
+	Fluency     float64 `json:"fluency"`
+	Terminology float64 `json:"terminology"`
+	Style       float64 `json:"style"`
+	Formatting  float64 `json:"formatting"`
+}
+
+// Mean returns the unweighted average of the scored dimensions, or 0 when
+// none was scored.
+func (r RubricScores) Mean() float64 {
+	var sum float64
+	var n int
+	for _, v := range []float64{r.Accuracy, r.Fluency, r.Terminology, r.Style, r.Formatting} {
+		if v != 0 {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
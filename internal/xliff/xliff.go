@@ -0,0 +1,464 @@
+// Package xliff reads XLIFF 1.2 and 2.0 files and fills in their targets.
+// The file is scanned, not rebuilt: only the targets that are written and
+// the attributes that change are replaced, and every other byte is kept.
+//
+// A unit is a 1.2 <trans-unit> or a 2.0 <segment>. Its source reaches
+// translation as text with [PHn] markers for the inline elements: one
+// marker for each start and end tag of <g>, <pc> and <mrk>, whose content
+// is translated, and one for each whole element of the other kinds
+// (<x/>, <ph>, <bpt>, <ept>, <sc/>, <ec/>, ...), which are codes.
+package xliff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var reMarker = regexp.MustCompile(`\[PH(\d+)\]`)
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Document is a parsed XLIFF file.
+type Document struct {
+	src string
+	// Version is the version attribute of <xliff>, e.g. "1.2" or "2.0".
+	Version string
+	// SourceLang is the srcLang of a 2.0 file or the source-language of
+	// the first 1.2 <file>.
+	SourceLang string
+	Units      []*Unit
+
+	// langTags are the tags carrying the target language: <xliff> in
+	// 2.0, each <file> in 1.2.
+	langTags   []span
+	targetLang string
+}
+
+// Unit is one translatable piece: a 1.2 trans-unit or a 2.0 segment.
+type Unit struct {
+	// ID is the id of the trans-unit or unit.
+	ID string
+	// Text is the source text with inline elements as [PHn] markers.
+	Text string
+	// Note holds the notes of the unit, one per line.
+	Note string
+	// State is the state of the existing translation: the state of a 1.2
+	// target or of a 2.0 segment.
+	State string
+	// Translate is false inside translate="no".
+	Translate bool
+	// Approved is true for a 1.2 trans-unit with approved="yes".
+	Approved bool
+
+	v2    bool
+	parts []part
+	// source is the start tag of <source>, sourceEnd the offset after
+	// its end tag.
+	source    span
+	sourceEnd int
+	// target is the whole <target> element and targetTag its start tag;
+	// target.start is -1 when there is none.
+	target, targetTag span
+	// targetInner is the content of the target.
+	targetInner string
+	// segment is the start tag of a 2.0 <segment>.
+	segment span
+	result  string
+	changed bool
+}
+
+type span struct{ start, end int }
+
+// part is what a marker stands for: the raw XML of a start tag, an end
+// tag or a whole code element.
+type part struct {
+	raw  string
+	kind partKind
+	// pair is the index of the other tag of a start or end tag.
+	pair int
+}
+
+type partKind int
+
+const (
+	partOpen partKind = iota
+	partClose
+	partCode
+)
+
+// PlainText returns Text without the markers.
+func (u *Unit) PlainText() string {
+	return strings.Join(strings.Fields(reMarker.ReplaceAllString(u.Text, " ")), " ")
+}
+
+// NeedsTranslation reports whether the unit should be translated: it is
+// translatable, not approved, has letters in its source, and its target
+// is missing, empty or in a state asking for a translation (1.2 "new"
+// and "needs-translation", 2.0 "initial").
+func (u *Unit) NeedsTranslation() bool {
+	if !u.Translate || u.Approved || !strings.ContainsFunc(u.PlainText(), unicode.IsLetter) {
+		return false
+	}
+	if u.target.start < 0 || strings.TrimSpace(u.targetInner) == "" {
+		return true
+	}
+	switch u.State {
+	case "new", "needs-translation":
+		return !u.v2
+	case "initial":
+		return u.v2
+	}
+	return false
+}
+
+// Parse scans an XLIFF 1.2 or 2.0 file.
+func Parse(src string) (*Document, error) {
+	d := &Document{src: src}
+	dec := xml.NewDecoder(strings.NewReader(src))
+
+	type frame struct {
+		name      string
+		translate bool
+	}
+	stack := []frame{{translate: true}}
+	var unit *Unit      // the unit being read
+	var unitFrame *Unit // the 1.2 trans-unit being read
+	var unitID, notes string
+	var inline []int // open paired elements in the source
+	var code, codeStart int
+	var text strings.Builder
+	inSource, inNote := false, false
+	var note strings.Builder
+	targetDepth := 0
+
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XLIFF: %w", err)
+		}
+		end := int(dec.InputOffset())
+		raw := src[start:end]
+
+		if inSource {
+			switch t := tok.(type) {
+			case xml.CharData:
+				if code == 0 {
+					text.WriteString(string(t))
+				}
+			case xml.StartElement:
+				switch {
+				case code > 0:
+					code++
+				case paired(t):
+					inline = append(inline, len(unit.parts))
+					text.WriteString(unit.addPart(raw, partOpen))
+				default:
+					code, codeStart = 1, start
+				}
+			case xml.EndElement:
+				switch {
+				case code > 0:
+					if code--; code == 0 {
+						text.WriteString(unit.addPart(src[codeStart:end], partCode))
+					}
+				case len(inline) > 0:
+					open := inline[len(inline)-1]
+					inline = inline[:len(inline)-1]
+					text.WriteString(unit.addPart(raw, partClose))
+					unit.parts[open].pair = len(unit.parts) - 1
+					unit.parts[len(unit.parts)-1].pair = open
+				default:
+					inSource = false
+					unit.Text = text.String()
+					unit.sourceEnd = end
+					stack = stack[:len(stack)-1]
+				}
+			default:
+				if code == 0 {
+					text.WriteString(unit.addPart(raw, partCode))
+				}
+			}
+			continue
+		}
+
+		if targetDepth > 0 {
+			switch tok.(type) {
+			case xml.StartElement:
+				targetDepth++
+			case xml.EndElement:
+				if targetDepth--; targetDepth == 0 {
+					unit.target.end = end
+					unit.targetInner = src[unit.targetTag.end:start]
+					stack = stack[:len(stack)-1]
+				}
+			}
+			continue
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := stack[len(stack)-1]
+			f := frame{name: t.Name.Local, translate: parent.translate}
+			switch attr(t, "translate") {
+			case "no":
+				f.translate = false
+			case "yes":
+				f.translate = true
+			}
+			stack = append(stack, f)
+
+			switch {
+			case f.name == "xliff":
+				d.Version = attr(t, "version")
+				if !strings.HasPrefix(d.Version, "1.") {
+					d.SourceLang = attr(t, "srcLang")
+					d.langTags = append(d.langTags, span{start, end})
+				}
+			case f.name == "file" && strings.HasPrefix(d.Version, "1."):
+				if d.SourceLang == "" {
+					d.SourceLang = attr(t, "source-language")
+				}
+				d.langTags = append(d.langTags, span{start, end})
+			case f.name == "trans-unit":
+				unit = &Unit{ID: attr(t, "id"), Translate: f.translate, Approved: attr(t, "approved") == "yes", target: span{-1, -1}}
+				unitFrame = unit
+				d.Units = append(d.Units, unit)
+			case f.name == "unit":
+				unitID, notes = attr(t, "id"), ""
+				unitFrame = nil
+			case f.name == "segment" && parent.name == "unit":
+				unit = &Unit{ID: unitID, Note: notes, State: attr(t, "state"), Translate: f.translate, v2: true, target: span{-1, -1}, segment: span{start, end}}
+				d.Units = append(d.Units, unit)
+			case f.name == "source" && (parent.name == "trans-unit" || parent.name == "segment"):
+				if strings.HasSuffix(raw, "/>") {
+					break
+				}
+				unit.source = span{start, end}
+				inSource, code, inline = true, 0, nil
+				text.Reset()
+			case f.name == "target" && (parent.name == "trans-unit" || parent.name == "segment"):
+				unit.target, unit.targetTag = span{start, end}, span{start, end}
+				if !unit.v2 {
+					unit.State = attr(t, "state")
+				}
+				if !strings.HasSuffix(raw, "/>") {
+					targetDepth = 1
+				}
+			case f.name == "note":
+				inNote = true
+				note.Reset()
+			}
+		case xml.CharData:
+			if inNote {
+				note.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			if t.Name.Local == "trans-unit" {
+				unitFrame = nil
+			}
+			if t.Name.Local == "note" && inNote {
+				inNote = false
+				// 1.2 notes follow the source inside the trans-unit;
+				// 2.0 notes come before the segments of the unit.
+				if unitFrame != nil {
+					unitFrame.Note = strings.TrimSpace(unitFrame.Note + "\n" + note.String())
+				} else {
+					notes = strings.TrimSpace(notes + "\n" + note.String())
+				}
+			}
+		}
+	}
+	return d, nil
+}
+
+// addPart records raw as a part and returns its marker.
+func (u *Unit) addPart(raw string, kind partKind) string {
+	u.parts = append(u.parts, part{raw: raw, kind: kind, pair: -1})
+	return fmt.Sprintf("[PH%d]", len(u.parts)-1)
+}
+
+// paired reports whether an inline element has translatable content:
+// <g>, <pc> and <mrk>, unless marked translate="no" or protected.
+func paired(e xml.StartElement) bool {
+	switch e.Name.Local {
+	case "g", "pc":
+		return true
+	case "mrk":
+		return attr(e, "translate") != "no" && attr(e, "mtype") != "protected"
+	}
+	return false
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Apply sets the translation of unit i. It fails when a marker is lost,
+// repeated or unknown, or when the markers of an element's start and end
+// tags are out of order, which would break the XML.
+func (d *Document) Apply(i int, translation string) error {
+	u := d.Units[i]
+	core := strings.TrimSpace(u.Text)
+	lead := u.Text[:strings.Index(u.Text, core)]
+	trail := u.Text[len(lead)+len(core):]
+	translation = lead + strings.TrimSpace(translation) + trail
+
+	seen := make([]bool, len(u.parts))
+	var open []int
+	var b strings.Builder
+	last := 0
+	for _, loc := range reMarker.FindAllStringSubmatchIndex(translation, -1) {
+		n, _ := strconv.Atoi(translation[loc[2]:loc[3]])
+		if n >= len(u.parts) {
+			return fmt.Errorf("translation has unknown tag placeholder [PH%d]", n)
+		}
+		if seen[n] {
+			return fmt.Errorf("translation repeats tag placeholder [PH%d]", n)
+		}
+		seen[n] = true
+		p := u.parts[n]
+		switch p.kind {
+		case partOpen:
+			open = append(open, n)
+		case partClose:
+			if len(open) == 0 || open[len(open)-1] != p.pair {
+				return fmt.Errorf("translation moved tag placeholder [PH%d] out of its element", n)
+			}
+			open = open[:len(open)-1]
+		}
+		b.WriteString(escaper.Replace(translation[last:loc[0]]))
+		b.WriteString(p.raw)
+		last = loc[1]
+	}
+	b.WriteString(escaper.Replace(translation[last:]))
+
+	missing := 0
+	for _, ok := range seen {
+		if !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("translation lost %d of %d tag placeholder(s)", missing, len(u.parts))
+	}
+	u.result, u.changed = b.String(), true
+	return nil
+}
+
+// SetTargetLang sets the target language of the file: trgLang in 2.0,
+// target-language of each <file> in 1.2.
+func (d *Document) SetTargetLang(lang string) {
+	d.targetLang = lang
+}
+
+// Render returns the file with the translations applied. A 1.2 target
+// gets state="needs-review-translation"; a 2.0 segment, which has no
+// review state, gets state="translated".
+func (d *Document) Render() string {
+	type edit struct {
+		span
+		text string
+	}
+	var edits []edit
+	if d.targetLang != "" {
+		name := "target-language"
+		if !strings.HasPrefix(d.Version, "1.") {
+			name = "trgLang"
+		}
+		for _, t := range d.langTags {
+			edits = append(edits, edit{t, setAttr(d.src[t.start:t.end], name, d.targetLang)})
+		}
+	}
+	for _, u := range d.Units {
+		if !u.changed {
+			continue
+		}
+		if u.v2 {
+			edits = append(edits, edit{u.segment, setAttr(d.src[u.segment.start:u.segment.end], "state", "translated")})
+		}
+		if u.target.start >= 0 {
+			tag := d.src[u.targetTag.start:u.targetTag.end]
+			name := tagName(tag)
+			if strings.HasSuffix(tag, "/>") {
+				tag = strings.TrimRight(tag[:len(tag)-2], " \t\r\n") + ">"
+			}
+			if !u.v2 {
+				tag = setAttr(tag, "state", "needs-review-translation")
+			}
+			edits = append(edits, edit{u.target, tag + u.result + "</" + name + ">"})
+			continue
+		}
+		name := strings.TrimSuffix(tagName(d.src[u.source.start:u.source.end]), "source") + "target"
+		tag := "<" + name + ">"
+		if !u.v2 {
+			tag = "<" + name + ` state="needs-review-translation">`
+		}
+		edits = append(edits, edit{span{u.sourceEnd, u.sourceEnd}, d.indent(u.source.start) + tag + u.result + "</" + name + ">"})
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(d.src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(d.src[last:])
+	return b.String()
+}
+
+// indent returns the line break and indentation before the tag at
+// offset, or "" when the tag does not start its line.
+func (d *Document) indent(offset int) string {
+	lineStart := strings.LastIndexByte(d.src[:offset], '\n') + 1
+	ws := d.src[lineStart:offset]
+	if lineStart == 0 || strings.TrimSpace(ws) != "" {
+		return ""
+	}
+	nl := "\n"
+	if lineStart >= 2 && d.src[lineStart-2] == '\r' {
+		nl = "\r\n"
+	}
+	return nl + ws
+}
+
+// tagName returns the qualified name of a start tag.
+func tagName(tag string) string {
+	name := strings.TrimPrefix(tag, "<")
+	if i := strings.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// setAttr sets an attribute of a start tag, adding it when missing.
+func setAttr(tag, name, value string) string {
+	re := regexp.MustCompile(`(\s` + regexp.QuoteMeta(name) + `\s*=\s*)(?:"[^"]*"|'[^']*')`)
+	if loc := re.FindStringSubmatchIndex(tag); loc != nil {
+		return tag[:loc[3]] + `"` + value + `"` + tag[loc[1]:]
+	}
+	end := len(tag) - 1
+	if strings.HasSuffix(tag, "/>") {
+		end--
+	}
+	return tag[:end] + " " + name + `="` + value + `"` + tag[end:]
+}
@@ -0,0 +1,173 @@
+package xliff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valpere/peretran/internal/xliff"
+)
+
+const sample12 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file source-language="en" datatype="plaintext" original="app">
+    <body>
+      <trans-unit id="greeting">
+        <source>Hello, <g id="1">dear</g> user<x id="2"/>!</source>
+        <note>Shown on the start page</note>
+      </trans-unit>
+      <trans-unit id="count">
+        <source>You have <ph id="1">{{count}}</ph> new &amp; unread messages</source>
+        <target state="new"></target>
+      </trans-unit>
+      <trans-unit id="done">
+        <source>Done</source>
+        <target state="translated">Готово</target>
+      </trans-unit>
+      <trans-unit id="brand" translate="no">
+        <source>AcmeCloud</source>
+      </trans-unit>
+      <trans-unit id="approved" approved="yes">
+        <source>Cancel</source>
+        <target/>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+
+const sample20 = `<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en">
+ <file id="f1">
+  <unit id="u1">
+   <notes><note>Button label</note></notes>
+   <segment>
+    <source>Save <pc id="1">all</pc> files</source>
+   </segment>
+   <segment state="initial">
+    <source>Close <mrk id="m1" translate="no">AcmeCloud</mrk><ph id="2"/></source>
+    <target>stale</target>
+   </segment>
+  </unit>
+  <unit id="u2" translate="no">
+   <segment><source>Keep</source></segment>
+  </unit>
+ </file>
+</xliff>
+`
+
+func parse(t *testing.T, src string) *xliff.Document {
+	t.Helper()
+	d, err := xliff.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParse_12(t *testing.T) {
+	d := parse(t, sample12)
+	if d.Version != "1.2" || d.SourceLang != "en" || len(d.Units) != 5 {
+		t.Fatalf("got version %q, source %q, %d units", d.Version, d.SourceLang, len(d.Units))
+	}
+	want := []struct {
+		id, text, note string
+		needs          bool
+	}{
+		{"greeting", "Hello, [PH0]dear[PH1] user[PH2]!", "Shown on the start page", true},
+		{"count", "You have [PH0] new & unread messages", "", true},
+		{"done", "Done", "", false},
+		{"brand", "AcmeCloud", "", false},
+		{"approved", "Cancel", "", false},
+	}
+	for i, w := range want {
+		u := d.Units[i]
+		if u.ID != w.id || u.Text != w.text || u.Note != w.note || u.NeedsTranslation() != w.needs {
+			t.Errorf("unit %d = (%q, %q, %q, %v), want %v", i, u.ID, u.Text, u.Note, u.NeedsTranslation(), w)
+		}
+	}
+}
+
+func TestRender_12(t *testing.T) {
+	d := parse(t, sample12)
+	if got := d.Render(); got != sample12 {
+		t.Errorf("Render without changes altered the file:\n%s", got)
+	}
+	if err := d.Apply(0, "Привіт, [PH0]шановний[PH1] користувачу[PH2]!"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Apply(1, "У вас [PH0] нових & непрочитаних повідомлень"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Apply(4, "Скасувати"); err != nil {
+		t.Fatal(err)
+	}
+	d.SetTargetLang("uk")
+	out := d.Render()
+	for _, want := range []string{
+		`<target state="needs-review-translation">Скасувати</target>`,
+		`<file source-language="en" datatype="plaintext" original="app" target-language="uk">`,
+		"<source>Hello, <g id=\"1\">dear</g> user<x id=\"2\"/>!</source>\n" +
+			`        <target state="needs-review-translation">Привіт, <g id="1">шановний</g> користувачу<x id="2"/>!</target>` + "\n" +
+			"        <note>",
+		`<target state="needs-review-translation">У вас <ph id="1">{{count}}</ph> нових &amp; непрочитаних повідомлень</target>`,
+		`<target state="translated">Готово</target>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s\n%s", want, out)
+		}
+	}
+}
+
+func TestParse_20(t *testing.T) {
+	d := parse(t, sample20)
+	if d.Version != "2.0" || d.SourceLang != "en" || len(d.Units) != 3 {
+		t.Fatalf("got version %q, source %q, %d units", d.Version, d.SourceLang, len(d.Units))
+	}
+	u := d.Units[0]
+	if u.ID != "u1" || u.Text != "Save [PH0]all[PH1] files" || u.Note != "Button label" || !u.NeedsTranslation() {
+		t.Errorf("unit 0 = %+v", u)
+	}
+	u = d.Units[1]
+	if u.Text != "Close [PH0][PH1]" || !u.NeedsTranslation() {
+		t.Errorf("unit 1 = %+v", u)
+	}
+	if d.Units[2].NeedsTranslation() {
+		t.Error("translate=\"no\" is inherited from the unit")
+	}
+
+	if err := d.Apply(0, "Зберегти [PH0]усі[PH1] файли"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Apply(1, "Закрити [PH0][PH1]"); err != nil {
+		t.Fatal(err)
+	}
+	d.SetTargetLang("uk")
+	out := d.Render()
+	for _, want := range []string{
+		`version="2.0" srcLang="en" trgLang="uk">`,
+		"<segment state=\"translated\">\n    <source>Save <pc id=\"1\">all</pc> files</source>\n    <target>Зберегти <pc id=\"1\">усі</pc> файли</target>\n   </segment>",
+		`<segment state="translated">`,
+		`<target>Закрити <mrk id="m1" translate="no">AcmeCloud</mrk><ph id="2"/></target>`,
+		`<segment><source>Keep</source></segment>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s\n%s", want, out)
+		}
+	}
+}
+
+func TestApply_RejectsBrokenMarkers(t *testing.T) {
+	d := parse(t, sample12)
+	for _, tr := range []string{
+		"Привіт, шановний[PH1] користувачу[PH2]!",
+		"Привіт, [PH1]шановний[PH0] користувачу[PH2]!",
+		"Привіт, [PH0]шановний[PH1] [PH2]користувачу[PH2]!",
+		"Привіт, [PH0]шановний[PH1] користувачу[PH2][PH7]!",
+	} {
+		if err := d.Apply(0, tr); err == nil {
+			t.Errorf("Apply(%q) should fail", tr)
+		}
+	}
+	if got := d.Render(); got != sample12 {
+		t.Error("a rejected translation changed the file")
+	}
+}